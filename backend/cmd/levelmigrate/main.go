@@ -0,0 +1,50 @@
+package main
+
+import (
+	"backend/internal/service"
+	"backend/pkg/database"
+	"flag"
+	"fmt"
+)
+
+// 等级曲线 (LEVEL_CURVES_FILE) 新增版本后，把用户切换到新曲线并列出等级变化的用户
+//
+//	LEVEL_CURVES_FILE=levels.json go run ./cmd/levelmigrate -dry-run   # 只打印变化
+//	LEVEL_CURVES_FILE=levels.json go run ./cmd/levelmigrate -user <uuid>
+func main() {
+	userID := flag.String("user", "", "只处理指定用户 (默认全部用户)")
+	dryRun := flag.Bool("dry-run", false, "只打印变化，不写入")
+	flag.Parse()
+
+	database.InitDB()
+
+	var userIDs []string
+	if *userID != "" {
+		userIDs = []string{*userID}
+	}
+
+	report, err := service.MigrateLevelCurve(userIDs, *dryRun)
+	if err != nil {
+		fmt.Printf("❌ 迁移失败: %v\n", err)
+		return
+	}
+
+	up, down := 0, 0
+	for _, m := range report.Moved {
+		arrow := "⬆️"
+		if m.NewLevel < m.OldLevel {
+			arrow = "⬇️"
+			down++
+		} else {
+			up++
+		}
+		fmt.Printf("%s %s (%s) v%d Lv.%d -> v%d Lv.%d (XP %d)\n",
+			arrow, m.Nickname, m.UserID, m.OldVersion, m.OldLevel, report.Version, m.NewLevel, m.TotalXP)
+	}
+
+	mode := "已写入"
+	if *dryRun {
+		mode = "dry-run，未写入"
+	}
+	fmt.Printf("\n✅ 完成：曲线版本 v%d，共检查 %d 个用户，%d 人升级，%d 人降级 (%s)\n", report.Version, report.Users, up, down, mode)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"backend/internal/model"
+	"backend/internal/service"
+	"backend/pkg/database"
+	"flag"
+	"fmt"
+)
+
+// 按用户时区重新归档 DailyStat
+//
+//	go run ./cmd/rebucket -dry-run          # 只打印差异
+//	go run ./cmd/rebucket -user <uuid>      # 只处理一个用户
+func main() {
+	userID := flag.String("user", "", "只处理指定用户 (默认全部用户)")
+	dryRun := flag.Bool("dry-run", false, "只打印差异，不写库")
+	flag.Parse()
+
+	database.InitDB()
+
+	var userIDs []string
+	if *userID != "" {
+		userIDs = []string{*userID}
+	} else {
+		database.DB.Model(&model.User{}).Order("created_at ASC").Pluck("id", &userIDs)
+	}
+
+	changedUsers := 0
+	for _, id := range userIDs {
+		report, err := service.RebucketDailyStats(id, *dryRun)
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", id, err)
+			continue
+		}
+		if len(report.Changes) == 0 {
+			continue
+		}
+
+		changedUsers++
+		fmt.Printf("👤 %s (%s -> %s)\n", report.UserID, report.FromTimezone, report.ToTimezone)
+		for _, c := range report.Changes {
+			fmt.Printf("   %s: %d -> %d\n", c.Date.Format("2006-01-02"), c.Before, c.After)
+		}
+	}
+
+	mode := "已写入"
+	if *dryRun {
+		mode = "dry-run，未写入"
+	}
+	fmt.Printf("\n✅ 完成：共检查 %d 个用户，%d 个用户有变化 (%s)\n", len(userIDs), changedUsers, mode)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"backend/internal/service"
+	"backend/pkg/database"
+	"flag"
+	"fmt"
+)
+
+// 按学习会话与经验流水重建 DailyStat、UserTagStat 以及 Redis 总榜 / 周榜 (Redis 被清空或数据漂移时使用)
+//
+//	go run ./cmd/rebuild -dry-run          # 只打印差异
+//	go run ./cmd/rebuild -user <uuid>      # 只处理一个用户
+func main() {
+	userID := flag.String("user", "", "只处理指定用户 (默认全部用户，并清理排行榜中已删除的用户)")
+	dryRun := flag.Bool("dry-run", false, "只打印差异，不写入")
+	flag.Parse()
+
+	database.InitDB()
+	database.InitRedis()
+
+	var userIDs []string
+	if *userID != "" {
+		userIDs = []string{*userID}
+	}
+
+	report, err := service.RebuildAggregates(userIDs, *dryRun)
+	if err != nil {
+		fmt.Printf("❌ 重建失败: %v\n", err)
+		return
+	}
+
+	lastUser := ""
+	for _, d := range report.Drifts {
+		if d.UserID != lastUser {
+			fmt.Printf("👤 %s\n", d.UserID)
+			lastUser = d.UserID
+		}
+		if d.Key != "" {
+			fmt.Printf("   %s [%s] %s: %d -> %d\n", d.Target, d.Key, d.Field, d.Before, d.After)
+		} else {
+			fmt.Printf("   %s %s: %d -> %d\n", d.Target, d.Field, d.Before, d.After)
+		}
+	}
+
+	mode := "已写入"
+	if *dryRun {
+		mode = "dry-run，未写入"
+	}
+	fmt.Printf("\n✅ 完成：共检查 %d 个用户，%d 处差异 (%s)\n", report.Users, len(report.Drifts), mode)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"backend/internal/service"
+	"backend/pkg/database"
+	"flag"
+	"fmt"
+	"time"
+)
+
+// 创建排行榜赛季 (日期按服务器时区，含两端)
+//
+//	go run ./cmd/season -name "S1" -start 2025-01-01 -end 2025-03-31
+//	go run ./cmd/season -list
+func main() {
+	name := flag.String("name", "", "赛季名称")
+	start := flag.String("start", "", "开始日期 YYYY-MM-DD")
+	end := flag.String("end", "", "结束日期 YYYY-MM-DD")
+	list := flag.Bool("list", false, "列出所有赛季")
+	flag.Parse()
+
+	database.InitDB()
+
+	rankingService := &service.RankingService{}
+	if *list {
+		seasons, err := rankingService.GetSeasons()
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		for _, s := range seasons {
+			fmt.Printf("🏆 %s  %s ~ %s  (%s)\n", s.Name, s.StartDate, s.EndDate, s.ID)
+		}
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", *start)
+	if err != nil {
+		fmt.Println("❌ 无效的开始日期，格式为 YYYY-MM-DD")
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", *end)
+	if err != nil {
+		fmt.Println("❌ 无效的结束日期，格式为 YYYY-MM-DD")
+		return
+	}
+
+	season, err := rankingService.CreateSeason(*name, startDate, endDate)
+	if err != nil {
+		fmt.Printf("❌ 创建失败: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ 已创建赛季 %s (%s)\n", season.Name, season.ID)
+	if startDate.Before(time.Now()) {
+		fmt.Println("💡 赛季已经开始，执行 go run ./cmd/rebuild 把已有会话计入赛季榜")
+	}
+}
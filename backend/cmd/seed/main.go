@@ -246,8 +246,9 @@ func createBulkUsers(count int, tags []model.Tag) []model.User {
 				continue
 			}
 			usedTags[idx] = true
+			mins := rand.Intn(3000) + 100
 			tagStats = append(tagStats, model.UserTagStat{
-				UserID: u.ID, TagID: tags[idx].ID, TotalMinutes: rand.Intn(3000) + 100,
+				UserID: u.ID, TagID: tags[idx].ID, TotalMinutes: mins, XP: mins,
 			})
 		}
 		if len(tagStats) >= 200 {
@@ -311,7 +312,7 @@ func generateStudyData(me model.User, users []model.User, tags []model.Tag) {
 				dateStr := date.Format("2006-01-02")
 				dailyMap[dateStr] += mins
 				dailyStatsBatch = append(dailyStatsBatch, model.DailyStat{
-					UserID: u.ID, Date: date, TotalMinutes: mins,
+					UserID: u.ID, Date: date, TotalMinutes: mins, XP: mins,
 				})
 			}
 		}
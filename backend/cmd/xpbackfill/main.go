@@ -0,0 +1,48 @@
+package main
+
+import (
+	"backend/internal/model"
+	"backend/internal/service"
+	"backend/pkg/database"
+	"flag"
+	"fmt"
+)
+
+// 为引入经验流水 (XPEvent) 之前的历史数据补写流水，并把 DailyStat 拆分为学习分钟与经验
+// 已有流水的来源不会重复发放，上线后随时可以执行
+//
+//	go run ./cmd/xpbackfill -dry-run          # 只打印结果，不写库
+//	go run ./cmd/xpbackfill -user <uuid>      # 只处理一个用户
+func main() {
+	userID := flag.String("user", "", "只处理指定用户 (默认全部用户)")
+	dryRun := flag.Bool("dry-run", false, "只打印结果，不写库")
+	flag.Parse()
+
+	database.InitDB()
+
+	var userIDs []string
+	if *userID != "" {
+		userIDs = []string{*userID}
+	} else {
+		database.DB.Model(&model.User{}).Order("created_at ASC").Pluck("id", &userIDs)
+	}
+
+	migrated := 0
+	for _, id := range userIDs {
+		report, err := service.BackfillXPLedger(id, *dryRun)
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", id, err)
+			continue
+		}
+		migrated++
+		fmt.Printf("👤 %s: %d 个会话 %d 分钟, 已有流水 %d 条, 补发经验 %d (学习 %d / 博客 %d / 点赞 %d / 收藏 %d), 删除空行 %d\n",
+			report.UserID, report.Sessions, report.StudyMinutes, report.ExistingEvents, report.TotalXP(),
+			report.StudyXP, report.BlogXP, report.LikeXP, report.BookmarkXP, report.DeletedEmpties)
+	}
+
+	mode := "已写入"
+	if *dryRun {
+		mode = "dry-run，未写入"
+	}
+	fmt.Printf("\n✅ 完成：共检查 %d 个用户，回填 %d 个用户 (%s)\n", len(userIDs), migrated, mode)
+}
@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+// AchievementResponse 成就 (目录项或已解锁的成就)
+type AchievementResponse struct {
+	Code        string     `json:"code"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Icon        string     `json:"icon"`
+	Threshold   int        `json:"threshold"`
+	Progress    *int       `json:"progress,omitempty"` // 当前进度 (只在查询自己的成就时返回)
+	Unlocked    bool       `json:"unlocked"`
+	UnlockedAt  *time.Time `json:"unlockedAt"`
+}
+
+// Socket Event: achievement_unlocked
+type AchievementUnlockedEvent struct {
+	Achievement AchievementResponse `json:"achievement"`
+}
@@ -0,0 +1,46 @@
+package dto
+
+import (
+	"backend/internal/model"
+	"time"
+)
+
+// SessionFlagQuery 反作弊审核队列查询参数
+type SessionFlagQuery struct {
+	Status   string `form:"status"` // pending | approved | rejected，默认 pending
+	UserID   string `form:"userId"`
+	Page     int    `form:"page,default=1"`
+	PageSize int    `form:"pageSize,default=20"`
+}
+
+// ReviewSessionFlagRequest 审核一条反作弊记录
+type ReviewSessionFlagRequest struct {
+	Action   string `json:"action" binding:"required,oneof=approve reject"`
+	RevokeXP bool   `json:"revokeXp"` // 驳回时是否同时撤销该会话获得的经验
+	Note     string `json:"note" binding:"max=500"`
+}
+
+type SessionFlagResponse struct {
+	ID              string                    `json:"id"`
+	SessionID       string                    `json:"sessionId"`
+	UserID          string                    `json:"userId"`
+	Nickname        string                    `json:"nickname"`
+	Rules           []string                  `json:"rules"`
+	Details         string                    `json:"details"`
+	Status          model.SessionReviewStatus `json:"status"`
+	XPRevoked       bool                      `json:"xpRevoked"`
+	StartTime       time.Time                 `json:"startTime"`
+	EndTime         *time.Time                `json:"endTime"`
+	DurationMinutes *int                      `json:"durationMinutes"`
+	ReviewerID      *string                   `json:"reviewerId"`
+	ReviewNote      string                    `json:"reviewNote"`
+	ReviewedAt      *time.Time                `json:"reviewedAt"`
+	CreatedAt       time.Time                 `json:"createdAt"`
+}
+
+type SessionFlagListResponse struct {
+	Items    []SessionFlagResponse `json:"items"`
+	Total    int64                 `json:"total"`
+	Page     int                   `json:"page"`
+	PageSize int                   `json:"pageSize"`
+}
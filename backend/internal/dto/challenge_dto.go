@@ -0,0 +1,60 @@
+package dto
+
+import (
+	"backend/internal/model"
+	"time"
+)
+
+type CreateChallengeRequest struct {
+	Title         string              `json:"title" binding:"required,max=100"`
+	Mode          model.ChallengeMode `json:"mode" binding:"required,oneof=competitive collective"`
+	TagName       string              `json:"tagName"`                                 // 可选，只统计该标签
+	TargetMinutes int                 `json:"targetMinutes" binding:"omitempty,min=1"` // 协作挑战必填
+	StartTime     *time.Time          `json:"startTime"`                               // 默认立即开始
+	EndTime       time.Time           `json:"endTime" binding:"required"`
+	FriendIDs     []string            `json:"friendIds" binding:"omitempty,max=50,dive,uuid"` // 创建时邀请的好友
+}
+
+type InviteChallengeRequest struct {
+	FriendIDs []string `json:"friendIds" binding:"required,min=1,max=50,dive,uuid"`
+}
+
+type RespondChallengeRequest struct {
+	Action string `json:"action" binding:"required,oneof=accept decline"`
+}
+
+type ChallengeQuery struct {
+	Status string `form:"status" binding:"omitempty,oneof=active closed"`
+}
+
+// ChallengeStanding 挑战榜单中的一行
+type ChallengeStanding struct {
+	Rank    int                              `json:"rank"` // 未接受邀请的为 0
+	User    UserSimple                       `json:"user"`
+	Status  model.ChallengeParticipantStatus `json:"status"`
+	Minutes int                              `json:"minutes"`
+}
+
+type ChallengeResponse struct {
+	ID            string                           `json:"id"`
+	Title         string                           `json:"title"`
+	Mode          model.ChallengeMode              `json:"mode"`
+	Creator       UserSimple                       `json:"creator"`
+	TagID         *string                          `json:"tagId"`
+	TagName       string                           `json:"tagName,omitempty"`
+	TargetMinutes int                              `json:"targetMinutes"`
+	StartTime     time.Time                        `json:"startTime"`
+	EndTime       time.Time                        `json:"endTime"`
+	Status        model.ChallengeStatus            `json:"status"`
+	TotalMinutes  int                              `json:"totalMinutes"`
+	Progress      float64                          `json:"progress"` // 协作挑战的完成度 0.0 - 1.0
+	Completed     bool                             `json:"completed"`
+	ClosedAt      *time.Time                       `json:"closedAt"`
+	MyStatus      model.ChallengeParticipantStatus `json:"myStatus"`
+	Standings     []ChallengeStanding              `json:"standings"` // 进行中为实时榜单，结束后为最终成绩
+}
+
+// Socket Event: challenge_updated / challenge_closed
+type ChallengeUpdatedEvent struct {
+	Challenge ChallengeResponse `json:"challenge"`
+}
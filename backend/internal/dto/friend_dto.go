@@ -48,14 +48,15 @@ type FriendRequestListResponse struct {
 
 // FriendItem 用于好友列表
 type FriendItem struct {
-	ID        string  `json:"id"` // 对方的 UserID
-	Nickname  string  `json:"nickname"`
-	AvatarURL *string `json:"avatarUrl"`
-	Bio       *string `json:"bio"`
-	IsOnline  bool    `json:"isOnline"`
-	Status    string  `json:"status"` // 'idle', 'learning', 'rest'
-	RoomID    *string `json:"roomId"`
-	RoomName  *string `json:"roomName"`
+	ID        string               `json:"id"` // 对方的 UserID
+	Nickname  string               `json:"nickname"`
+	AvatarURL *string              `json:"avatarUrl"`
+	Bio       *string              `json:"bio"`
+	IsOnline  bool                 `json:"isOnline"`
+	Presence  model.PresenceStatus `json:"presence"` // offline, online, away, studying
+	Status    string               `json:"status"`   // 'idle', 'learning', 'rest'
+	RoomID    *string              `json:"roomId"`
+	RoomName  *string              `json:"roomName"`
 }
 
 type FriendListResponse struct {
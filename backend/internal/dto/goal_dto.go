@@ -0,0 +1,58 @@
+package dto
+
+import (
+	"backend/internal/model"
+	"time"
+)
+
+type CreateGoalRequest struct {
+	Period        model.GoalPeriod `json:"period" binding:"required,oneof=daily weekly"`
+	TargetMinutes int              `json:"targetMinutes" binding:"required,min=1,max=10080"`
+	TagID         string           `json:"tagId"`   // 可选：只统计某个标签
+	TagName       string           `json:"tagName"` // 可选：按名称指定标签
+}
+
+type UpdateGoalRequest struct {
+	TargetMinutes *int  `json:"targetMinutes" binding:"omitempty,min=1,max=10080"`
+	IsActive      *bool `json:"isActive"`
+}
+
+type GoalHistoryQuery struct {
+	GoalID   string `form:"goalId"` // 为空时返回全部目标的历史
+	Page     int    `form:"page,default=1"`
+	PageSize int    `form:"pageSize,default=20"`
+}
+
+// GoalProgress 目标在当前周期的进度
+type GoalProgress struct {
+	GoalID        string           `json:"goalId"`
+	Period        model.GoalPeriod `json:"period"`
+	TagID         *string          `json:"tagId"`
+	TagName       string           `json:"tagName,omitempty"`
+	TargetMinutes int              `json:"targetMinutes"`
+	ActualMinutes int              `json:"actualMinutes"`
+	Progress      float64          `json:"progress"` // 0.0 - 1.0
+	Met           bool             `json:"met"`
+	IsActive      bool             `json:"isActive"`
+	PeriodStart   string           `json:"periodStart"` // "2025-08-17"
+	PeriodEnd     string           `json:"periodEnd"`
+}
+
+type GoalRecordResponse struct {
+	GoalID        string           `json:"goalId"`
+	Period        model.GoalPeriod `json:"period"`
+	TagID         *string          `json:"tagId"`
+	PeriodStart   string           `json:"periodStart"`
+	PeriodEnd     string           `json:"periodEnd"`
+	TargetMinutes int              `json:"targetMinutes"`
+	ActualMinutes int              `json:"actualMinutes"`
+	Met           bool             `json:"met"`
+	CreatedAt     time.Time        `json:"createdAt"`
+}
+
+type GoalHistoryResponse struct {
+	Items    []GoalRecordResponse `json:"items"`
+	Total    int64                `json:"total"`
+	Page     int                  `json:"page"`
+	PageSize int                  `json:"pageSize"`
+}
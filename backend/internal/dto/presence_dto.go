@@ -0,0 +1,23 @@
+package dto
+
+import "backend/internal/model"
+
+// FriendPresence 好友的在线状态
+type FriendPresence struct {
+	UserID string               `json:"userId"`
+	Status model.PresenceStatus `json:"status"` // offline, online, away, studying
+	RoomID *string              `json:"roomId"` // 所在的自习室
+}
+
+// --- Socket Event DTOs ---
+
+// Client -> Server: set_presence
+type SetPresencePayload struct {
+	Status model.PresenceStatus `json:"status"` // online 或 away，studying 由进行中的会话决定
+}
+
+// event: presence_updated (发给所有好友)
+type PresenceUpdatedEvent struct {
+	UserID string               `json:"userId"`
+	Status model.PresenceStatus `json:"status"`
+}
@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"backend/internal/model"
+	"time"
+)
+
+type QuestResponse struct {
+	ID          string           `json:"id"`
+	Code        string           `json:"code"`
+	Title       string           `json:"title"`
+	Period      model.GoalPeriod `json:"period"`
+	TagID       *string          `json:"tagId"`
+	Target      int              `json:"target"`
+	Progress    int              `json:"progress"`
+	RewardXP    int              `json:"rewardXP"`
+	Completed   bool             `json:"completed"`
+	CompletedAt *time.Time       `json:"completedAt"`
+	PeriodStart string           `json:"periodStart"` // "2025-08-17"
+	PeriodEnd   string           `json:"periodEnd"`
+}
+
+// QuestListResponse 当前周期的任务
+type QuestListResponse struct {
+	Daily  []QuestResponse `json:"daily"`
+	Weekly []QuestResponse `json:"weekly"`
+}
+
+// Socket Event: quest_progress / quest_completed
+type QuestEvent struct {
+	Quest QuestResponse `json:"quest"`
+}
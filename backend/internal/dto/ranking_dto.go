@@ -2,13 +2,95 @@ package dto
 
 // RankingItem 单个排名项
 type RankingItem struct {
+	Rank      int     `json:"rank"` // 名次，从 1 开始；0 表示未上榜
 	UserID    string  `json:"userId"`
 	Minutes   int     `json:"minutes"`
 	Nickname  string  `json:"nickname"`
 	AvatarURL *string `json:"avatarUrl"`
 }
 
+// RankingSeasonResponse 赛季信息
+type RankingSeasonResponse struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	StartDate string `json:"startDate"` // YYYY-MM-DD
+	EndDate   string `json:"endDate"`   // YYYY-MM-DD
+}
+
 // GlobalRankingResponse 全站榜单返回
 type GlobalRankingResponse struct {
-	Items []RankingItem `json:"items"`
+	Scope  string                 `json:"scope"` // day | week | month | season | all
+	Season *RankingSeasonResponse `json:"season,omitempty"`
+	TagID  *string                `json:"tagId,omitempty"`
+	Items  []RankingItem          `json:"items"`
+	Me     *RankingItem           `json:"me"` // 当前用户的名次，不在前 N 名也会返回
+}
+
+// MyRankingQuery 我的名次查询参数
+type MyRankingQuery struct {
+	Scope   string `form:"scope"`   // day | week | month | season | all，默认 week
+	Season  string `form:"season"`  // 赛季 ID，scope=season 时可选，默认当前赛季
+	Tag     string `form:"tag"`     // 按标签
+	Friends bool   `form:"friends"` // 只在好友圈内排名
+	K       int    `form:"k"`       // 前后各返回几人，默认 3，最多 20
+}
+
+// MyRankingResponse 我的名次及前后相邻的用户
+type MyRankingResponse struct {
+	Scope      string                 `json:"scope"`
+	Season     *RankingSeasonResponse `json:"season,omitempty"`
+	TagID      *string                `json:"tagId,omitempty"`
+	Me         RankingItem            `json:"me"`
+	Above      []RankingItem          `json:"above"`      // 排在我前面的 K 人 (名次从高到低)
+	Below      []RankingItem          `json:"below"`      // 排在我后面的 K 人
+	Total      int64                  `json:"total"`      // 榜单总人数
+	Percentile float64                `json:"percentile"` // 超过了百分之多少的上榜用户，未上榜为 0
+}
+
+// LeaderboardHistoryQuery 历史榜单查询参数
+type LeaderboardHistoryQuery struct {
+	Scope    string `form:"scope"` // week | month | season，为空返回全部
+	Page     int    `form:"page,default=1"`
+	PageSize int    `form:"pageSize,default=20"`
+}
+
+// LeaderboardSnapshotResponse 一个已归档的榜单周期
+type LeaderboardSnapshotResponse struct {
+	ID           string       `json:"id"`
+	Scope        string       `json:"scope"`
+	SeasonID     *string      `json:"seasonId,omitempty"`
+	PeriodStart  string       `json:"periodStart"` // YYYY-MM-DD
+	PeriodEnd    string       `json:"periodEnd"`
+	Participants int          `json:"participants"`
+	Champion     *RankingItem `json:"champion,omitempty"`
+}
+
+// LeaderboardSnapshotListResponse 历史榜单列表
+type LeaderboardSnapshotListResponse struct {
+	Items    []LeaderboardSnapshotResponse `json:"items"`
+	Total    int64                         `json:"total"`
+	Page     int                           `json:"page"`
+	PageSize int                           `json:"pageSize"`
+}
+
+// LeaderboardSnapshotDetailResponse 历史榜单详情
+type LeaderboardSnapshotDetailResponse struct {
+	Snapshot LeaderboardSnapshotResponse `json:"snapshot"`
+	Items    []RankingItem               `json:"items"`
+	Me       *RankingItem                `json:"me"` // 当前用户在该期的名次，未上榜为 null
+}
+
+// MyLeaderboardRecord 我在某一期榜单中的名次
+type MyLeaderboardRecord struct {
+	Snapshot LeaderboardSnapshotResponse `json:"snapshot"`
+	Rank     int                         `json:"rank"`
+	Minutes  int                         `json:"minutes"`
+}
+
+// MyLeaderboardHistoryResponse 我的历史名次
+type MyLeaderboardHistoryResponse struct {
+	Items    []MyLeaderboardRecord `json:"items"`
+	Total    int64                 `json:"total"`
+	Page     int                   `json:"page"`
+	PageSize int                   `json:"pageSize"`
 }
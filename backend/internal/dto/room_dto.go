@@ -101,6 +101,24 @@ type NewMessageEvent struct {
 	Sender    UserSimple `json:"sender"`
 }
 
+// event: room_history (join_room 成功后发给加入者，按时间正序)
+type RoomHistoryEvent struct {
+	RoomID   string            `json:"roomId"`
+	Messages []NewMessageEvent `json:"messages"`
+}
+
+// GET /rooms/:id/messages
+type RoomMessageQuery struct {
+	Before string `form:"before" binding:"omitempty,uuid"` // 游标：上一页最早一条消息的 ID，不传从最新开始
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Q      string `form:"q" binding:"max=100"` // 关键词搜索
+}
+
+type RoomMessageListResponse struct {
+	Items      []NewMessageEvent `json:"items"`      // 按时间倒序
+	NextCursor string            `json:"nextCursor"` // 为空表示没有更早的消息
+}
+
 // event: status_updated
 type StatusUpdatedEvent struct {
 	UserID string           `json:"userId"`
@@ -0,0 +1,71 @@
+package dto
+
+import (
+	"backend/internal/model"
+	"time"
+)
+
+type KickMemberRequest struct {
+	Reason string `json:"reason" binding:"max=200"`
+}
+
+type BanMemberRequest struct {
+	Reason          string `json:"reason" binding:"max=200"`
+	DurationMinutes int    `json:"durationMinutes" binding:"omitempty,min=1,max=525600"` // 不传表示永久封禁
+}
+
+type MuteMemberRequest struct {
+	Reason          string `json:"reason" binding:"max=200"`
+	DurationMinutes int    `json:"durationMinutes" binding:"required,min=1,max=10080"`
+}
+
+type RoomBanResponse struct {
+	User      UserSimple `json:"user"`
+	BannedBy  string     `json:"bannedBy"`
+	Reason    string     `json:"reason"`
+	ExpiresAt *time.Time `json:"expiresAt"` // 为空表示永久
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+type RoomModerationLogResponse struct {
+	ID              string                 `json:"id"`
+	Action          model.ModerationAction `json:"action"`
+	Actor           UserSimple             `json:"actor"`
+	Target          UserSimple             `json:"target"`
+	Reason          string                 `json:"reason"`
+	DurationMinutes int                    `json:"durationMinutes"`
+	CreatedAt       time.Time              `json:"createdAt"`
+}
+
+type RoomModerationLogListResponse struct {
+	Items    []RoomModerationLogResponse `json:"items"`
+	Total    int64                       `json:"total"`
+	Page     int                         `json:"page"`
+	PageSize int                         `json:"pageSize"`
+}
+
+// --- Socket Event DTOs ---
+
+// Client -> Server: moderate_member
+type ModerateMemberPayload struct {
+	RoomID          string                 `json:"roomId"`
+	TargetUserID    string                 `json:"targetUserId"`
+	Action          model.ModerationAction `json:"action"` // kick, ban, unban, mute, unmute
+	Reason          string                 `json:"reason"`
+	DurationMinutes int                    `json:"durationMinutes"` // ban 可选，mute 必填
+}
+
+// event: room_kicked (发给被踢出 / 封禁的用户，客户端收到后离开房间)
+type RoomKickedEvent struct {
+	RoomID    string                 `json:"roomId"`
+	Action    model.ModerationAction `json:"action"` // kick 或 ban
+	Reason    string                 `json:"reason"`
+	ExpiresAt *time.Time             `json:"expiresAt"` // 封禁到期时间，为空表示永久
+}
+
+// event: room_muted (发给被禁言 / 解除禁言的用户，客户端据此置灰聊天框)
+type RoomMutedEvent struct {
+	RoomID     string     `json:"roomId"`
+	Reason     string     `json:"reason"`
+	MutedUntil *time.Time `json:"mutedUntil"` // 为空表示已解除禁言
+}
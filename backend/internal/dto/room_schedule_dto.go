@@ -0,0 +1,70 @@
+package dto
+
+import (
+	"backend/internal/model"
+	"time"
+)
+
+type CreateRoomScheduleRequest struct {
+	Title           string    `json:"title" binding:"required,max=100"`
+	StartTime       time.Time `json:"startTime" binding:"required"` // 第一次开始时间
+	DurationMinutes int       `json:"durationMinutes" binding:"required,min=5,max=720"`
+	RRule           string    `json:"rrule" binding:"max=255"`                            // 为空表示一次性，例如 FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR
+	Timezone        string    `json:"timezone"`                                           // 重复规则使用的时区，默认取创建者的时区
+	ReminderMinutes *int      `json:"reminderMinutes" binding:"omitempty,min=0,max=1440"` // 默认 15，0 表示不提醒
+}
+
+type RoomScheduleQuery struct {
+	Days int `form:"days" binding:"omitempty,min=1,max=62"` // 展开未来多少天，默认 14
+}
+
+type RSVPRequest struct {
+	OccurrenceStart time.Time        `json:"occurrenceStart" binding:"required"`
+	Status          model.RSVPStatus `json:"status" binding:"required,oneof=going maybe declined"`
+}
+
+type RoomAttendanceQuery struct {
+	Start time.Time `form:"start" binding:"required"` // 场次开始时间 (RFC3339)
+}
+
+// RoomOccurrenceResponse 日程展开后的某一场
+type RoomOccurrenceResponse struct {
+	ScheduleID string           `json:"scheduleId"`
+	Title      string           `json:"title"`
+	Start      time.Time        `json:"start"`
+	End        time.Time        `json:"end"`
+	Going      int              `json:"going"`
+	Maybe      int              `json:"maybe"`
+	Declined   int              `json:"declined"`
+	MyRSVP     model.RSVPStatus `json:"myRsvp,omitempty"`
+}
+
+type RoomScheduleResponse struct {
+	ID              string                   `json:"id"`
+	RoomID          string                   `json:"roomId"`
+	CreatorID       string                   `json:"creatorId"`
+	Title           string                   `json:"title"`
+	StartTime       time.Time                `json:"startTime"`
+	DurationMinutes int                      `json:"durationMinutes"`
+	RRule           string                   `json:"rrule"`
+	Timezone        string                   `json:"timezone"`
+	ReminderMinutes int                      `json:"reminderMinutes"`
+	Occurrences     []RoomOccurrenceResponse `json:"occurrences"` // 查询范围内的场次
+}
+
+// RoomAttendee 某一场的出席情况，分钟数由 RoomMember 的进出记录与场次时间的重叠计算
+type RoomAttendee struct {
+	User          UserSimple       `json:"user"`
+	RSVP          model.RSVPStatus `json:"rsvp,omitempty"`
+	Minutes       int              `json:"minutes"`
+	Attended      bool             `json:"attended"`
+	FirstJoinedAt *time.Time       `json:"firstJoinedAt"`
+}
+
+type RoomAttendanceResponse struct {
+	ScheduleID string         `json:"scheduleId"`
+	Title      string         `json:"title"`
+	Start      time.Time      `json:"start"`
+	End        time.Time      `json:"end"`
+	Attendees  []RoomAttendee `json:"attendees"`
+}
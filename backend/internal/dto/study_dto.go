@@ -8,9 +8,37 @@ import (
 // --- Request DTOs ---
 
 type StartSessionRequest struct {
-	Type    model.SessionType `json:"type" binding:"required,oneof=learning rest"`
-	TagName string            `json:"tagName"` // 可选：用户输入的标签名
-	TagID   string            `json:"tagId"`   // 可选：直接传 ID
+	Type     model.SessionType    `json:"type" binding:"required,oneof=learning rest"`
+	TagName  string               `json:"tagName"`  // 可选：用户输入的标签名
+	TagID    string               `json:"tagId"`    // 可选：直接传 ID
+	Pomodoro *PomodoroPlanRequest `json:"pomodoro"` // 可选：附带番茄钟计划，由后端自动切换阶段
+}
+
+type PomodoroPlanRequest struct {
+	FocusMinutes          int `json:"focusMinutes" binding:"required,min=1,max=180"`
+	ShortBreakMinutes     int `json:"shortBreakMinutes" binding:"required,min=1,max=60"`
+	LongBreakMinutes      int `json:"longBreakMinutes" binding:"required,min=1,max=120"`
+	CyclesBeforeLongBreak int `json:"cyclesBeforeLongBreak" binding:"required,min=1,max=12"`
+	TotalCycles           int `json:"totalCycles" binding:"min=0,max=24"` // 0 表示不限
+}
+
+// ManualSessionRequest 补录离线学习 (图书馆、考场等没有开 App 的场景)
+type ManualSessionRequest struct {
+	Type      model.SessionType `json:"type" binding:"omitempty,oneof=learning rest"` // 默认 learning
+	StartTime time.Time         `json:"startTime" binding:"required"`
+	EndTime   time.Time         `json:"endTime" binding:"required"`
+	TagName   string            `json:"tagName"`
+	TagID     string            `json:"tagId"`
+	Note      string            `json:"note" binding:"max=200"` // 补录说明，写入审计记录
+}
+
+// SessionSyncRequest 客户端离线期间记录的心跳，联网后一次性提交
+// Signature = hex(HMAC-SHA256(syncKey, "<sessionId>|<batchId>|<heartbeats 逗号分隔>|<endedAt 或空>"))
+type SessionSyncRequest struct {
+	BatchID    string  `json:"batchId" binding:"required,max=64"`            // 客户端生成，重试同一批次时保持不变
+	Heartbeats []int64 `json:"heartbeats" binding:"required,min=1,max=2000"` // 心跳时间 (Unix 秒)
+	EndedAt    *int64  `json:"endedAt"`                                      // 离线期间用户点了结束的时间 (Unix 秒)
+	Signature  string  `json:"signature" binding:"required"`
 }
 
 type EndSessionRequest struct {
@@ -18,11 +46,12 @@ type EndSessionRequest struct {
 }
 
 type GetSessionsQuery struct {
-	From     string            `form:"from"` // 格式: YYYY-MM-DD
-	To       string            `form:"to"`
-	Type     model.SessionType `form:"type"`
-	Page     int               `form:"page,default=1"`
-	PageSize int               `form:"pageSize,default=20"`
+	From       string            `form:"from"` // 格式: YYYY-MM-DD
+	To         string            `form:"to"`
+	Type       model.SessionType `form:"type"`
+	PomodoroID string            `form:"pomodoroId"` // 查看某个番茄计划的各阶段
+	Page       int               `form:"page,default=1"`
+	PageSize   int               `form:"pageSize,default=20"`
 }
 
 type GetStatsQuery struct {
@@ -30,19 +59,60 @@ type GetStatsQuery struct {
 	To    string `form:"to"`
 	Range string `form:"range"` // "7", "30" 等
 	Type  string `form:"type,default=learning"`
-	Tz    string `form:"tz,default=Asia/Shanghai"` // 时区
+	// 时区以用户设置 (User.Timezone) 为准，DailyStat 已按该时区归档
 }
 
 // --- Response DTOs ---
 
 type StudySessionResponse struct {
-	ID              string            `json:"id"`
-	UserID          string            `json:"userId"`
-	Type            model.SessionType `json:"type"`
-	StartTime       time.Time         `json:"startTime"`
-	EndTime         *time.Time        `json:"endTime"` // 指针允许 null
-	DurationMinutes *int              `json:"durationMinutes"`
-	CreatedAt       time.Time         `json:"createdAt"`
+	ID              string                    `json:"id"`
+	UserID          string                    `json:"userId"`
+	Type            model.SessionType         `json:"type"`
+	StartTime       time.Time                 `json:"startTime"`
+	EndTime         *time.Time                `json:"endTime"`         // 指针允许 null
+	DurationMinutes *int                      `json:"durationMinutes"` // 净专注时长 (已扣除暂停)
+	IsPaused        bool                      `json:"isPaused"`
+	PausedAt        *time.Time                `json:"pausedAt"`      // 当前暂停的开始时间
+	PausedMinutes   int                       `json:"pausedMinutes"` // 累计暂停分钟数
+	PomodoroID      *string                   `json:"pomodoroId"`
+	IsManual        bool                      `json:"isManual"` // 补录的离线会话
+	EndReason       model.SessionEndReason    `json:"endReason,omitempty"`
+	SyncKey         string                    `json:"syncKey,omitempty"`      // 离线同步签名密钥
+	ReviewStatus    model.SessionReviewStatus `json:"reviewStatus,omitempty"` // 反作弊审核状态
+	CreatedAt       time.Time                 `json:"createdAt"`
+}
+
+type SessionSyncResponse struct {
+	Action       string               `json:"action"`       // none / extended / reopened / ended
+	DeltaMinutes int                  `json:"deltaMinutes"` // 本次同步补记的净专注分钟数
+	Duplicate    bool                 `json:"duplicate"`    // 该批次之前已处理过
+	Session      StudySessionResponse `json:"session"`
+}
+
+type PomodoroPlanResponse struct {
+	ID                    string               `json:"id"`
+	TagID                 *string              `json:"tagId"`
+	FocusMinutes          int                  `json:"focusMinutes"`
+	ShortBreakMinutes     int                  `json:"shortBreakMinutes"`
+	LongBreakMinutes      int                  `json:"longBreakMinutes"`
+	CyclesBeforeLongBreak int                  `json:"cyclesBeforeLongBreak"`
+	TotalCycles           int                  `json:"totalCycles"`
+	Status                model.PomodoroStatus `json:"status"`
+	CurrentPhase          model.PomodoroPhase  `json:"currentPhase"`
+	CurrentCycle          int                  `json:"currentCycle"`
+	CurrentSessionID      *string              `json:"currentSessionId"`
+	PhaseEndsAt           time.Time            `json:"phaseEndsAt"`
+}
+
+// event: pomodoro_phase_changed / pomodoro_finished (Server -> Client)
+type PomodoroPhaseEvent struct {
+	PlanID            string               `json:"planId"`
+	Status            model.PomodoroStatus `json:"status"`
+	Phase             model.PomodoroPhase  `json:"phase"`
+	Cycle             int                  `json:"cycle"`
+	SessionID         *string              `json:"sessionId"`         // 新阶段对应的会话
+	PreviousSessionID *string              `json:"previousSessionId"` // 刚结束的会话
+	PhaseEndsAt       time.Time            `json:"phaseEndsAt"`
 }
 
 type DeleteActiveResponse struct {
@@ -64,17 +134,46 @@ type DailyStat struct {
 }
 
 type StatsSummaryResponse struct {
-	Type          string      `json:"type"`
-	Tz            string      `json:"tz"`
-	From          time.Time   `json:"from"`
-	To            time.Time   `json:"to"`
-	TotalMinutes  int         `json:"totalMinutes"`
-	Daily         []DailyStat `json:"daily"`
-	
+	Type         string      `json:"type"`
+	Tz           string      `json:"tz"`
+	From         time.Time   `json:"from"`
+	To           time.Time   `json:"to"`
+	TotalMinutes int         `json:"totalMinutes"`
+	Daily        []DailyStat `json:"daily"`
+
 	// Gamification Info
-	LevelInfo     LevelInfo   `json:"levelInfo"`
-	CurrentStreak int         `json:"currentStreak"`
-	LongestStreak int         `json:"longestStreak"` // 这通常需要更复杂的计算，MVP 先不做或者简化
+	LevelInfo     LevelInfo      `json:"levelInfo"`
+	CurrentStreak int            `json:"currentStreak"`
+	LongestStreak int            `json:"longestStreak"`
+	Goals         []GoalProgress `json:"goals"` // 当前周期各目标的进度
+}
+
+// 连续打卡相关
+type StreakResponse struct {
+	CurrentStreak   int     `json:"currentStreak"`
+	LongestStreak   int     `json:"longestStreak"`
+	CurrentStart    *string `json:"currentStart"`   // "2025-08-17"，没有连续记录时为 null
+	LastActiveDate  *string `json:"lastActiveDate"` // 最近一个达标的日期
+	TodayCompleted  bool    `json:"todayCompleted"` // 今天是否已达标
+	FreezeTokens    int     `json:"freezeTokens"`
+	MaxFreezeTokens int     `json:"maxFreezeTokens"`
+	DailyMinimum    int     `json:"dailyMinimum"`
+}
+
+type StreakPeriodResponse struct {
+	StartDate  string `json:"startDate"`
+	EndDate    string `json:"endDate"`
+	Length     int    `json:"length"`
+	FrozenDays int    `json:"frozenDays"`
+	Ongoing    bool   `json:"ongoing"` // 是否为当前仍在进行的连续记录
+}
+
+type UseStreakFreezeRequest struct {
+	Date string `json:"date" binding:"omitempty,datetime=2006-01-02"` // 默认冻结昨天
+}
+
+type UpdateStreakSettingsRequest struct {
+	DailyMinimum int `json:"dailyMinimum" binding:"required,min=1,max=720"`
 }
 
 type LevelInfo struct {
@@ -83,6 +182,10 @@ type LevelInfo struct {
 	LevelFloorXP int     `json:"levelFloorXP"`
 	NextLevelXP  int     `json:"nextLevelXP"`
 	Progress     float64 `json:"progress"`
+	MaxLevel     int     `json:"maxLevel"`
+	Prestige     int     `json:"prestige,omitempty"`   // 转生次数 (仅总等级)
+	LifetimeXP   int     `json:"lifetimeXP,omitempty"` // 累计总经验，转生后 CurrentXP 从 0 重新计算 (仅总等级)
+	CanPrestige  bool    `json:"canPrestige,omitempty"`
 }
 
 // --- Tag DTOs ---
@@ -95,11 +198,28 @@ type TagResponse struct {
 type UserTagResponse struct {
 	TagID        string    `json:"tagId"`
 	TagName      string    `json:"tagName"`
-	TotalMinutes int       `json:"totalMinutes"`
-	Level        LevelInfo `json:"levelInfo"` // 包含等级详情
+	TotalMinutes int       `json:"totalMinutes"` // 学习分钟数
+	XP           int       `json:"xp"`           // 学习 + 博客经验，等级按它计算
+	Level        LevelInfo `json:"levelInfo"`    // 包含等级详情
 	LastStudied  time.Time `json:"lastStudied"`
 }
 
+// SkillNode 技能树节点，XP / 分钟数包含所有子标签
+type SkillNode struct {
+	TagID        string      `json:"tagId"`
+	TagName      string      `json:"tagName"`
+	OwnXP        int         `json:"ownXP"`        // 仅该标签自身的经验
+	XP           int         `json:"xp"`           // 自身 + 子标签经验，等级按它计算
+	TotalMinutes int         `json:"totalMinutes"` // 自身 + 子标签学习分钟数
+	Level        LevelInfo   `json:"levelInfo"`
+	Children     []SkillNode `json:"children"`
+}
+
+type SkillTreeResponse struct {
+	UserID string      `json:"userId"`
+	Skills []SkillNode `json:"skills"` // 顶层技能，按 XP 降序
+}
+
 type AddTagRequest struct {
 	TagName string `json:"tagName" binding:"required"`
 }
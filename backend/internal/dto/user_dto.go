@@ -7,13 +7,15 @@ type UserProfileResponse struct {
 	Nickname  string  `json:"nickname"`
 	AvatarURL *string `json:"avatarUrl"` // 指针允许返回 null
 	Bio       *string `json:"bio"`       // 指针允许返回 null
+	Timezone  string  `json:"timezone"`  // IANA 时区，例如 Asia/Shanghai
 }
 
 // UpdateMeRequest
 type UpdateProfileRequest struct {
-	Nickname  string  `json:"nickname" binding:"required,max=50"` // 必填且最大50
-	AvatarURL *string `json:"avatarUrl" binding:"omitempty,url"`  // 可选，若有值必须是URL
-	Bio       *string `json:"bio" binding:"omitempty,max=200"`    // 可选，最大200字
+	Nickname  string  `json:"nickname" binding:"required,max=50"`  // 必填且最大50
+	AvatarURL *string `json:"avatarUrl" binding:"omitempty,url"`   // 可选，若有值必须是URL
+	Bio       *string `json:"bio" binding:"omitempty,max=200"`     // 可选，最大200字
+	Timezone  *string `json:"timezone" binding:"omitempty,max=64"` // 可选，IANA 时区；修改后会重新归档每日统计
 }
 
 // ChangePasswordRequest
@@ -32,6 +34,7 @@ type PublicProfileResponse struct {
 	TopTags     []UserTagResponse `json:"topTags"`   // 最擅长的 3 个标签
 	IsFriend    bool              `json:"isFriend"`
 	FriendStatus string           `json:"friendStatus"` // e.g., 'pending', 'accepted', ''
+	Achievements []AchievementResponse `json:"achievements"` // 已解锁的成就徽章
 }
 
 // SearchUserResponse (单项)
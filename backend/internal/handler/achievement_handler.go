@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"backend/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AchievementHandler struct {
+	Service service.AchievementService
+}
+
+// GetMyAchievements 完整成就目录，带当前用户的解锁状态和进度
+func (h *AchievementHandler) GetMyAchievements(c *gin.Context) {
+	userID := c.GetString("userId")
+
+	items, err := h.Service.GetCatalog(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
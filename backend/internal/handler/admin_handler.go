@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"backend/internal/dto"
+	"backend/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	Review service.SessionReviewService
+}
+
+// GetSessionFlags 反作弊审核队列
+func (h *AdminHandler) GetSessionFlags(c *gin.Context) {
+	var q dto.SessionFlagQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.Review.GetFlags(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ReviewSessionFlag 审核被标记的会话
+func (h *AdminHandler) ReviewSessionFlag(c *gin.Context) {
+	reviewerID := c.GetString("userId")
+	flagID := c.Param("id")
+
+	var req dto.ReviewSessionFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.Review.ReviewFlag(reviewerID, flagID, req)
+	if err != nil {
+		switch err.Error() {
+		case "flag not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "flag already reviewed":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"backend/internal/dto"
+	"backend/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ChallengeHandler struct {
+	Service service.ChallengeService
+}
+
+// challengeError 把挑战相关的业务错误映射为 HTTP 状态码
+func challengeError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "challenge not found", "invitation not found", "tag not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "only the creator can invite":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case "challenge already closed", "can only invite friends", "end time must be after start time",
+		"challenge is too long", "target minutes is required for collective challenges":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// CreateChallenge 创建挑战并邀请好友
+func (h *ChallengeHandler) CreateChallenge(c *gin.Context) {
+	userID := c.GetString("userId")
+	var req dto.CreateChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.Service.CreateChallenge(userID, req)
+	if err != nil {
+		challengeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GetChallenges 我参与的挑战
+func (h *ChallengeHandler) GetChallenges(c *gin.Context) {
+	userID := c.GetString("userId")
+	var q dto.ChallengeQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	items, err := h.Service.GetChallenges(userID, q)
+	if err != nil {
+		challengeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// GetChallenge 挑战详情及榜单
+func (h *ChallengeHandler) GetChallenge(c *gin.Context) {
+	userID := c.GetString("userId")
+
+	resp, err := h.Service.GetChallenge(userID, c.Param("id"))
+	if err != nil {
+		challengeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// InviteFriends 创建者继续邀请好友
+func (h *ChallengeHandler) InviteFriends(c *gin.Context) {
+	userID := c.GetString("userId")
+	var req dto.InviteChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.Service.Invite(userID, c.Param("id"), req.FriendIDs)
+	if err != nil {
+		challengeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Respond 接受 / 拒绝挑战邀请
+func (h *ChallengeHandler) Respond(c *gin.Context) {
+	userID := c.GetString("userId")
+	var req dto.RespondChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.Service.Respond(userID, c.Param("id"), req.Action)
+	if err != nil {
+		challengeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
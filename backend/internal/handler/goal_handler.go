@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"backend/internal/dto"
+	"backend/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GoalHandler struct {
+	Service service.GoalService
+}
+
+// GetGoals 获取目标列表及当前周期进度
+func (h *GoalHandler) GetGoals(c *gin.Context) {
+	userID := c.GetString("userId")
+	activeOnly := c.Query("active") == "true"
+
+	items, err := h.Service.GetGoals(userID, activeOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// CreateGoal 创建目标
+func (h *GoalHandler) CreateGoal(c *gin.Context) {
+	userID := c.GetString("userId")
+	var req dto.CreateGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.Service.CreateGoal(userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// UpdateGoal 修改目标
+func (h *GoalHandler) UpdateGoal(c *gin.Context) {
+	userID := c.GetString("userId")
+	goalID := c.Param("id")
+	var req dto.UpdateGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.Service.UpdateGoal(userID, goalID, req)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DeleteGoal 删除目标
+func (h *GoalHandler) DeleteGoal(c *gin.Context) {
+	userID := c.GetString("userId")
+	goalID := c.Param("id")
+
+	if err := h.Service.DeleteGoal(userID, goalID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GetGoalHistory 获取目标达成历史
+func (h *GoalHandler) GetGoalHistory(c *gin.Context) {
+	userID := c.GetString("userId")
+	var query dto.GoalHistoryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.Service.GetGoalHistory(userID, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"backend/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PresenceHandler struct {
+	Service service.PresenceService
+}
+
+// GetFriendsPresence 好友的在线状态 (offline / online / away / studying)
+func (h *PresenceHandler) GetFriendsPresence(c *gin.Context) {
+	userID := c.GetString("userId")
+
+	items, err := h.Service.GetFriendsPresence(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"backend/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QuestHandler struct {
+	Service service.QuestService
+}
+
+// GetQuests 当前的每日 / 每周任务及进度
+func (h *QuestHandler) GetQuests(c *gin.Context) {
+	userID := c.GetString("userId")
+
+	quests, err := h.Service.GetQuests(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, quests)
+}
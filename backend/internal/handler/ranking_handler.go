@@ -1,10 +1,10 @@
 package handler
 
 import (
+	"backend/internal/dto"
 	"backend/internal/service"
 	"net/http"
 	"strconv"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,57 +14,84 @@ type RankingHandler struct {
 }
 
 // GetGlobalRankings 全站榜单
+// scope: day | week | month | season | all，默认 week；scope=season 时可用 season 指定赛季 ID，默认当前赛季
+// tag: 按标签筛选 (支持别名)
 func (h *RankingHandler) GetGlobalRankings(c *gin.Context) {
-	// Query 参数解析
-	scopeStr := c.DefaultQuery("scope", "week") // 简化，这里假设只传单个 scope，如果前端传 array 需要额外处理
-	// 兼容前端传 array 格式 ?scope=week&scope=all 实际上我们通常一次返回一个榜单
-	// 或者如果前端传的是 scope=["week"] 字符串，需要 strings.Trim
-	// 根据你的接口文档：Query 参数 scope 是 string，但示例值是 ["week|all"]，通常 RESTful 推荐 ?scope=week
-	// 这里我们简单处理：默认取 week，如果传了就用传的
+	userID := c.GetString("userId")
+	scope := service.ParseRankingScope(c.DefaultQuery("scope", "week"))
 
 	limitStr := c.DefaultQuery("limit", "50")
 	limit, _ := strconv.Atoi(limitStr)
-	if limit <= 0 {
+	if limit <= 0 || limit > 200 {
 		limit = 50
 	}
 
-	// 处理 scope
-	// 如果业务需要一次返回 "items": [weekList, allList]，结构体会很复杂。
-	// 这里假设一次请求只返回一种 scope 的列表，或者根据业务逻辑默认返回 week
-	targetScope := "week"
-	if strings.Contains(scopeStr, "all") {
-		targetScope = "all"
-	}
-	
 	tagName := c.Query("tag")
 
-	items, err := h.Service.GetGlobalRankings(targetScope, limit, tagName)
+	resp, err := h.Service.GetGlobalRankings(userID, scope, c.Query("season"), limit, tagName)
 	if err != nil {
+		if err.Error() == "tag not found" || err.Error() == "season not found" || err.Error() == "no active season" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"items": items,
-	})
+	c.JSON(http.StatusOK, resp)
 }
 
 // GetFriendRankings 好友榜单
 func (h *RankingHandler) GetFriendRankings(c *gin.Context) {
 	userID := c.GetString("userId")
-	scopeStr := c.DefaultQuery("scope", "week")
+	scope := service.ParseRankingScope(c.DefaultQuery("scope", "week"))
 	limitStr := c.DefaultQuery("limit", "50")
 	limit, _ := strconv.Atoi(limitStr)
 	if limit <= 0 {
 		limit = 50
 	}
 
-	targetScope := "week"
-	if strings.Contains(scopeStr, "all") {
-		targetScope = "all"
+	items, err := h.Service.GetFriendRankings(userID, scope, c.Query("season"), limit)
+	if err != nil {
+		if err.Error() == "season not found" || err.Error() == "no active season" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": items,
+	})
+}
+
+// GetMyRanking 我的名次及前后相邻的用户
+func (h *RankingHandler) GetMyRanking(c *gin.Context) {
+	userID := c.GetString("userId")
+
+	var q dto.MyRankingQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.Service.GetMyRanking(userID, q)
+	if err != nil {
+		if err.Error() == "tag not found" || err.Error() == "season not found" || err.Error() == "no active season" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
-	items, err := h.Service.GetFriendRankings(userID, targetScope, limit)
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetSeasons 赛季列表
+func (h *RankingHandler) GetSeasons(c *gin.Context) {
+	items, err := h.Service.GetSeasons()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -74,3 +101,62 @@ func (h *RankingHandler) GetFriendRankings(c *gin.Context) {
 		"items": items,
 	})
 }
+
+// GetLeaderboardHistory 历史榜单列表
+func (h *RankingHandler) GetLeaderboardHistory(c *gin.Context) {
+	var q dto.LeaderboardHistoryQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.Service.GetLeaderboardHistory(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetMyLeaderboardHistory 我的历史名次
+func (h *RankingHandler) GetMyLeaderboardHistory(c *gin.Context) {
+	userID := c.GetString("userId")
+
+	var q dto.LeaderboardHistoryQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.Service.GetMyLeaderboardHistory(userID, q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetLeaderboardSnapshot 某一期历史榜单详情
+func (h *RankingHandler) GetLeaderboardSnapshot(c *gin.Context) {
+	userID := c.GetString("userId")
+	snapshotID := c.Param("id")
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	resp, err := h.Service.GetLeaderboardSnapshot(userID, snapshotID, limit)
+	if err != nil {
+		if err.Error() == "snapshot not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
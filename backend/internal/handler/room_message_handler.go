@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"backend/internal/dto"
+	"backend/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoomMessageHandler struct {
+	Service service.RoomMessageService
+}
+
+// GetMessages 房间聊天记录 (?before=<消息ID>&limit=50&q=关键词)
+func (h *RoomMessageHandler) GetMessages(c *gin.Context) {
+	userID := c.GetString("userId")
+	var q dto.RoomMessageQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.Service.GetMessages(userID, c.Param("id"), q)
+	if err != nil {
+		switch err.Error() {
+		case "room not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "permission denied":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case "invalid cursor":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"backend/internal/dto"
+	"backend/internal/service"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoomModerationHandler struct {
+	Service service.RoomModerationService
+}
+
+// moderationError 把房间管理相关的业务错误映射为 HTTP 状态码
+func moderationError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "room not found", "ban not found", "mute not found", "user is not in the room":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "permission denied":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case "cannot moderate yourself", "mute duration is required":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// KickMember 踢出房间
+func (h *RoomModerationHandler) KickMember(c *gin.Context) {
+	userID := c.GetString("userId")
+	var req dto.KickMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) { // 请求体可选
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.Service.Kick(userID, c.Param("id"), c.Param("userId"), req.Reason); err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// BanMember 封禁用户 (不传时长为永久)
+func (h *RoomModerationHandler) BanMember(c *gin.Context) {
+	userID := c.GetString("userId")
+	var req dto.BanMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) { // 请求体可选
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.Service.Ban(userID, c.Param("id"), c.Param("userId"), req.Reason, req.DurationMinutes); err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// UnbanMember 解除封禁
+func (h *RoomModerationHandler) UnbanMember(c *gin.Context) {
+	userID := c.GetString("userId")
+
+	if err := h.Service.Unban(userID, c.Param("id"), c.Param("userId")); err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// MuteMember 禁言
+func (h *RoomModerationHandler) MuteMember(c *gin.Context) {
+	userID := c.GetString("userId")
+	var req dto.MuteMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.Service.Mute(userID, c.Param("id"), c.Param("userId"), req.Reason, req.DurationMinutes); err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// UnmuteMember 解除禁言
+func (h *RoomModerationHandler) UnmuteMember(c *gin.Context) {
+	userID := c.GetString("userId")
+
+	if err := h.Service.Unmute(userID, c.Param("id"), c.Param("userId")); err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// GetBans 封禁名单
+func (h *RoomModerationHandler) GetBans(c *gin.Context) {
+	userID := c.GetString("userId")
+
+	items, err := h.Service.GetBans(userID, c.Param("id"))
+	if err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// GetModerationLog 房间管理操作记录
+func (h *RoomModerationHandler) GetModerationLog(c *gin.Context) {
+	userID := c.GetString("userId")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+
+	resp, err := h.Service.GetModerationLog(userID, c.Param("id"), page, pageSize)
+	if err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
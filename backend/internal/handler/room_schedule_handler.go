@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"backend/internal/dto"
+	"backend/internal/service"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoomScheduleHandler struct {
+	Service service.RoomScheduleService
+}
+
+// scheduleError 把日程相关的业务错误映射为 HTTP 状态码
+func scheduleError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "room not found", err.Error() == "schedule not found", err.Error() == "occurrence not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err.Error() == "permission denied":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "invalid "), err.Error() == "timezone cannot be empty",
+		err.Error() == "rrule has no occurrences", err.Error() == "start time must be in the future",
+		err.Error() == "occurrence already ended":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// CreateSchedule 房主创建定时自习 (一次性或重复)
+func (h *RoomScheduleHandler) CreateSchedule(c *gin.Context) {
+	userID := c.GetString("userId")
+	var req dto.CreateRoomScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.Service.CreateSchedule(userID, c.Param("id"), req)
+	if err != nil {
+		scheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GetSchedules 房间的日程及未来的场次
+func (h *RoomScheduleHandler) GetSchedules(c *gin.Context) {
+	userID := c.GetString("userId")
+	var q dto.RoomScheduleQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	items, err := h.Service.GetSchedules(userID, c.Param("id"), q.Days)
+	if err != nil {
+		scheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// DeleteSchedule 删除日程
+func (h *RoomScheduleHandler) DeleteSchedule(c *gin.Context) {
+	userID := c.GetString("userId")
+
+	if err := h.Service.DeleteSchedule(userID, c.Param("id"), c.Param("scheduleId")); err != nil {
+		scheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// RSVP 回复某一场是否参加
+func (h *RoomScheduleHandler) RSVP(c *gin.Context) {
+	userID := c.GetString("userId")
+	var req dto.RSVPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.Service.RSVP(userID, c.Param("id"), c.Param("scheduleId"), req)
+	if err != nil {
+		scheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetAttendance 某一场的实际出席情况
+func (h *RoomScheduleHandler) GetAttendance(c *gin.Context) {
+	userID := c.GetString("userId")
+	var q dto.RoomAttendanceQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.Service.GetAttendance(userID, c.Param("id"), c.Param("scheduleId"), q.Start)
+	if err != nil {
+		scheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
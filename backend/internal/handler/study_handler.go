@@ -10,6 +10,7 @@ import (
 
 type StudyHandler struct {
 	Service service.StudyService
+	Streak  service.StreakService
 }
 
 // StartSession
@@ -28,15 +29,7 @@ func (h *StudyHandler) StartSession(c *gin.Context) {
 	}
 
 	// 格式化返回
-	c.JSON(http.StatusCreated, dto.StudySessionResponse{
-		ID:              sess.ID,
-		UserID:          sess.UserID,
-		Type:            sess.Type,
-		StartTime:       sess.StartTime,
-		EndTime:         sess.EndTime,
-		DurationMinutes: sess.DurationMinutes,
-		CreatedAt:       sess.CreatedAt,
-	})
+	c.JSON(http.StatusCreated, h.Service.ToSessionResponse(sess))
 }
 
 // EndSession
@@ -54,15 +47,35 @@ func (h *StudyHandler) EndSession(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, dto.StudySessionResponse{
-		ID:              sess.ID,
-		UserID:          sess.UserID,
-		Type:            sess.Type,
-		StartTime:       sess.StartTime,
-		EndTime:         sess.EndTime,
-		DurationMinutes: sess.DurationMinutes,
-		CreatedAt:       sess.CreatedAt,
-	})
+	c.JSON(http.StatusOK, h.Service.ToSessionResponse(sess))
+}
+
+// PauseSession
+func (h *StudyHandler) PauseSession(c *gin.Context) {
+	userID := c.GetString("userId")
+	sessionID := c.Param("id")
+
+	sess, err := h.Service.PauseSession(userID, sessionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.Service.ToSessionResponse(sess))
+}
+
+// ResumeSession
+func (h *StudyHandler) ResumeSession(c *gin.Context) {
+	userID := c.GetString("userId")
+	sessionID := c.Param("id")
+
+	sess, err := h.Service.ResumeSession(userID, sessionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.Service.ToSessionResponse(sess))
 }
 
 // GetActiveSession
@@ -80,15 +93,25 @@ func (h *StudyHandler) GetActiveSession(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, dto.StudySessionResponse{
-		ID:              sess.ID,
-		UserID:          sess.UserID,
-		Type:            sess.Type,
-		StartTime:       sess.StartTime,
-		EndTime:         sess.EndTime,
-		DurationMinutes: sess.DurationMinutes,
-		CreatedAt:       sess.CreatedAt,
-	})
+	c.JSON(http.StatusOK, h.Service.ToSessionResponse(sess))
+}
+
+// GetActivePomodoro
+func (h *StudyHandler) GetActivePomodoro(c *gin.Context) {
+	userID := c.GetString("userId")
+
+	plan, err := h.Service.GetActivePomodoro(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if plan == nil {
+		c.JSON(http.StatusOK, nil) // 返回 null
+		return
+	}
+
+	c.JSON(http.StatusOK, h.Service.ToPomodoroResponse(plan))
 }
 
 // CancelActiveSession
@@ -112,7 +135,13 @@ func (h *StudyHandler) Heartbeat(c *gin.Context) {
 	userID := c.GetString("userId")
 	sessionID := c.Param("id")
 
-	if err := h.Service.Heartbeat(userID, sessionID); err != nil {
+	// 客户端可以通过 X-Device-ID 上报设备标识，否则按 IP + UA 区分
+	device := c.GetHeader("X-Device-ID")
+	if device == "" {
+		device = c.ClientIP() + "|" + c.Request.UserAgent()
+	}
+
+	if err := h.Service.Heartbeat(userID, sessionID, device); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -157,3 +186,105 @@ func (h *StudyHandler) GetStatsSummary(c *gin.Context) {
 
 	c.JSON(http.StatusOK, resp)
 }
+
+// SyncSession 离线心跳同步
+func (h *StudyHandler) SyncSession(c *gin.Context) {
+	userID := c.GetString("userId")
+	sessionID := c.Param("id")
+	var req dto.SessionSyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.Service.SyncSession(userID, sessionID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// CreateManualSession 补录离线学习
+func (h *StudyHandler) CreateManualSession(c *gin.Context) {
+	userID := c.GetString("userId")
+	var req dto.ManualSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sess, err := h.Service.CreateManualSession(userID, req, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, h.Service.ToSessionResponse(sess))
+}
+
+// GetStreak 获取连续打卡信息
+func (h *StudyHandler) GetStreak(c *gin.Context) {
+	userID := c.GetString("userId")
+
+	resp, err := h.Streak.GetStreak(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetStreakHistory 获取历史连续记录
+func (h *StudyHandler) GetStreakHistory(c *gin.Context) {
+	userID := c.GetString("userId")
+
+	items, err := h.Streak.GetStreakHistory(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// UseStreakFreeze 使用冻结卡补签
+func (h *StudyHandler) UseStreakFreeze(c *gin.Context) {
+	userID := c.GetString("userId")
+	var req dto.UseStreakFreezeRequest
+	// Body 可以为空 (默认冻结昨天)
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	resp, err := h.Streak.UseFreeze(userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// UpdateStreakSettings 修改每日达标门槛
+func (h *StudyHandler) UpdateStreakSettings(c *gin.Context) {
+	userID := c.GetString("userId")
+	var req dto.UpdateStreakSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.Streak.UpdateSettings(userID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -59,6 +59,23 @@ func (h *TagHandler) GetMyTags(c *gin.Context) {
 	c.JSON(http.StatusOK, tags)
 }
 
+// GetUserSkills 用户的技能树 (子标签经验累加到父标签)
+func (h *TagHandler) GetUserSkills(c *gin.Context) {
+	targetID := c.Param("id")
+
+	tree, err := h.Service.GetSkillTree(targetID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tree)
+}
+
 // AddTag (Attach)
 func (h *TagHandler) AddTag(c *gin.Context) {
 	userID := c.GetString("userId")
@@ -30,6 +30,7 @@ func (h *UserHandler) GetMe(c *gin.Context) {
 		Nickname:  user.Nickname,
 		AvatarURL: user.AvatarUrl,
 		Bio:       user.Bio,
+		Timezone:  user.Timezone,
 	})
 }
 
@@ -55,6 +56,7 @@ func (h *UserHandler) UpdateMe(c *gin.Context) {
 		Nickname:  updatedUser.Nickname,
 		AvatarURL: updatedUser.AvatarUrl,
 		Bio:       updatedUser.Bio,
+		Timezone:  updatedUser.Timezone,
 	})
 }
 
@@ -124,3 +126,24 @@ func (h *UserHandler) DeleteAccount(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"ok": true})
 }
+
+// Prestige 满级后转生，展示等级归零，累计经验保留
+func (h *UserHandler) Prestige(c *gin.Context) {
+	userID := c.GetString("userId")
+
+	levelService := &service.LevelService{}
+	info, err := levelService.Prestige(userID)
+	if err != nil {
+		switch err.Error() {
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "max level not reached":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
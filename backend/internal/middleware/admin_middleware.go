@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"backend/internal/model"
+	"backend/pkg/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminMiddleware 只允许管理员访问，需放在 AuthMiddleware 之后
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user model.User
+		err := database.DB.Select("id", "is_admin").First(&user, "id = ?", c.GetString("userId")).Error
+		if err != nil || !user.IsAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin permission required"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/lib/pq"
+	"gorm.io/gorm"
 )
 
 // --- Enums ---
@@ -18,9 +19,33 @@ const (
 type NotificationType string
 
 const (
-	NotificationTypeSystem NotificationType = "system"
-	NotificationTypeInvite NotificationType = "invite"
-	NotificationTypeFriend NotificationType = "friend"
+	NotificationTypeSystem    NotificationType = "system"
+	NotificationTypeInvite    NotificationType = "invite"
+	NotificationTypeFriend    NotificationType = "friend"
+	NotificationTypeGoal      NotificationType = "goal"
+	NotificationTypeRank      NotificationType = "ranking"
+	NotificationTypeBadge     NotificationType = "achievement"
+	NotificationTypeQuest     NotificationType = "quest"
+	NotificationTypeChallenge NotificationType = "challenge"
+	NotificationTypeSchedule  NotificationType = "schedule"
+)
+
+// XPSource 经验来源
+type XPSource string
+
+const (
+	XPSourceStudy        XPSource = "study"         // 学习会话 (ReferenceID = 会话 ID)
+	XPSourceBlogAI       XPSource = "blog_ai"       // 博客 AI 评分 (ReferenceID = 博客 ID)
+	XPSourceBlogLike     XPSource = "blog_like"     // 博客被点赞 (ReferenceID = 博客 ID, ActorID = 点赞者)
+	XPSourceBlogBookmark XPSource = "blog_bookmark" // 博客被收藏 (ReferenceID = 博客 ID, ActorID = 收藏者)
+	XPSourceQuest        XPSource = "quest"         // 完成任务 (ReferenceID = UserQuest ID)
+)
+
+type GoalPeriod string
+
+const (
+	GoalPeriodDaily  GoalPeriod = "daily"
+	GoalPeriodWeekly GoalPeriod = "weekly"
 )
 
 type SessionType string
@@ -30,6 +55,41 @@ const (
 	SessionTypeRest     SessionType = "rest"
 )
 
+// SessionEndReason 会话结束的原因
+type SessionEndReason string
+
+const (
+	SessionEndReasonUser     SessionEndReason = "user"     // 用户手动结束
+	SessionEndReasonReaped   SessionEndReason = "reaped"   // 心跳超时被 Reaper 回收
+	SessionEndReasonPomodoro SessionEndReason = "pomodoro" // 番茄钟阶段切换
+	SessionEndReasonSynced   SessionEndReason = "synced"   // 客户端离线结束，联网后同步
+)
+
+// SessionReviewStatus 反作弊审核状态，为空表示未命中任何规则
+type SessionReviewStatus string
+
+const (
+	SessionReviewPending  SessionReviewStatus = "pending"  // 命中规则，暂不计入排行榜，等待审核
+	SessionReviewApproved SessionReviewStatus = "approved" // 审核通过，补计入排行榜
+	SessionReviewRejected SessionReviewStatus = "rejected" // 审核驳回，不计入排行榜
+)
+
+type PomodoroPhase string
+
+const (
+	PomodoroPhaseFocus      PomodoroPhase = "focus"
+	PomodoroPhaseShortBreak PomodoroPhase = "short_break"
+	PomodoroPhaseLongBreak  PomodoroPhase = "long_break"
+)
+
+type PomodoroStatus string
+
+const (
+	PomodoroStatusRunning   PomodoroStatus = "running"
+	PomodoroStatusCompleted PomodoroStatus = "completed"
+	PomodoroStatusCancelled PomodoroStatus = "cancelled"
+)
+
 type RoomStatus string
 
 const (
@@ -38,17 +98,33 @@ const (
 	RoomStatusIdle     RoomStatus = "idle"
 )
 
+// PresenceStatus 好友可见的在线状态，由 Redis 中的连接计数和进行中的会话推导，不落库
+type PresenceStatus string
+
+const (
+	PresenceOffline  PresenceStatus = "offline"
+	PresenceOnline   PresenceStatus = "online"
+	PresenceAway     PresenceStatus = "away"
+	PresenceStudying PresenceStatus = "studying"
+)
+
 // --- Models ---
 
 type User struct {
-	ID           string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"` // 依赖 Postgres pgcrypto
-	Email        string    `gorm:"uniqueIndex;not null"`
-	PasswordHash string    `gorm:"not null"`
-	Nickname     string    `gorm:"not null"`
-	AvatarUrl    *string   `gorm:"default:null"` // 指针类型表示可选
-	Bio          *string   `gorm:"default:null"`
-	CreatedAt    time.Time `gorm:"autoCreateTime"`
-	UpdatedAt    time.Time `gorm:"autoUpdateTime"`
+	ID                string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"` // 依赖 Postgres pgcrypto
+	Email             string    `gorm:"uniqueIndex;not null"`
+	PasswordHash      string    `gorm:"not null"`
+	Nickname          string    `gorm:"not null"`
+	AvatarUrl         *string   `gorm:"default:null"` // 指针类型表示可选
+	Bio               *string   `gorm:"default:null"`
+	Timezone          string    `gorm:"type:varchar(64);not null;default:'Asia/Shanghai'"` // IANA 时区，决定 DailyStat 的日期归属
+	StatsTimezone     string    `gorm:"type:varchar(64);default:''"`                       // DailyStat 当前归档所用的时区，为空表示旧数据 (服务器本地时区)
+	IsAdmin           bool      `gorm:"default:false"`
+	Prestige          int       `gorm:"default:0"` // 转生次数
+	PrestigeXP        int       `gorm:"default:0"` // 转生消耗的经验，展示等级按 总经验 - PrestigeXP 计算，总经验本身不变
+	LevelCurveVersion int       `gorm:"default:1"` // 最近一次按哪个版本的等级曲线计算过等级，曲线升级时由 levelmigrate 更新
+	CreatedAt         time.Time `gorm:"autoCreateTime"`
+	UpdatedAt         time.Time `gorm:"autoUpdateTime"`
 
 	// Relations
 	StudySessions []StudySession `gorm:"foreignKey:UserID"`
@@ -67,12 +143,223 @@ type DailyStat struct {
 	ID           string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
 	UserID       string    `gorm:"type:uuid;not null;index:idx_user_date,unique"` // 复合唯一索引
 	Date         time.Time `gorm:"type:date;not null;index:idx_user_date,unique"` // 复合唯一索引
-	TotalMinutes int       `gorm:"default:0"`
+	TotalMinutes int       `gorm:"default:0"`                                     // 学习分钟数 (只来自学习会话)
+	XP           int       `gorm:"default:0"`                                     // 当天获得的经验，由 XPService 根据 XPEvent 维护
 	UpdatedAt    time.Time `gorm:"autoUpdateTime"`
 
 	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 }
 
+// XPEvent 经验流水，只追加不修改；撤销时写入一条金额相反、ReversalOf 指向原记录的流水
+// DailyStat.XP 与 UserTagStat.XP 都是它的聚合
+type XPEvent struct {
+	ID          string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID      string    `gorm:"type:uuid;not null;index"`
+	Source      XPSource  `gorm:"type:varchar(20);not null;index:idx_xp_source_ref"`
+	ReferenceID string    `gorm:"type:varchar(64);not null;index:idx_xp_source_ref"`
+	ActorID     *string   `gorm:"type:uuid;default:null"` // 触发者，例如点赞的用户
+	TagID       *string   `gorm:"type:uuid;default:null;index"`
+	Amount      int       `gorm:"not null"`
+	Date        time.Time `gorm:"type:date;not null"`           // 计入哪一天 (用户时区)
+	ReversalOf  *string   `gorm:"type:uuid;default:null;index"` // 被撤销的原流水
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// RankingSeason 自定义赛季，赛季内结束的学习会话计入赛季榜 (日期为服务器时区，含两端)
+type RankingSeason struct {
+	ID        string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Name      string    `gorm:"type:varchar(50);not null"`
+	StartDate time.Time `gorm:"type:date;not null;index"`
+	EndDate   time.Time `gorm:"type:date;not null;index"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// LeaderboardSnapshot 已结束周期的全站榜单快照 (Redis 周榜 / 月榜会过期，结束后归档到这里)
+type LeaderboardSnapshot struct {
+	ID           string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Scope        string    `gorm:"type:varchar(10);not null;index"`       // week | month | season
+	PeriodKey    string    `gorm:"type:varchar(64);not null;uniqueIndex"` // 对应的 Redis Key 周期部分，例如 week:2025-3
+	SeasonID     *string   `gorm:"type:uuid;default:null"`
+	PeriodStart  time.Time `gorm:"type:date;not null"`
+	PeriodEnd    time.Time `gorm:"type:date;not null"`
+	Participants int       `gorm:"not null"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+}
+
+// LeaderboardEntry 快照中每个上榜用户的名次与分数
+type LeaderboardEntry struct {
+	ID         string `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	SnapshotID string `gorm:"type:uuid;not null;uniqueIndex:idx_snapshot_user;index:idx_snapshot_rank"`
+	UserID     string `gorm:"type:uuid;not null;uniqueIndex:idx_snapshot_user;index"`
+	Rank       int    `gorm:"not null;index:idx_snapshot_rank"`
+	Minutes    int    `gorm:"not null"`
+
+	Snapshot LeaderboardSnapshot `gorm:"foreignKey:SnapshotID;constraint:OnDelete:CASCADE;"`
+	User     User                `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// UserAchievement 用户已解锁的成就 (成就的定义见 service.AchievementCatalog)
+type UserAchievement struct {
+	ID         string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID     string    `gorm:"type:uuid;not null;uniqueIndex:idx_user_achievement"`
+	Code       string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_user_achievement"`
+	UnlockedAt time.Time `gorm:"autoCreateTime"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// UserQuest 按模板为用户生成的每日 / 每周任务 (模板见 service.QuestTemplates)
+type UserQuest struct {
+	ID          string     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID      string     `gorm:"type:uuid;not null;index:idx_user_quest,unique"`
+	Code        string     `gorm:"type:varchar(64);not null;index:idx_user_quest,unique"` // 模板 Code
+	Period      GoalPeriod `gorm:"type:varchar(10);not null"`
+	PeriodStart time.Time  `gorm:"type:date;not null;index:idx_user_quest,unique"` // 用户时区的周期起止日期 (含两端)
+	PeriodEnd   time.Time  `gorm:"type:date;not null"`
+	Title       string     `gorm:"not null"`
+	TagID       *string    `gorm:"type:uuid;default:null"` // 限定标签的任务，生成时从用户的标签中选出
+	Target      int        `gorm:"not null"`
+	Progress    int        `gorm:"default:0"`
+	RewardXP    int        `gorm:"not null"`
+	CompletedAt *time.Time `gorm:"default:null"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Tag  *Tag `gorm:"foreignKey:TagID"`
+}
+
+// ChallengeMode 挑战类型
+type ChallengeMode string
+
+const (
+	ChallengeModeCompetitive ChallengeMode = "competitive" // 比拼：周期内谁学得多
+	ChallengeModeCollective  ChallengeMode = "collective"  // 协作：所有人一起完成总目标
+)
+
+type ChallengeStatus string
+
+const (
+	ChallengeStatusActive ChallengeStatus = "active"
+	ChallengeStatusClosed ChallengeStatus = "closed"
+)
+
+type ChallengeParticipantStatus string
+
+const (
+	ChallengeParticipantInvited  ChallengeParticipantStatus = "invited"
+	ChallengeParticipantAccepted ChallengeParticipantStatus = "accepted"
+	ChallengeParticipantDeclined ChallengeParticipantStatus = "declined"
+)
+
+// Challenge 好友之间限时的学习挑战
+type Challenge struct {
+	ID            string          `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	CreatorID     string          `gorm:"type:uuid;not null;index"`
+	Title         string          `gorm:"not null"`
+	Mode          ChallengeMode   `gorm:"type:varchar(20);not null"`
+	TagID         *string         `gorm:"type:uuid;default:null"` // 只统计该标签的学习时长，为空表示全部
+	TargetMinutes int             `gorm:"default:0"`              // 协作挑战的总目标
+	StartTime     time.Time       `gorm:"not null"`
+	EndTime       time.Time       `gorm:"not null;index"`
+	Status        ChallengeStatus `gorm:"type:varchar(20);not null;default:'active';index"`
+	TotalMinutes  int             `gorm:"default:0"`     // 结束时所有参与者的总时长
+	Completed     bool            `gorm:"default:false"` // 协作挑战是否达成目标
+	ClosedAt      *time.Time      `gorm:"default:null"`
+	CreatedAt     time.Time       `gorm:"autoCreateTime"`
+
+	Creator      User                   `gorm:"foreignKey:CreatorID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Tag          *Tag                   `gorm:"foreignKey:TagID"`
+	Participants []ChallengeParticipant `gorm:"foreignKey:ChallengeID"`
+}
+
+// ChallengeParticipant 挑战的参与者 (含被邀请但还未接受的好友)，挑战结束时写入最终成绩
+type ChallengeParticipant struct {
+	ID          string                     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	ChallengeID string                     `gorm:"type:uuid;not null;index:idx_challenge_participant,unique"`
+	UserID      string                     `gorm:"type:uuid;not null;index:idx_challenge_participant,unique;index"`
+	InviterID   *string                    `gorm:"type:uuid;default:null"`
+	Status      ChallengeParticipantStatus `gorm:"type:varchar(20);not null"`
+	Minutes     int                        `gorm:"default:0"` // 最终学习时长
+	Rank        int                        `gorm:"default:0"` // 最终名次 (只有接受了挑战的参与者有名次)
+	CreatedAt   time.Time                  `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time                  `gorm:"autoUpdateTime"`
+
+	Challenge Challenge `gorm:"foreignKey:ChallengeID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	User      User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// UserStreak 用户连续学习记录，随 DailyStat 增量维护
+// 某天学习分钟数 >= DailyMinimum 或使用了冻结卡，即视为该天未断签 (冻结日不计入天数)
+type UserStreak struct {
+	UserID         string     `gorm:"type:uuid;primaryKey"`
+	CurrentStreak  int        `gorm:"default:0"`
+	LongestStreak  int        `gorm:"default:0"`
+	CurrentStart   *time.Time `gorm:"type:date;default:null"` // 当前连续记录的起始日期
+	LastActiveDate *time.Time `gorm:"type:date;default:null"` // 最近一个达标的日期
+	FreezeTokens   int        `gorm:"default:0"`              // 可用的冻结卡数量
+	DailyMinimum   int        `gorm:"default:1"`              // 每天至少学习多少分钟才算打卡
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// StreakPeriod 已结束的连续学习记录 (历史)
+type StreakPeriod struct {
+	ID         string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID     string    `gorm:"type:uuid;not null;index"`
+	StartDate  time.Time `gorm:"type:date;not null"`
+	EndDate    time.Time `gorm:"type:date;not null"`
+	Length     int       `gorm:"not null"`  // 达标天数
+	FrozenDays int       `gorm:"default:0"` // 期间使用冻结卡保住的天数
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// StreakFreeze 冻结卡使用记录，每个日期最多使用一次
+type StreakFreeze struct {
+	ID        string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID    string    `gorm:"type:uuid;not null;index:idx_user_freeze_date,unique"`
+	Date      time.Time `gorm:"type:date;not null;index:idx_user_freeze_date,unique"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// StudyGoal 学习目标 (每日/每周的分钟数)，TagID 为空表示所有学习都计入
+type StudyGoal struct {
+	ID            string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID        string         `gorm:"type:uuid;not null;index"`
+	TagID         *string        `gorm:"type:uuid;default:null"`
+	Period        GoalPeriod     `gorm:"type:varchar(10);not null"`
+	TargetMinutes int            `gorm:"not null"`
+	IsActive      bool           `gorm:"default:true;index"`
+	ActivatedAt   *time.Time     `gorm:"default:null"` // 最近一次重新启用的时间，停用期间的周期不结算
+	CreatedAt     time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt     time.Time      `gorm:"autoUpdateTime"`
+	DeletedAt     gorm.DeletedAt `gorm:"index"` // 软删除，保留已结算的历史记录
+
+	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Tag  *Tag `gorm:"foreignKey:TagID"`
+}
+
+// GoalRecord 目标在某个周期结束后的结果 (达成/未达成)
+type GoalRecord struct {
+	ID            string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	GoalID        string    `gorm:"type:uuid;not null;index:idx_goal_period,unique"`
+	UserID        string    `gorm:"type:uuid;not null;index"`
+	PeriodStart   time.Time `gorm:"type:date;not null;index:idx_goal_period,unique"`
+	PeriodEnd     time.Time `gorm:"type:date;not null"`
+	TargetMinutes int       `gorm:"not null"` // 记录当时的目标值，之后修改目标不影响历史
+	ActualMinutes int       `gorm:"not null"`
+	Met           bool      `gorm:"not null"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+
+	Goal StudyGoal `gorm:"foreignKey:GoalID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
 type Tag struct {
 	ID        string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
 	Name      string    `gorm:"uniqueIndex;not null"` // 标准化名称 (lowercase)
@@ -89,7 +376,8 @@ type UserTagStat struct {
 	ID           string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
 	UserID       string    `gorm:"type:uuid;not null;index:idx_user_tag,unique"`
 	TagID        string    `gorm:"type:uuid;not null;index:idx_user_tag,unique"`
-	TotalMinutes int       `gorm:"default:0"` // 1 min = 1 XP
+	TotalMinutes int       `gorm:"default:0"` // 该标签下的学习分钟数
+	XP           int       `gorm:"default:0"` // 该标签下的经验 (学习 + 博客)，由 XPService 维护
 	UpdatedAt    time.Time `gorm:"autoUpdateTime"`
 
 	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
@@ -109,17 +397,105 @@ type Friend struct {
 }
 
 type StudySession struct {
-	ID              string      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
-	UserID          string      `gorm:"type:uuid;not null;index"` // 配合 startTime/endTime 的索引可以手动在 DB 加，或者 GORM 这种简单写法
-	StartTime       time.Time   `gorm:"not null;index"`
-	EndTime         *time.Time  `gorm:"default:null;index"`
-	DurationMinutes *int        `gorm:"default:null"`
-	Type            SessionType `gorm:"type:varchar(20);not null"`
-	CreatedAt       time.Time   `gorm:"autoCreateTime"`
-	TagID           *string     `gorm:"type:uuid;default:null;index"` // 允许为空，兼容旧数据
+	ID              string              `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID          string              `gorm:"type:uuid;not null;index"` // 配合 startTime/endTime 的索引可以手动在 DB 加，或者 GORM 这种简单写法
+	StartTime       time.Time           `gorm:"not null;index"`
+	EndTime         *time.Time          `gorm:"default:null;index"`
+	DurationMinutes *int                `gorm:"default:null"`
+	Type            SessionType         `gorm:"type:varchar(20);not null"`
+	CreatedAt       time.Time           `gorm:"autoCreateTime"`
+	TagID           *string             `gorm:"type:uuid;default:null;index"` // 允许为空，兼容旧数据
+	PomodoroID      *string             `gorm:"type:uuid;default:null;index"` // 所属番茄计划，每个阶段各自是一条会话
+	IsManual        bool                `gorm:"default:false;index"`          // 用户补录的离线会话 (非实时计时)
+	EndReason       SessionEndReason    `gorm:"type:varchar(20);default:''"`
+	ReapedEndTime   *time.Time          `gorm:"default:null"`                      // Reaper 回收时写入的结束时间，离线同步的延长上限以此为基准
+	SyncKey         string              `gorm:"type:varchar(64);default:''"`       // 离线同步签名密钥，开始会话时下发给客户端
+	ReviewStatus    SessionReviewStatus `gorm:"type:varchar(20);default:'';index"` // 反作弊审核状态
+
+	User   User                `gorm:"foreignKey:UserID"`
+	Tag    *Tag                `gorm:"foreignKey:TagID"`
+	Pauses []StudySessionPause `gorm:"foreignKey:SessionID"`
+}
 
-	User User `gorm:"foreignKey:UserID"`
-	Tag  *Tag `gorm:"foreignKey:TagID"`
+// SessionFlag 反作弊规则命中记录，每个会话一条，同时作为管理员的审核队列
+type SessionFlag struct {
+	ID         string              `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	SessionID  string              `gorm:"type:uuid;not null;uniqueIndex"`
+	UserID     string              `gorm:"type:uuid;not null;index"`
+	Rules      pq.StringArray      `gorm:"type:text[]"` // 命中的规则
+	Details    string              `gorm:"type:text"`   // 每条规则的具体数值，便于审核
+	Status     SessionReviewStatus `gorm:"type:varchar(20);not null;index"`
+	XPRevoked  bool                `gorm:"default:false"`
+	ReviewerID *string             `gorm:"type:uuid;default:null"`
+	ReviewNote string              `gorm:"type:text"`
+	ReviewedAt *time.Time          `gorm:"default:null"`
+	CreatedAt  time.Time           `gorm:"autoCreateTime"`
+
+	Session StudySession `gorm:"foreignKey:SessionID;constraint:OnDelete:CASCADE;"`
+	User    User         `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// StudySessionAudit 会话审计记录，目前用于记录补录 (manual) 会话的来源
+type StudySessionAudit struct {
+	ID              string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	SessionID       string    `gorm:"type:uuid;not null;index"`
+	UserID          string    `gorm:"type:uuid;not null;index"`
+	Action          string    `gorm:"type:varchar(32);not null"`
+	StartTime       time.Time `gorm:"not null"`
+	EndTime         time.Time `gorm:"not null"`
+	DurationMinutes int       `gorm:"not null"`
+	CreditedMinutes int       `gorm:"not null"` // 实际计入的经验 (补录可能打折)
+	Note            string    `gorm:"type:text"`
+	ClientIP        string    `gorm:"type:varchar(64)"`
+	UserAgent       string    `gorm:"type:text"`
+	CreatedAt       time.Time `gorm:"autoCreateTime"`
+
+	Session StudySession `gorm:"foreignKey:SessionID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// SessionSyncBatch 已处理的离线同步批次，用于幂等 (客户端重试同一批次不会重复计时)
+type SessionSyncBatch struct {
+	ID             string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	SessionID      string    `gorm:"type:uuid;not null;index:idx_session_batch,unique"`
+	BatchID        string    `gorm:"type:varchar(64);not null;index:idx_session_batch,unique"`
+	UserID         string    `gorm:"type:uuid;not null;index"`
+	Action         string    `gorm:"type:varchar(20);not null"` // none / extended / reopened / ended
+	DeltaMinutes   int       `gorm:"default:0"`
+	HeartbeatCount int       `gorm:"default:0"`
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+
+	Session StudySession `gorm:"foreignKey:SessionID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// PomodoroPlan 番茄钟计划，由后端调度在专注/休息阶段之间自动切换
+type PomodoroPlan struct {
+	ID                    string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID                string         `gorm:"type:uuid;not null;index"`
+	TagID                 *string        `gorm:"type:uuid;default:null"`
+	FocusMinutes          int            `gorm:"not null"`
+	ShortBreakMinutes     int            `gorm:"not null"`
+	LongBreakMinutes      int            `gorm:"not null"`
+	CyclesBeforeLongBreak int            `gorm:"not null"`
+	TotalCycles           int            `gorm:"default:0"` // 0 表示不限轮次，直到用户手动结束
+	Status                PomodoroStatus `gorm:"type:varchar(20);not null;index"`
+	CurrentPhase          PomodoroPhase  `gorm:"type:varchar(20);not null"`
+	CurrentCycle          int            `gorm:"default:1"` // 当前是第几个专注轮次 (从 1 开始)
+	CurrentSessionID      *string        `gorm:"type:uuid;default:null"`
+	PhaseEndsAt           time.Time      `gorm:"not null;index"`
+	CreatedAt             time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt             time.Time      `gorm:"autoUpdateTime"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// StudySessionPause 会话内的一次暂停区间，计算净专注时长时需要扣除
+type StudySessionPause struct {
+	ID        string     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	SessionID string     `gorm:"type:uuid;not null;index"`
+	PausedAt  time.Time  `gorm:"not null"`
+	ResumedAt *time.Time `gorm:"default:null"` // 为空表示仍处于暂停中
+
+	Session StudySession `gorm:"foreignKey:SessionID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 }
 
 type BlogStatus string
@@ -219,6 +595,111 @@ type RoomMember struct {
 	User User `gorm:"foreignKey:UserID"`
 }
 
+// RoomMessage 房间聊天记录，(room_id, created_at) 索引用于按时间倒序的游标分页
+type RoomMessage struct {
+	ID        string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	RoomID    string    `gorm:"type:uuid;not null;index:idx_room_message"`
+	SenderID  string    `gorm:"type:uuid;not null"`
+	Content   string    `gorm:"type:text;not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_room_message"`
+
+	Room   Room `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Sender User `gorm:"foreignKey:SenderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// RoomBan 房间封禁名单，JoinRoom 时检查
+type RoomBan struct {
+	ID        string     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	RoomID    string     `gorm:"type:uuid;not null;index:idx_room_ban,unique"`
+	UserID    string     `gorm:"type:uuid;not null;index:idx_room_ban,unique"`
+	BannedBy  string     `gorm:"type:uuid;not null"`
+	Reason    string     `gorm:"type:varchar(200);default:''"`
+	ExpiresAt *time.Time `gorm:"default:null"` // 为空表示永久封禁
+	CreatedAt time.Time  `gorm:"autoCreateTime"`
+
+	Room Room `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// RoomMute 房间禁言，到期前 send_message 会被拒绝
+type RoomMute struct {
+	ID        string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	RoomID    string    `gorm:"type:uuid;not null;index:idx_room_mute,unique"`
+	UserID    string    `gorm:"type:uuid;not null;index:idx_room_mute,unique"`
+	MutedBy   string    `gorm:"type:uuid;not null"`
+	Reason    string    `gorm:"type:varchar(200);default:''"`
+	ExpiresAt time.Time `gorm:"not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+
+	Room Room `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+type ModerationAction string
+
+const (
+	ModerationKick   ModerationAction = "kick"
+	ModerationBan    ModerationAction = "ban"
+	ModerationUnban  ModerationAction = "unban"
+	ModerationMute   ModerationAction = "mute"
+	ModerationUnmute ModerationAction = "unmute"
+)
+
+// RoomModerationLog 房间管理操作记录
+type RoomModerationLog struct {
+	ID              string           `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	RoomID          string           `gorm:"type:uuid;not null;index:idx_room_moderation_log"`
+	ActorID         string           `gorm:"type:uuid;not null"`
+	TargetID        string           `gorm:"type:uuid;not null"`
+	Action          ModerationAction `gorm:"type:varchar(20);not null"`
+	Reason          string           `gorm:"type:varchar(200);default:''"`
+	DurationMinutes int              `gorm:"default:0"` // 封禁 / 禁言时长，0 表示永久或不适用
+	CreatedAt       time.Time        `gorm:"autoCreateTime;index:idx_room_moderation_log"`
+
+	Room   Room `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Actor  User `gorm:"foreignKey:ActorID"`
+	Target User `gorm:"foreignKey:TargetID"`
+}
+
+// RoomSchedule 房间的定时自习，一次性或按 RRULE 重复 (例如工作日 06:00-08:00 的早起打卡)
+type RoomSchedule struct {
+	ID              string     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	RoomID          string     `gorm:"type:uuid;not null;index"`
+	CreatorID       string     `gorm:"type:uuid;not null"`
+	Title           string     `gorm:"not null"`
+	StartTime       time.Time  `gorm:"not null"` // 第一次开始时间
+	DurationMinutes int        `gorm:"not null"`
+	RRule           string     `gorm:"type:varchar(255);default:''"` // 为空表示一次性，支持 FREQ=DAILY|WEEKLY;INTERVAL;BYDAY;COUNT;UNTIL
+	Timezone        string     `gorm:"type:varchar(64);not null"`    // 重复规则按该时区的本地时间展开
+	ReminderMinutes int        `gorm:"default:15"`                   // 开始前多少分钟提醒，0 表示不提醒
+	EndsAt          *time.Time `gorm:"default:null;index"`           // 最后一场的结束时间，不限次数的重复为空
+	CreatedAt       time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime"`
+
+	Room Room `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+type RSVPStatus string
+
+const (
+	RSVPGoing    RSVPStatus = "going"
+	RSVPMaybe    RSVPStatus = "maybe"
+	RSVPDeclined RSVPStatus = "declined"
+)
+
+// RoomScheduleRSVP 用户对某一场的回复，OccurrenceStart 标识重复日程中的具体场次
+type RoomScheduleRSVP struct {
+	ID              string     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	ScheduleID      string     `gorm:"type:uuid;not null;index:idx_schedule_rsvp,unique"`
+	OccurrenceStart time.Time  `gorm:"not null;index:idx_schedule_rsvp,unique"`
+	UserID          string     `gorm:"type:uuid;not null;index:idx_schedule_rsvp,unique"`
+	Status          RSVPStatus `gorm:"type:varchar(20);not null"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime"`
+
+	Schedule RoomSchedule `gorm:"foreignKey:ScheduleID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	User     User         `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
 type HealthData struct {
 	ID              string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
 	UserID          string    `gorm:"type:uuid;not null;index:idx_user_health_date,unique"`
@@ -17,6 +17,15 @@ func SetupRouter(r *gin.Engine) {
 	rankingHandler := &handler.RankingHandler{}
 	roomHandler := &handler.RoomHandler{}
 	tagHandler := &handler.TagHandler{}
+	goalHandler := &handler.GoalHandler{}
+	adminHandler := &handler.AdminHandler{}
+	achievementHandler := &handler.AchievementHandler{}
+	questHandler := &handler.QuestHandler{}
+	challengeHandler := &handler.ChallengeHandler{}
+	roomScheduleHandler := &handler.RoomScheduleHandler{}
+	roomModerationHandler := &handler.RoomModerationHandler{}
+	roomMessageHandler := &handler.RoomMessageHandler{}
+	presenceHandler := &handler.PresenceHandler{}
 
 	messageService := &service.MessageService{}
 	messageHandler := &handler.MessageHandler{Service: *messageService}
@@ -70,35 +79,57 @@ func SetupRouter(r *gin.Engine) {
 			userGroup.PATCH("/me", userHandler.UpdateMe)
 			userGroup.PATCH("/me/password", userHandler.ChangePassword)
 			userGroup.DELETE("/me", userHandler.DeleteAccount)
+			userGroup.POST("/me/prestige", userHandler.Prestige) // 满级转生
 
 			userGroup.GET("/search", userHandler.SearchUsers) // 对应 /users/search?query=xxx
 			userGroup.GET("/ambient", matchingHandler.GetAmbientBuddies) // 智能同频学伴推荐
 			userGroup.GET("/:id/public", userHandler.GetPublicProfile)
 			userGroup.GET("/:id/blogs", blogHandler.GetUserBlogs) // 获取某用户的博客列表
+			userGroup.GET("/:id/skills", tagHandler.GetUserSkills) // 技能树
 
 			// 用户的标签管理
 			userGroup.GET("/me/tags", tagHandler.GetMyTags)
 			userGroup.POST("/me/tags", tagHandler.AddTag)
 			userGroup.DELETE("/me/tags/:id", tagHandler.RemoveTag)
+
+			// 成就
+			userGroup.GET("/me/achievements", achievementHandler.GetMyAchievements)
 		}
 
 		// Study 路由
 		studyGroup := protected.Group("/study")
 		{
 			studyGroup.POST("/sessions/start", studyHandler.StartSession)
+			studyGroup.POST("/sessions/manual", studyHandler.CreateManualSession) // 补录离线学习
 			studyGroup.POST("/sessions/:id/end", studyHandler.EndSession) // 注意 :id
 			studyGroup.POST("/sessions/:id/heartbeat", studyHandler.Heartbeat) // 心跳
+			studyGroup.POST("/sessions/:id/pause", studyHandler.PauseSession)   // 暂停
+			studyGroup.POST("/sessions/:id/resume", studyHandler.ResumeSession) // 恢复
+			studyGroup.POST("/sessions/:id/sync", studyHandler.SyncSession)     // 离线心跳同步
 			studyGroup.GET("/sessions/active", studyHandler.GetActiveSession)
 			studyGroup.DELETE("/sessions/active", studyHandler.CancelActiveSession)
 			studyGroup.GET("/sessions", studyHandler.GetSessions) // 历史记录
+			studyGroup.GET("/pomodoro/active", studyHandler.GetActivePomodoro) // 当前番茄计划
 
 			studyGroup.GET("/stats/summary", studyHandler.GetStatsSummary)
+
+			studyGroup.GET("/streak", studyHandler.GetStreak)
+			studyGroup.GET("/streak/history", studyHandler.GetStreakHistory)
+			studyGroup.POST("/streak/freeze", studyHandler.UseStreakFreeze)       // 使用冻结卡
+			studyGroup.PATCH("/streak/settings", studyHandler.UpdateStreakSettings) // 每日达标门槛
+
+			studyGroup.GET("/goals", goalHandler.GetGoals)
+			studyGroup.POST("/goals", goalHandler.CreateGoal)
+			studyGroup.GET("/goals/history", goalHandler.GetGoalHistory)
+			studyGroup.PATCH("/goals/:id", goalHandler.UpdateGoal)
+			studyGroup.DELETE("/goals/:id", goalHandler.DeleteGoal)
 		}
 
 		// Friends 路由
 		friendGroup := protected.Group("/friends")
 		{
 			friendGroup.GET("", friendHandler.GetFriendList)
+			friendGroup.GET("/presence", presenceHandler.GetFriendsPresence) // 好友在线状态
 			friendGroup.DELETE("/:id", friendHandler.DeleteFriend) // 解除好友
 
 			friendGroup.POST("/requests", friendHandler.SendRequest)
@@ -109,6 +140,11 @@ func SetupRouter(r *gin.Engine) {
 		}
 
 		protected.GET("/rankings", rankingHandler.GetGlobalRankings)
+		protected.GET("/rankings/seasons", rankingHandler.GetSeasons)
+		protected.GET("/rankings/me", rankingHandler.GetMyRanking)
+		protected.GET("/rankings/history", rankingHandler.GetLeaderboardHistory)
+		protected.GET("/rankings/history/me", rankingHandler.GetMyLeaderboardHistory)
+		protected.GET("/rankings/history/:id", rankingHandler.GetLeaderboardSnapshot)
 
 		roomGroup := protected.Group("/rooms")
 		{
@@ -120,7 +156,24 @@ func SetupRouter(r *gin.Engine) {
 			roomGroup.DELETE("/:id", roomHandler.DeleteRoom) // 删除房间
 			roomGroup.POST("/validate-password", roomHandler.ValidatePassword) // 新增验证接口
 			roomGroup.GET("/:id/members", roomHandler.GetRoomMembers)
+			roomGroup.GET("/:id/messages", roomMessageHandler.GetMessages) // 聊天记录，游标分页 + 关键词搜索
 			roomGroup.PATCH("/:id/members/:userId/role", roomHandler.UpdateMemberRole) // 修改成员角色
+
+			// 房间管理 (房主和管理员)
+			roomGroup.POST("/:id/members/:userId/kick", roomModerationHandler.KickMember)
+			roomGroup.GET("/:id/bans", roomModerationHandler.GetBans)
+			roomGroup.POST("/:id/bans/:userId", roomModerationHandler.BanMember)
+			roomGroup.DELETE("/:id/bans/:userId", roomModerationHandler.UnbanMember)
+			roomGroup.POST("/:id/mutes/:userId", roomModerationHandler.MuteMember)
+			roomGroup.DELETE("/:id/mutes/:userId", roomModerationHandler.UnmuteMember)
+			roomGroup.GET("/:id/moderation-logs", roomModerationHandler.GetModerationLog)
+
+			// 定时自习 (一次性或 RRULE 重复)
+			roomGroup.GET("/:id/schedules", roomScheduleHandler.GetSchedules) // ?days=14
+			roomGroup.POST("/:id/schedules", roomScheduleHandler.CreateSchedule)
+			roomGroup.DELETE("/:id/schedules/:scheduleId", roomScheduleHandler.DeleteSchedule)
+			roomGroup.POST("/:id/schedules/:scheduleId/rsvp", roomScheduleHandler.RSVP)
+			roomGroup.GET("/:id/schedules/:scheduleId/attendance", roomScheduleHandler.GetAttendance) // ?start=RFC3339
 		}
 
 		// Analytics 路由
@@ -177,5 +230,29 @@ func SetupRouter(r *gin.Engine) {
 			notificationGroup.PATCH("/:id/read", notificationHandler.MarkAsRead)
 			notificationGroup.PATCH("/read-all", notificationHandler.MarkAllAsRead)
 		}
+
+		// Quest 路由
+		questGroup := protected.Group("/quests")
+		{
+			questGroup.GET("", questHandler.GetQuests)
+		}
+
+		// Challenge 路由 (好友挑战)
+		challengeGroup := protected.Group("/challenges")
+		{
+			challengeGroup.GET("", challengeHandler.GetChallenges)
+			challengeGroup.POST("", challengeHandler.CreateChallenge)
+			challengeGroup.GET("/:id", challengeHandler.GetChallenge)
+			challengeGroup.POST("/:id/invite", challengeHandler.InviteFriends)
+			challengeGroup.POST("/:id/respond", challengeHandler.Respond) // body: {action: accept|decline}
+		}
+
+		// Admin 路由
+		adminGroup := protected.Group("/admin")
+		adminGroup.Use(middleware.AdminMiddleware())
+		{
+			adminGroup.GET("/session-flags", adminHandler.GetSessionFlags)
+			adminGroup.POST("/session-flags/:id/review", adminHandler.ReviewSessionFlag)
+		}
 	}
 }
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"backend/internal/dto"
+	"backend/internal/model"
+	"backend/pkg/database"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// AchievementMetric 成就依据的统计指标
+type AchievementMetric string
+
+const (
+	MetricTotalMinutes   AchievementMetric = "total_minutes"   // 累计学习分钟数
+	MetricTagMinutes     AchievementMetric = "tag_minutes"     // 单个标签的最高学习分钟数
+	MetricSessions       AchievementMetric = "sessions"        // 完成的学习会话数
+	MetricLongestStreak  AchievementMetric = "longest_streak"  // 最长连续学习天数
+	MetricExcellentBlogs AchievementMetric = "excellent_blogs" // 被 AI 评为 excellent 的博客数
+	MetricRooms          AchievementMetric = "rooms"           // 加入过的自习室数
+	MetricFriends        AchievementMetric = "friends"         // 好友数
+)
+
+// AchievementTrigger 触发成就检查的事件，每种事件只重新计算相关的指标
+type AchievementTrigger string
+
+const (
+	AchievementTriggerSession AchievementTrigger = "session"
+	AchievementTriggerBlog    AchievementTrigger = "blog"
+	AchievementTriggerFriend  AchievementTrigger = "friend"
+)
+
+var achievementTriggerMetrics = map[AchievementTrigger][]AchievementMetric{
+	AchievementTriggerSession: {MetricTotalMinutes, MetricTagMinutes, MetricSessions, MetricLongestStreak, MetricRooms},
+	AchievementTriggerBlog:    {MetricExcellentBlogs},
+	AchievementTriggerFriend:  {MetricFriends},
+}
+
+// AchievementDef 成就定义：指标达到阈值即解锁
+type AchievementDef struct {
+	Code        string
+	Name        string
+	Description string
+	Icon        string
+	Metric      AchievementMetric
+	Threshold   int
+}
+
+// AchievementCatalog 成就目录，Code 一旦上线不能修改 (UserAchievement 按 Code 存储)
+var AchievementCatalog = []AchievementDef{
+	{Code: "first_session", Name: "First Steps", Description: "Complete your first study session", Icon: "🌱", Metric: MetricSessions, Threshold: 1},
+	{Code: "sessions_100", Name: "Regular", Description: "Complete 100 study sessions", Icon: "📚", Metric: MetricSessions, Threshold: 100},
+	{Code: "study_10h", Name: "Getting Started", Description: "Study for 10 hours in total", Icon: "⏱️", Metric: MetricTotalMinutes, Threshold: 10 * 60},
+	{Code: "study_100h", Name: "Dedicated", Description: "Study for 100 hours in total", Icon: "🔥", Metric: MetricTotalMinutes, Threshold: 100 * 60},
+	{Code: "study_1000h", Name: "Scholar", Description: "Study for 1000 hours in total", Icon: "🎓", Metric: MetricTotalMinutes, Threshold: 1000 * 60},
+	{Code: "tag_100h", Name: "Specialist", Description: "Study 100 hours in a single tag", Icon: "🎯", Metric: MetricTagMinutes, Threshold: 100 * 60},
+	{Code: "streak_7", Name: "On a Roll", Description: "Reach a 7-day study streak", Icon: "📅", Metric: MetricLongestStreak, Threshold: 7},
+	{Code: "streak_30", Name: "Unstoppable", Description: "Reach a 30-day study streak", Icon: "⚡", Metric: MetricLongestStreak, Threshold: 30},
+	{Code: "streak_100", Name: "Centurion", Description: "Reach a 100-day study streak", Icon: "🏛️", Metric: MetricLongestStreak, Threshold: 100},
+	{Code: "blog_excellent_1", Name: "Wordsmith", Description: "Write a blog rated excellent", Icon: "✍️", Metric: MetricExcellentBlogs, Threshold: 1},
+	{Code: "blog_excellent_10", Name: "Thought Leader", Description: "Write 10 blogs rated excellent", Icon: "🏆", Metric: MetricExcellentBlogs, Threshold: 10},
+	{Code: "rooms_5", Name: "Explorer", Description: "Study in 5 different rooms", Icon: "🧭", Metric: MetricRooms, Threshold: 5},
+	{Code: "friends_1", Name: "Study Buddy", Description: "Make your first friend", Icon: "🤝", Metric: MetricFriends, Threshold: 1},
+	{Code: "friends_10", Name: "Social Learner", Description: "Make 10 friends", Icon: "👥", Metric: MetricFriends, Threshold: 10},
+}
+
+type AchievementService struct{}
+
+// achievementMetricValue 计算某个用户的某项指标
+func achievementMetricValue(userID string, metric AchievementMetric) (int, error) {
+	var value int64
+	var err error
+
+	switch metric {
+	case MetricTotalMinutes:
+		err = database.DB.Model(&model.DailyStat{}).Where("user_id = ?", userID).
+			Select("COALESCE(SUM(total_minutes), 0)").Scan(&value).Error
+	case MetricTagMinutes:
+		err = database.DB.Model(&model.UserTagStat{}).Where("user_id = ?", userID).
+			Select("COALESCE(MAX(total_minutes), 0)").Scan(&value).Error
+	case MetricSessions:
+		err = database.DB.Model(&model.StudySession{}).
+			Where("user_id = ? AND end_time IS NOT NULL AND type <> ?", userID, model.SessionTypeRest).
+			Count(&value).Error
+	case MetricLongestStreak:
+		err = database.DB.Model(&model.UserStreak{}).Where("user_id = ?", userID).
+			Select("COALESCE(MAX(longest_streak), 0)").Scan(&value).Error
+	case MetricExcellentBlogs:
+		err = database.DB.Model(&model.Blog{}).
+			Where("user_id = ? AND ai_quality = ?", userID, model.BlogQualityExcellent).
+			Count(&value).Error
+	case MetricRooms:
+		err = database.DB.Model(&model.RoomMember{}).Where("user_id = ?", userID).
+			Distinct("room_id").Count(&value).Error
+	case MetricFriends:
+		err = database.DB.Model(&model.Friend{}).
+			Where("status = ? AND (user_id = ? OR friend_id = ?)", model.FriendStatusAccepted, userID, userID).
+			Count(&value).Error
+	default:
+		return 0, fmt.Errorf("unknown achievement metric %s", metric)
+	}
+	return int(value), err
+}
+
+// Evaluate 检查与事件相关的成就，新解锁的成就写库并通过 socket 和通知告知用户
+func (s *AchievementService) Evaluate(userID string, trigger AchievementTrigger) error {
+	var unlocked []string
+	if err := database.DB.Model(&model.UserAchievement{}).Where("user_id = ?", userID).Pluck("code", &unlocked).Error; err != nil {
+		return err
+	}
+	has := make(map[string]bool, len(unlocked))
+	for _, code := range unlocked {
+		has[code] = true
+	}
+
+	values := make(map[AchievementMetric]int)
+	for _, metric := range achievementTriggerMetrics[trigger] {
+		// 这个指标下的成就都已解锁时不再计算
+		pending := false
+		for _, def := range AchievementCatalog {
+			if def.Metric == metric && !has[def.Code] {
+				pending = true
+				break
+			}
+		}
+		if !pending {
+			continue
+		}
+
+		value, err := achievementMetricValue(userID, metric)
+		if err != nil {
+			return err
+		}
+		values[metric] = value
+	}
+
+	for _, def := range AchievementCatalog {
+		value, ok := values[def.Metric]
+		if !ok || has[def.Code] || value < def.Threshold {
+			continue
+		}
+
+		// 唯一索引保证并发触发时只解锁一次
+		record := model.UserAchievement{UserID: userID, Code: def.Code}
+		result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		s.announce(userID, def, record.UnlockedAt)
+	}
+	return nil
+}
+
+// EvaluateAsync 在后台检查成就，不影响主流程
+func (s *AchievementService) EvaluateAsync(userID string, trigger AchievementTrigger) {
+	go func() {
+		if err := s.Evaluate(userID, trigger); err != nil {
+			log.Printf("[AchievementService] Failed to evaluate %s achievements for user %s: %v", trigger, userID, err)
+		}
+	}()
+}
+
+func (s *AchievementService) announce(userID string, def AchievementDef, unlockedAt time.Time) {
+	emitToUser(userID, "achievement_unlocked", dto.AchievementUnlockedEvent{
+		Achievement: toAchievementResponse(def, &unlockedAt),
+	})
+
+	notificationService := &NotificationService{}
+	content := fmt.Sprintf("%s %s: %s", def.Icon, def.Name, def.Description)
+	if err := notificationService.Notify(userID, model.NotificationTypeBadge, "Achievement unlocked", content, nil); err != nil {
+		log.Printf("[AchievementService] Failed to notify user %s: %v", userID, err)
+	}
+}
+
+// GetUnlocked 用户已解锁的成就 (按解锁时间倒序)，用于公开主页
+func (s *AchievementService) GetUnlocked(userID string) ([]dto.AchievementResponse, error) {
+	var records []model.UserAchievement
+	if err := database.DB.Where("user_id = ?", userID).Order("unlocked_at DESC").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	defs := make(map[string]AchievementDef, len(AchievementCatalog))
+	for _, def := range AchievementCatalog {
+		defs[def.Code] = def
+	}
+
+	items := make([]dto.AchievementResponse, 0, len(records))
+	for i := range records {
+		// 已下线的成就不再展示
+		def, ok := defs[records[i].Code]
+		if !ok {
+			continue
+		}
+		items = append(items, toAchievementResponse(def, &records[i].UnlockedAt))
+	}
+	return items, nil
+}
+
+// GetCatalog 完整的成就目录，附带当前用户的解锁状态和进度
+func (s *AchievementService) GetCatalog(userID string) ([]dto.AchievementResponse, error) {
+	var records []model.UserAchievement
+	if err := database.DB.Where("user_id = ?", userID).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	unlockedAt := make(map[string]time.Time, len(records))
+	for _, r := range records {
+		unlockedAt[r.Code] = r.UnlockedAt
+	}
+
+	values := make(map[AchievementMetric]int)
+	items := make([]dto.AchievementResponse, 0, len(AchievementCatalog))
+	for _, def := range AchievementCatalog {
+		value, ok := values[def.Metric]
+		if !ok {
+			v, err := achievementMetricValue(userID, def.Metric)
+			if err != nil {
+				return nil, err
+			}
+			values[def.Metric] = v
+			value = v
+		}
+
+		var at *time.Time
+		if t, ok := unlockedAt[def.Code]; ok {
+			at = &t
+		}
+		item := toAchievementResponse(def, at)
+		progress := min(value, def.Threshold)
+		item.Progress = &progress
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func toAchievementResponse(def AchievementDef, unlockedAt *time.Time) dto.AchievementResponse {
+	return dto.AchievementResponse{
+		Code:        def.Code,
+		Name:        def.Name,
+		Description: def.Description,
+		Icon:        def.Icon,
+		Threshold:   def.Threshold,
+		Unlocked:    unlockedAt != nil,
+		UnlockedAt:  unlockedAt,
+	}
+}
@@ -0,0 +1,380 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/pkg/database"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AggregateDrift 聚合数据与按原始数据重算结果之间的一处差异
+type AggregateDrift struct {
+	Target string // daily_stats / user_tag_stats / 排行榜 Redis Key
+	UserID string
+	Key    string // 日期或标签 ID，排行榜为空
+	Field  string // total_minutes / xp / score
+	Before int
+	After  int
+}
+
+// RebuildReport 一次重算的结果
+type RebuildReport struct {
+	Users  int
+	Drifts []AggregateDrift
+}
+
+// userAggregates 按学习会话与经验流水重算出的某个用户的全部聚合值
+type userAggregates struct {
+	dailyMinutes map[time.Time]int
+	dailyXP      map[time.Time]int
+	tagMinutes   map[string]int
+	tagXP        map[string]int
+	rankScores   map[string]int // 排行榜 Key -> 分钟
+}
+
+// computeUserAggregates 口径与 creditSession 保持一致：
+// 休息段不计入；跨天会话按用户时区拆分；补录会话默认不进排行榜；待审核 / 被驳回的会话不进排行榜；
+// 排行榜按结束时间划分周期
+func computeUserAggregates(db *gorm.DB, userID string, seasons []model.RankingSeason) (*userAggregates, error) {
+	agg := &userAggregates{
+		dailyMinutes: make(map[time.Time]int),
+		dailyXP:      make(map[time.Time]int),
+		tagMinutes:   make(map[string]int),
+		tagXP:        make(map[string]int),
+		rankScores:   make(map[string]int),
+	}
+
+	var sessions []model.StudySession
+	if err := db.Preload("Pauses").
+		Where("user_id = ? AND end_time IS NOT NULL AND type <> ?", userID, model.SessionTypeRest).
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	loc := userLocation(db, userID)
+	for _, sess := range sessions {
+		minutes := 0
+		for _, day := range splitSpansByDay(focusSpans(sess.StartTime, *sess.EndTime, sess.Pauses), loc) {
+			agg.dailyMinutes[day.Date] += day.Minutes
+			minutes += day.Minutes
+		}
+		if sess.TagID != nil {
+			agg.tagMinutes[*sess.TagID] += minutes
+		}
+		if !isHeldFromRankings(&sess) && (!sess.IsManual || ManualSessionInRankings) {
+			for _, p := range rankingPeriodsWith(*sess.EndTime, seasons) {
+				agg.rankScores[rankingKey(p, "")] += minutes
+				if sess.TagID != nil {
+					agg.rankScores[rankingKey(p, *sess.TagID)] += minutes
+				}
+			}
+		}
+	}
+
+	var dailyXP []struct {
+		Date time.Time
+		XP   int
+	}
+	if err := db.Model(&model.XPEvent{}).
+		Select("date, SUM(amount) AS xp").
+		Where("user_id = ?", userID).
+		Group("date").
+		Scan(&dailyXP).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range dailyXP {
+		agg.dailyXP[dateOnly(r.Date)] += r.XP
+	}
+
+	var tagXP []struct {
+		TagID string
+		XP    int
+	}
+	if err := db.Model(&model.XPEvent{}).
+		Select("tag_id, SUM(amount) AS xp").
+		Where("user_id = ? AND tag_id IS NOT NULL", userID).
+		Group("tag_id").
+		Scan(&tagXP).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range tagXP {
+		agg.tagXP[r.TagID] += r.XP
+	}
+
+	return agg, nil
+}
+
+// RebuildAggregates 按学习会话与经验流水重算 DailyStat、UserTagStat 以及各周期的全站 / 标签排行榜
+// userIDs 为空时处理全部用户，并清理排行榜中已经不存在的用户；dryRun 只返回差异不写入
+func RebuildAggregates(userIDs []string, dryRun bool) (*RebuildReport, error) {
+	full := len(userIDs) == 0
+	if full {
+		if err := database.DB.Model(&model.User{}).Order("created_at ASC").Pluck("id", &userIDs).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var seasons []model.RankingSeason
+	if err := database.DB.Order("start_date ASC").Find(&seasons).Error; err != nil {
+		return nil, err
+	}
+	periods := activeRankingPeriods(time.Now(), seasons)
+
+	report := &RebuildReport{Users: len(userIDs)}
+	rankingKeys := make(map[string]bool)
+	for _, userID := range userIDs {
+		drifts, err := rebuildUserAggregates(userID, seasons, periods, rankingKeys, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		report.Drifts = append(report.Drifts, drifts...)
+	}
+
+	if full {
+		keys := make([]string, 0, len(rankingKeys))
+		for key := range rankingKeys {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		drifts, err := pruneRankingMembers(keys, userIDs, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		report.Drifts = append(report.Drifts, drifts...)
+	}
+
+	return report, nil
+}
+
+// rebuildUserAggregates 重算单个用户，返回差异；检查过的排行榜 Key 记录到 rankingKeys
+func rebuildUserAggregates(userID string, seasons []model.RankingSeason, periods []rankingPeriod, rankingKeys map[string]bool, dryRun bool) ([]AggregateDrift, error) {
+	var drifts []AggregateDrift
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		agg, err := computeUserAggregates(tx, userID, seasons)
+		if err != nil {
+			return err
+		}
+
+		// 1. DailyStat
+		var stats []model.DailyStat
+		if err := tx.Where("user_id = ?", userID).Find(&stats).Error; err != nil {
+			return err
+		}
+		curMinutes := make(map[time.Time]int)
+		curXP := make(map[time.Time]int)
+		for _, st := range stats {
+			d := dateOnly(st.Date)
+			curMinutes[d] += st.TotalMinutes
+			curXP[d] += st.XP
+		}
+
+		dates := make(map[time.Time]bool)
+		for _, m := range []map[time.Time]int{curMinutes, curXP, agg.dailyMinutes, agg.dailyXP} {
+			for d := range m {
+				dates[d] = true
+			}
+		}
+		sortedDates := make([]time.Time, 0, len(dates))
+		for d := range dates {
+			sortedDates = append(sortedDates, d)
+		}
+		sort.Slice(sortedDates, func(i, j int) bool { return sortedDates[i].Before(sortedDates[j]) })
+
+		dailyChanged := false
+		for _, d := range sortedDates {
+			minutes, xp := agg.dailyMinutes[d], agg.dailyXP[d]
+			if curMinutes[d] == minutes && curXP[d] == xp {
+				continue
+			}
+			key := d.Format("2006-01-02")
+			if curMinutes[d] != minutes {
+				drifts = append(drifts, AggregateDrift{Target: "daily_stats", UserID: userID, Key: key, Field: "total_minutes", Before: curMinutes[d], After: minutes})
+			}
+			if curXP[d] != xp {
+				drifts = append(drifts, AggregateDrift{Target: "daily_stats", UserID: userID, Key: key, Field: "xp", Before: curXP[d], After: xp})
+			}
+			dailyChanged = true
+			if dryRun {
+				continue
+			}
+
+			if minutes == 0 && xp == 0 {
+				if err := tx.Where("user_id = ? AND date = ?", userID, d).Delete(&model.DailyStat{}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "date"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{"total_minutes": minutes, "xp": xp}),
+			}).Create(&model.DailyStat{
+				UserID:       userID,
+				Date:         d,
+				TotalMinutes: minutes,
+				XP:           xp,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		// 2. UserTagStat
+		var tagStats []model.UserTagStat
+		if err := tx.Where("user_id = ?", userID).Find(&tagStats).Error; err != nil {
+			return err
+		}
+		curTagMinutes := make(map[string]int)
+		curTagXP := make(map[string]int)
+		for _, st := range tagStats {
+			curTagMinutes[st.TagID] = st.TotalMinutes
+			curTagXP[st.TagID] = st.XP
+		}
+
+		tagIDs := make(map[string]bool)
+		for _, m := range []map[string]int{curTagMinutes, curTagXP, agg.tagMinutes, agg.tagXP} {
+			for id := range m {
+				tagIDs[id] = true
+			}
+		}
+		sortedTags := make([]string, 0, len(tagIDs))
+		for id := range tagIDs {
+			sortedTags = append(sortedTags, id)
+		}
+		sort.Strings(sortedTags)
+
+		for _, tagID := range sortedTags {
+			minutes, xp := agg.tagMinutes[tagID], agg.tagXP[tagID]
+			if curTagMinutes[tagID] == minutes && curTagXP[tagID] == xp {
+				continue
+			}
+			if curTagMinutes[tagID] != minutes {
+				drifts = append(drifts, AggregateDrift{Target: "user_tag_stats", UserID: userID, Key: tagID, Field: "total_minutes", Before: curTagMinutes[tagID], After: minutes})
+			}
+			if curTagXP[tagID] != xp {
+				drifts = append(drifts, AggregateDrift{Target: "user_tag_stats", UserID: userID, Key: tagID, Field: "xp", Before: curTagXP[tagID], After: xp})
+			}
+			if dryRun {
+				continue
+			}
+
+			if minutes == 0 && xp == 0 {
+				if err := tx.Where("user_id = ? AND tag_id = ?", userID, tagID).Delete(&model.UserTagStat{}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "tag_id"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{"total_minutes": minutes, "xp": xp}),
+			}).Create(&model.UserTagStat{
+				UserID:       userID,
+				TagID:        tagID,
+				TotalMinutes: minutes,
+				XP:           xp,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		if dailyChanged && !dryRun {
+			if err := (&StreakService{}).RecomputeStreak(tx, userID); err != nil {
+				return err
+			}
+		}
+
+		// 3. 排行榜 (Redis 不参与事务，放在最后，写失败时回滚数据库部分)
+		// 标签榜检查会话涉及的标签以及原有的标签统计，覆盖标签被改掉的情况
+		for _, p := range periods {
+			keys := []string{rankingKey(p, "")}
+			for _, tagID := range sortedTags {
+				keys = append(keys, rankingKey(p, tagID))
+			}
+			for _, key := range keys {
+				rankingKeys[key] = true
+				drift, err := rebuildRankingScore(key, p, userID, agg.rankScores[key], dryRun)
+				if err != nil {
+					return err
+				}
+				if drift != nil {
+					drifts = append(drifts, *drift)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return drifts, nil
+}
+
+// rebuildRankingScore 校正某个排行榜上单个用户的分数，分数为 0 时移出榜单
+func rebuildRankingScore(key string, p rankingPeriod, userID string, expected int, dryRun bool) (*AggregateDrift, error) {
+	ctx := context.Background()
+
+	current := 0
+	score, err := database.RDB.ZScore(ctx, key, userID).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	if err == nil {
+		current = int(score)
+	}
+	if current == expected {
+		return nil, nil
+	}
+
+	drift := &AggregateDrift{Target: key, UserID: userID, Field: "score", Before: current, After: expected}
+	if dryRun {
+		return drift, nil
+	}
+
+	if expected == 0 {
+		err = database.RDB.ZRem(ctx, key, userID).Err()
+	} else {
+		err = database.RDB.ZAdd(ctx, key, redis.Z{Score: float64(expected), Member: userID}).Err()
+		if err == nil && p.TTL > 0 {
+			database.RDB.Expire(ctx, key, p.TTL)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return drift, nil
+}
+
+// pruneRankingMembers 移除排行榜中已不存在的用户
+func pruneRankingMembers(keys []string, userIDs []string, dryRun bool) ([]AggregateDrift, error) {
+	ctx := context.Background()
+	known := make(map[string]bool, len(userIDs))
+	for _, id := range userIDs {
+		known[id] = true
+	}
+
+	var drifts []AggregateDrift
+	for _, key := range keys {
+		members, err := database.RDB.ZRangeWithScores(ctx, key, 0, -1).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, z := range members {
+			member := z.Member.(string)
+			if known[member] {
+				continue
+			}
+			drifts = append(drifts, AggregateDrift{Target: key, UserID: member, Field: "score", Before: int(z.Score), After: 0})
+			if dryRun {
+				continue
+			}
+			if err := database.RDB.ZRem(ctx, key, member).Err(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return drifts, nil
+}
@@ -12,11 +12,14 @@ type AnalyticsService struct{}
 
 // GetActivityHeatmap returns total study minutes per day for the given year
 func (s *AnalyticsService) GetActivityHeatmap(userID string, year int) (*dto.ActivityHeatmapResponse, error) {
-	// Fallback to current year if 0
+	// Fallback to current year (in the user's timezone) if 0
+	loc := userLocation(database.DB, userID)
 	if year == 0 {
-		year = time.Now().Year()
+		year = time.Now().In(loc).Year()
 	}
 
+	// DailyStat rows are already bucketed by the user's local day
+
 	startDate := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
 	endDate := time.Date(year, 12, 31, 23, 59, 59, 999999999, time.UTC)
 
@@ -68,10 +71,13 @@ func (s *AnalyticsService) GetTimeMatrix(userID string, days int) (*dto.TimeMatr
 		grid[i] = make([]int, 24)
 	}
 
+	// Bin by the user's local wall clock, not the server's
+	loc := userLocation(database.DB, userID)
+
 	// Process each session
 	for _, session := range sessions {
-		curTime := session.StartTime
-		endTime := *session.EndTime
+		curTime := session.StartTime.In(loc)
+		endTime := session.EndTime.In(loc)
 
 		// Distribute the duration minute-by-minute (or by chunk) into the grid.
 		// Since sessions are relatively short, minute iteration is fine, but chunking is safer.
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/pkg/database"
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 反作弊规则阈值
+var (
+	AntiCheatMaxSessionMinutes = 8 * 60                // 单次会话净专注时长上限
+	AntiCheatMaxDailyMinutes   = 16 * 60               // 单日累计学习时长上限
+	AntiCheatMaxDevices        = 2                     // 同一会话允许的心跳来源数
+	AntiCheatMinHeartbeats     = 30                    // 至少收集到多少次心跳才判断规律性
+	AntiCheatMinJitter         = 15 * time.Millisecond // 心跳间隔标准差低于该值视为脚本
+)
+
+// 反作弊规则
+const (
+	AntiCheatRuleDuration         = "implausible_duration"
+	AntiCheatRuleRegularHeartbeat = "regular_heartbeat"
+	AntiCheatRuleDevices          = "multiple_devices"
+	AntiCheatRuleDailyTotal       = "daily_total"
+)
+
+// heartbeatLogSize 每个会话保留最近多少次心跳时间
+const heartbeatLogSize = 200
+
+func heartbeatLogKey(sessionID string) string {
+	return fmt.Sprintf("study:beats:%s", sessionID)
+}
+
+func heartbeatDevicesKey(sessionID string) string {
+	return fmt.Sprintf("study:devices:%s", sessionID)
+}
+
+// recordHeartbeat 记录心跳到达时间和来源设备，会话结束时用于规则判断
+func recordHeartbeat(ctx context.Context, sessionID, device string, at time.Time) error {
+	logKey := heartbeatLogKey(sessionID)
+	devicesKey := heartbeatDevicesKey(sessionID)
+
+	pipe := database.RDB.Pipeline()
+	pipe.RPush(ctx, logKey, at.UnixMilli())
+	pipe.LTrim(ctx, logKey, -heartbeatLogSize, -1)
+	pipe.Expire(ctx, logKey, 26*time.Hour)
+	if device != "" {
+		pipe.SAdd(ctx, devicesKey, device)
+		pipe.Expire(ctx, devicesKey, 26*time.Hour)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// isHeldFromRankings 待审核或被驳回的会话不计入排行榜
+func isHeldFromRankings(session *model.StudySession) bool {
+	return session.ReviewStatus == model.SessionReviewPending || session.ReviewStatus == model.SessionReviewRejected
+}
+
+// screenSession 会话计入统计时执行反作弊规则，命中任一规则即标记为待审核 (在事务中调用)
+// 补录会话有单独的限制且不进排行榜，已经审核过的会话不再重复检查
+// 返回会话是否需要暂不计入排行榜
+func (s *StudyService) screenSession(tx *gorm.DB, session *model.StudySession, days []dayMinutes, minutes int) (bool, error) {
+	if session.IsManual || session.Type == model.SessionTypeRest || session.ReviewStatus != "" {
+		return isHeldFromRankings(session), nil
+	}
+
+	var rules, details []string
+	hit := func(rule, detail string) {
+		rules = append(rules, rule)
+		details = append(details, fmt.Sprintf("%s: %s", rule, detail))
+	}
+
+	// 1. 单次时长
+	if minutes > AntiCheatMaxSessionMinutes {
+		hit(AntiCheatRuleDuration, fmt.Sprintf("%d minutes (limit %d)", minutes, AntiCheatMaxSessionMinutes))
+	}
+
+	// 2. 心跳间隔过于规律
+	ctx := context.Background()
+	beats, err := database.RDB.LRange(ctx, heartbeatLogKey(session.ID), 0, -1).Result()
+	if err != nil {
+		return false, err
+	}
+	if stddev, ok := heartbeatJitter(beats); ok && stddev < AntiCheatMinJitter {
+		hit(AntiCheatRuleRegularHeartbeat, fmt.Sprintf("interval stddev %s over %d heartbeats", stddev, len(beats)))
+	}
+
+	// 3. 心跳来自过多设备
+	devices, err := database.RDB.SCard(ctx, heartbeatDevicesKey(session.ID)).Result()
+	if err != nil {
+		return false, err
+	}
+	if int(devices) > AntiCheatMaxDevices {
+		hit(AntiCheatRuleDevices, fmt.Sprintf("%d devices (limit %d)", devices, AntiCheatMaxDevices))
+	}
+
+	// 4. 单日累计时长 (DailyStat 此时已包含本次会话)
+	for _, day := range days {
+		var total int
+		tx.Model(&model.DailyStat{}).
+			Where("user_id = ? AND date = ?", session.UserID, day.Date).
+			Select("COALESCE(SUM(total_minutes), 0)").
+			Scan(&total)
+		if total > AntiCheatMaxDailyMinutes {
+			hit(AntiCheatRuleDailyTotal, fmt.Sprintf("%d minutes on %s (limit %d)", total, day.Date.Format("2006-01-02"), AntiCheatMaxDailyMinutes))
+			break
+		}
+	}
+
+	if len(rules) == 0 {
+		return false, nil
+	}
+
+	err = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.SessionFlag{
+		SessionID: session.ID,
+		UserID:    session.UserID,
+		Rules:     pq.StringArray(rules),
+		Details:   strings.Join(details, "\n"),
+		Status:    model.SessionReviewPending,
+	}).Error
+	if err != nil {
+		return false, err
+	}
+
+	session.ReviewStatus = model.SessionReviewPending
+	if err := tx.Model(&model.StudySession{}).Where("id = ?", session.ID).
+		Update("review_status", model.SessionReviewPending).Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// heartbeatJitter 心跳间隔的标准差，心跳次数不足时 ok 为 false
+func heartbeatJitter(beats []string) (time.Duration, bool) {
+	if len(beats) < AntiCheatMinHeartbeats {
+		return 0, false
+	}
+
+	intervals := make([]float64, 0, len(beats)-1)
+	prev := int64(-1)
+	for _, b := range beats {
+		ms, err := strconv.ParseInt(b, 10, 64)
+		if err != nil {
+			continue
+		}
+		if prev >= 0 {
+			intervals = append(intervals, float64(ms-prev))
+		}
+		prev = ms
+	}
+	if len(intervals) < AntiCheatMinHeartbeats-1 {
+		return 0, false
+	}
+
+	mean := 0.0
+	for _, v := range intervals {
+		mean += v
+	}
+	mean /= float64(len(intervals))
+
+	variance := 0.0
+	for _, v := range intervals {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(intervals))
+
+	return time.Duration(math.Sqrt(variance) * float64(time.Millisecond)), true
+}
+
+// xpRevokedFor 会话的经验是否已在审核中被撤销
+func xpRevokedFor(tx *gorm.DB, session *model.StudySession) bool {
+	if session.ReviewStatus != model.SessionReviewRejected {
+		return false
+	}
+	var count int64
+	tx.Model(&model.SessionFlag{}).Where("session_id = ? AND xp_revoked = ?", session.ID, true).Count(&count)
+	return count > 0
+}
@@ -32,6 +32,8 @@ func (s *AuthService) Register(req dto.RegisterRequest) (*model.User, string, st
 		Email:        req.Email,
 		PasswordHash: hashedPwd,
 		Nickname:     req.Nickname,
+		// 新用户直接按当前曲线计算，不需要 levelmigrate
+		LevelCurveVersion: CurrentLevelCurve().Version,
 	}
 	if err := database.DB.Create(&newUser).Error; err != nil {
 		return nil, "", "", err
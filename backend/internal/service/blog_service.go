@@ -7,11 +7,9 @@ import (
 	"errors"
 	"log"
 	"strings"
-	"time"
 
 	"github.com/lib/pq"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
 type BlogService struct {
@@ -60,6 +58,9 @@ func (s *BlogService) CreateBlog(userID string, req dto.CreateBlogRequest) (*mod
 			}
 		}(blog.ID, userID)
 	}
+	if status == model.BlogStatusPublished {
+		(&QuestService{}).RefreshAsync(userID, QuestTriggerBlog)
+	}
 
 	if err != nil {
 		return nil, err
@@ -226,6 +227,9 @@ func (s *BlogService) UpdateBlog(userID, blogID string, req dto.UpdateBlogReques
 			}
 		}(blogID, userID)
 	}
+	if req.Status != nil {
+		(&QuestService{}).RefreshAsync(userID, QuestTriggerBlog)
+	}
 
 	// 重新加载
 	if err := database.DB.Preload("User").Preload("BlogTags").First(&blog, "id = ?", blogID).Error; err != nil {
@@ -237,14 +241,21 @@ func (s *BlogService) UpdateBlog(userID, blogID string, req dto.UpdateBlogReques
 
 // DeleteBlog 删除博客
 func (s *BlogService) DeleteBlog(userID, blogID string) error {
-	result := database.DB.Where("id = ? AND user_id = ?", blogID, userID).Delete(&model.Blog{})
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return errors.New("blog not found or not owned by you")
-	}
-	return nil
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", blogID, userID).Delete(&model.Blog{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("blog not found or not owned by you")
+		}
+
+		// 撤销这篇博客带来的全部经验 (AI 评分、点赞、收藏)
+		xpService := &XPService{}
+		_, err := xpService.Reverse(tx, userID,
+			[]model.XPSource{model.XPSourceBlogAI, model.XPSourceBlogLike, model.XPSourceBlogBookmark}, blogID, nil)
+		return err
+	})
 }
 
 // LikeBlog 点赞
@@ -270,30 +281,19 @@ func (s *BlogService) LikeBlog(userID, blogID string) error {
 	if result.RowsAffected > 0 {
 		database.DB.Model(&blog).UpdateColumn("like_count", gorm.Expr("like_count + 1"))
 
-		// 发放 XP 给作者 (点赞：基础1, good*2, excellent*3)
-		xp := 1
-		if blog.AIQuality != nil {
-			if *blog.AIQuality == model.BlogQualityGood {
-				xp = 2
-			} else if *blog.AIQuality == model.BlogQualityExcellent {
-				xp = 3
-			}
-		}
-		
-		now := time.Now()
-		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
-		
-		dailyStat := model.DailyStat{
-			UserID:       blog.UserID, // 发给作者
-			Date:         today,
-			TotalMinutes: xp,
-		}
-		database.DB.Clauses(clause.OnConflict{
-			Columns:   []clause.Column{{Name: "user_id"}, {Name: "date"}},
-			DoUpdates: clause.Assignments(map[string]interface{}{
-				"total_minutes": gorm.Expr("daily_stats.total_minutes + ?", xp),
-			}),
-		}).Create(&dailyStat)
+		// 发放 XP 给作者
+		xp := likeXP(blog.AIQuality)
+
+		xpService := &XPService{}
+		database.DB.Transaction(func(tx *gorm.DB) error {
+			return xpService.Grant(tx, XPGrant{
+				UserID:      blog.UserID, // 发给作者
+				Source:      model.XPSourceBlogLike,
+				ReferenceID: blog.ID,
+				ActorID:     &userID,
+				Amount:      xp,
+			})
+		})
 	}
 
 	return nil
@@ -308,6 +308,17 @@ func (s *BlogService) UnlikeBlog(userID, blogID string) error {
 	if result.RowsAffected > 0 {
 		database.DB.Model(&model.Blog{}).Where("id = ?", blogID).
 			UpdateColumn("like_count", gorm.Expr("GREATEST(like_count - 1, 0)"))
+
+		// 收回作者因此获得的经验，避免反复操作刷经验
+		var authorID string
+		database.DB.Model(&model.Blog{}).Select("user_id").Where("id = ?", blogID).Scan(&authorID)
+		if authorID != "" {
+			xpService := &XPService{}
+			database.DB.Transaction(func(tx *gorm.DB) error {
+				_, err := xpService.Reverse(tx, authorID, []model.XPSource{model.XPSourceBlogLike}, blogID, &userID)
+				return err
+			})
+		}
 	}
 	return nil
 }
@@ -332,30 +343,19 @@ func (s *BlogService) BookmarkBlog(userID, blogID string) error {
 	if result.RowsAffected > 0 {
 		database.DB.Model(&blog).UpdateColumn("bookmark_count", gorm.Expr("bookmark_count + 1"))
 
-		// 发放 XP 给作者 (收藏：基础2, good*3, excellent*5)
-		xp := 2
-		if blog.AIQuality != nil {
-			if *blog.AIQuality == model.BlogQualityGood {
-				xp = 3
-			} else if *blog.AIQuality == model.BlogQualityExcellent {
-				xp = 5
-			}
-		}
-		
-		now := time.Now()
-		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
-		
-		dailyStat := model.DailyStat{
-			UserID:       blog.UserID, // 发给作者
-			Date:         today,
-			TotalMinutes: xp,
-		}
-		database.DB.Clauses(clause.OnConflict{
-			Columns:   []clause.Column{{Name: "user_id"}, {Name: "date"}},
-			DoUpdates: clause.Assignments(map[string]interface{}{
-				"total_minutes": gorm.Expr("daily_stats.total_minutes + ?", xp),
-			}),
-		}).Create(&dailyStat)
+		// 发放 XP 给作者
+		xp := bookmarkXP(blog.AIQuality)
+
+		xpService := &XPService{}
+		database.DB.Transaction(func(tx *gorm.DB) error {
+			return xpService.Grant(tx, XPGrant{
+				UserID:      blog.UserID, // 发给作者
+				Source:      model.XPSourceBlogBookmark,
+				ReferenceID: blog.ID,
+				ActorID:     &userID,
+				Amount:      xp,
+			})
+		})
 	}
 
 	return nil
@@ -370,10 +370,45 @@ func (s *BlogService) UnbookmarkBlog(userID, blogID string) error {
 	if result.RowsAffected > 0 {
 		database.DB.Model(&model.Blog{}).Where("id = ?", blogID).
 			UpdateColumn("bookmark_count", gorm.Expr("GREATEST(bookmark_count - 1, 0)"))
+
+		// 收回作者因此获得的经验，避免反复操作刷经验
+		var authorID string
+		database.DB.Model(&model.Blog{}).Select("user_id").Where("id = ?", blogID).Scan(&authorID)
+		if authorID != "" {
+			xpService := &XPService{}
+			database.DB.Transaction(func(tx *gorm.DB) error {
+				_, err := xpService.Reverse(tx, authorID, []model.XPSource{model.XPSourceBlogBookmark}, blogID, &userID)
+				return err
+			})
+		}
 	}
 	return nil
 }
 
+// likeXP 博客被点赞时作者获得的经验 (基础1, good*2, excellent*3)
+func likeXP(quality *model.BlogQuality) int {
+	if quality != nil {
+		if *quality == model.BlogQualityGood {
+			return 2
+		} else if *quality == model.BlogQualityExcellent {
+			return 3
+		}
+	}
+	return 1
+}
+
+// bookmarkXP 博客被收藏时作者获得的经验 (基础2, good*3, excellent*5)
+func bookmarkXP(quality *model.BlogQuality) int {
+	if quality != nil {
+		if *quality == model.BlogQualityGood {
+			return 3
+		} else if *quality == model.BlogQualityExcellent {
+			return 5
+		}
+	}
+	return 2
+}
+
 // CheckUserInteraction 检查用户对博客的点赞/收藏状态
 func (s *BlogService) CheckUserInteraction(userID, blogID string) (liked bool, bookmarked bool) {
 	if userID == "" {
@@ -513,46 +548,33 @@ func (s *BlogService) processAITaggingAndXP(blogID string, userID string) error
 			return err
 		}
 
-		// 6. 发放 XP (更新 UserTagStat 和 DailyStat)
-		if aiRes.XpPerTag > 0 && len(finalTags) > 0 {
-			now := time.Now()
-			today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
-			totalXPAdded := 0
-
+		// 6. 发放 XP (写入 XP 流水，由 XPService 维护 UserTagStat.XP 和 DailyStat.XP)
+		// 博客编辑后会重新评分，先撤销上一次评分发放的经验
+		xpService := &XPService{}
+		if _, err := xpService.Reverse(tx, userID, []model.XPSource{model.XPSourceBlogAI}, blogID, nil); err != nil {
+			return err
+		}
+		if aiRes.XpPerTag > 0 {
 			for _, tag := range finalTags {
-				stat := model.UserTagStat{
-					UserID: userID,
-					TagID:  tag.ID,
-				}
-				// Upsert UserTagStat
-				if err := tx.Clauses(clause.OnConflict{
-					Columns:   []clause.Column{{Name: "user_id"}, {Name: "tag_id"}},
-					DoUpdates: clause.Assignments(map[string]interface{}{
-						"total_minutes": gorm.Expr("user_tag_stats.total_minutes + ?", aiRes.XpPerTag),
-					}),
-				}).Create(&stat).Error; err != nil {
+				tagID := tag.ID
+				if err := xpService.Grant(tx, XPGrant{
+					UserID:      userID,
+					Source:      model.XPSourceBlogAI,
+					ReferenceID: blogID,
+					TagID:       &tagID,
+					Amount:      aiRes.XpPerTag,
+				}); err != nil {
 					return err
 				}
-				totalXPAdded += aiRes.XpPerTag
-			}
-
-			// Upsert DailyStat
-			dailyStat := model.DailyStat{
-				UserID:       userID,
-				Date:         today,
-				TotalMinutes: totalXPAdded,
-			}
-			if err := tx.Clauses(clause.OnConflict{
-				Columns:   []clause.Column{{Name: "user_id"}, {Name: "date"}},
-				DoUpdates: clause.Assignments(map[string]interface{}{
-					"total_minutes": gorm.Expr("daily_stats.total_minutes + ?", totalXPAdded),
-				}),
-			}).Create(&dailyStat).Error; err != nil {
-				return err
 			}
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
 
-	return err
+	(&AchievementService{}).EvaluateAsync(userID, AchievementTriggerBlog)
+	(&QuestService{}).RefreshAsync(userID, QuestTriggerBlog) // AI 打标签后，限定标签的博客任务才能计入
+	return nil
 }
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/pkg/database"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StartChallengeCloser 启动挑战结算任务：到期的挑战写入最终成绩并通知参与者
+// 在 main.go 中 go service.StartChallengeCloser() 调用
+func StartChallengeCloser() {
+	interval := 5 * time.Minute
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if acquireJobLock("challenge_closer", interval) {
+			runChallengeCloser()
+		}
+	}
+}
+
+func runChallengeCloser() {
+	var challenges []model.Challenge
+	if err := database.DB.Where("status = ? AND end_time <= ?", model.ChallengeStatusActive, time.Now()).
+		Find(&challenges).Error; err != nil {
+		log.Printf("[ChallengeCloser] Error fetching challenges: %v\n", err)
+		return
+	}
+
+	s := &ChallengeService{}
+	for i := range challenges {
+		if err := s.closeChallenge(challenges[i].ID); err != nil {
+			log.Printf("[ChallengeCloser] Failed to close challenge %s: %v\n", challenges[i].ID, err)
+		}
+	}
+}
+
+// closeChallenge 结算挑战，行锁保证多实例同时结算时只有一个生效，只有生效的实例发送通知
+func (s *ChallengeService) closeChallenge(challengeID string) error {
+	var ch model.Challenge
+	closed := false
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&ch, "id = ? AND status = ?", challengeID, model.ChallengeStatusActive).Error; err != nil {
+			return nil // 已被其他实例结算
+		}
+
+		var participants []model.ChallengeParticipant
+		if err := tx.Where("challenge_id = ? AND status = ?", ch.ID, model.ChallengeParticipantAccepted).
+			Find(&participants).Error; err != nil {
+			return err
+		}
+		userIDs := make([]string, len(participants))
+		for i, p := range participants {
+			userIDs[i] = p.UserID
+		}
+
+		minutes, err := challengeMinutes(&ch, userIDs)
+		if err != nil {
+			return err
+		}
+		sort.SliceStable(participants, func(i, j int) bool {
+			return minutes[participants[i].UserID] > minutes[participants[j].UserID]
+		})
+
+		total := 0
+		for i := range participants {
+			p := &participants[i]
+			p.Minutes = minutes[p.UserID]
+			p.Rank = i + 1
+			if i > 0 && p.Minutes == participants[i-1].Minutes {
+				p.Rank = participants[i-1].Rank
+			}
+			total += p.Minutes
+			if err := tx.Model(p).Updates(map[string]interface{}{"minutes": p.Minutes, "rank": p.Rank}).Error; err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		ch.Status = model.ChallengeStatusClosed
+		ch.TotalMinutes = total
+		ch.Completed = ch.Mode == model.ChallengeModeCollective && total >= ch.TargetMinutes
+		ch.ClosedAt = &now
+		if err := tx.Model(&ch).Updates(map[string]interface{}{
+			"status":        ch.Status,
+			"total_minutes": ch.TotalMinutes,
+			"completed":     ch.Completed,
+			"closed_at":     ch.ClosedAt,
+		}).Error; err != nil {
+			return err
+		}
+		closed = true
+		return nil
+	})
+	if err != nil || !closed {
+		return err
+	}
+
+	loaded, err := s.loadChallenge(ch.CreatorID, ch.ID)
+	if err != nil {
+		return err
+	}
+	resp, err := s.toResponse(loaded, ch.CreatorID)
+	if err != nil {
+		return err
+	}
+	s.broadcast(loaded, "challenge_closed", resp)
+
+	notificationService := &NotificationService{}
+	for _, p := range loaded.Participants {
+		if p.Status != model.ChallengeParticipantAccepted {
+			continue
+		}
+		var content string
+		switch {
+		case loaded.Mode == model.ChallengeModeCollective && loaded.Completed:
+			content = fmt.Sprintf("Your team completed \"%s\" with %d / %d minutes.", loaded.Title, loaded.TotalMinutes, loaded.TargetMinutes)
+		case loaded.Mode == model.ChallengeModeCollective:
+			content = fmt.Sprintf("\"%s\" ended at %d / %d minutes.", loaded.Title, loaded.TotalMinutes, loaded.TargetMinutes)
+		default:
+			content = fmt.Sprintf("You finished #%d in \"%s\" with %d minutes.", p.Rank, loaded.Title, p.Minutes)
+		}
+		if err := notificationService.Notify(p.UserID, model.NotificationTypeChallenge, "Challenge finished", content, &loaded.ID); err != nil {
+			log.Printf("[ChallengeCloser] Failed to notify user %s: %v\n", p.UserID, err)
+		}
+	}
+	return nil
+}
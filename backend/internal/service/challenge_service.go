@@ -0,0 +1,468 @@
+package service
+
+import (
+	"backend/internal/dto"
+	"backend/internal/model"
+	"backend/pkg/database"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ChallengeMaxDuration 挑战最长持续时间
+var ChallengeMaxDuration = 90 * 24 * time.Hour
+
+type ChallengeService struct{}
+
+// challengeMinutes 参与者在挑战时间窗口内的学习分钟数，跨越窗口边界的会话只计入窗口内的部分
+// 与任务一致，补录会话和待审核 / 被驳回的会话不计入；进行中的会话计入到当前时刻 (不超过窗口结束时间)，
+// 结束挑战时仍未结束的会话也能计入窗口内已经学习的部分
+func challengeMinutes(ch *model.Challenge, userIDs []string) (map[string]int, error) {
+	minutes := make(map[string]int, len(userIDs))
+	if len(userIDs) == 0 {
+		return minutes, nil
+	}
+
+	now := time.Now()
+	query := database.DB.Preload("Pauses").
+		Where("user_id IN ? AND type <> ? AND is_manual = ? AND start_time < ? AND (end_time IS NULL OR end_time > ?)",
+			userIDs, model.SessionTypeRest, false, ch.EndTime, ch.StartTime).
+		Where("review_status NOT IN ?", []model.SessionReviewStatus{model.SessionReviewPending, model.SessionReviewRejected})
+	if ch.TagID != nil {
+		query = query.Where("tag_id = ?", *ch.TagID)
+	}
+
+	var sessions []model.StudySession
+	if err := query.Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	for _, sess := range sessions {
+		from, to := sess.StartTime, now
+		if sess.EndTime != nil {
+			to = *sess.EndTime
+		}
+		if from.Before(ch.StartTime) {
+			from = ch.StartTime
+		}
+		if to.After(ch.EndTime) {
+			to = ch.EndTime
+		}
+		minutes[sess.UserID] += spansMinutes(focusSpans(from, to, sess.Pauses))
+	}
+	return minutes, nil
+}
+
+// standings 挑战榜单：进行中的挑战实时计算，已结束的挑战使用结束时写入的成绩
+// 接受了挑战的参与者按时长排名 (时长相同名次相同)，未接受邀请的排在最后
+func (s *ChallengeService) standings(ch *model.Challenge) ([]dto.ChallengeStanding, int, error) {
+	var accepted []string
+	for _, p := range ch.Participants {
+		if p.Status == model.ChallengeParticipantAccepted {
+			accepted = append(accepted, p.UserID)
+		}
+	}
+
+	live := ch.Status == model.ChallengeStatusActive
+	var minutes map[string]int
+	if live {
+		var err error
+		if minutes, err = challengeMinutes(ch, accepted); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	standings := make([]dto.ChallengeStanding, 0, len(ch.Participants))
+	total := 0
+	for _, p := range ch.Participants {
+		if p.Status == model.ChallengeParticipantDeclined {
+			continue
+		}
+		item := dto.ChallengeStanding{
+			Rank:    p.Rank,
+			User:    dto.UserSimple{ID: p.User.ID, Nickname: p.User.Nickname, AvatarURL: p.User.AvatarUrl},
+			Status:  p.Status,
+			Minutes: p.Minutes,
+		}
+		if live {
+			item.Minutes = minutes[p.UserID]
+		}
+		if p.Status == model.ChallengeParticipantAccepted {
+			total += item.Minutes
+		}
+		standings = append(standings, item)
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		ai := standings[i].Status == model.ChallengeParticipantAccepted
+		aj := standings[j].Status == model.ChallengeParticipantAccepted
+		if ai != aj {
+			return ai
+		}
+		if standings[i].Minutes != standings[j].Minutes {
+			return standings[i].Minutes > standings[j].Minutes
+		}
+		return standings[i].User.Nickname < standings[j].User.Nickname
+	})
+
+	if live {
+		for i := range standings {
+			if standings[i].Status != model.ChallengeParticipantAccepted {
+				break
+			}
+			standings[i].Rank = i + 1
+			if i > 0 && standings[i].Minutes == standings[i-1].Minutes {
+				standings[i].Rank = standings[i-1].Rank
+			}
+		}
+	} else {
+		total = ch.TotalMinutes
+	}
+	return standings, total, nil
+}
+
+func (s *ChallengeService) toResponse(ch *model.Challenge, myID string) (*dto.ChallengeResponse, error) {
+	standings, total, err := s.standings(ch)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.ChallengeResponse{
+		ID:            ch.ID,
+		Title:         ch.Title,
+		Mode:          ch.Mode,
+		Creator:       dto.UserSimple{ID: ch.Creator.ID, Nickname: ch.Creator.Nickname, AvatarURL: ch.Creator.AvatarUrl},
+		TagID:         ch.TagID,
+		TargetMinutes: ch.TargetMinutes,
+		StartTime:     ch.StartTime,
+		EndTime:       ch.EndTime,
+		Status:        ch.Status,
+		TotalMinutes:  total,
+		Completed:     ch.Completed,
+		ClosedAt:      ch.ClosedAt,
+		Standings:     standings,
+	}
+	if ch.Tag != nil {
+		resp.TagName = ch.Tag.Name
+	}
+	if ch.TargetMinutes > 0 {
+		resp.Progress = min(float64(total)/float64(ch.TargetMinutes), 1)
+	}
+	for _, p := range ch.Participants {
+		if p.UserID == myID {
+			resp.MyStatus = p.Status
+		}
+	}
+	return resp, nil
+}
+
+// loadChallenge 加载挑战及参与者，只有参与者 (含被邀请者) 可以查看
+func (s *ChallengeService) loadChallenge(userID, challengeID string) (*model.Challenge, error) {
+	var ch model.Challenge
+	err := database.DB.Preload("Creator").Preload("Tag").Preload("Participants.User").
+		Where("id = ? AND EXISTS (SELECT 1 FROM challenge_participants cp WHERE cp.challenge_id = challenges.id AND cp.user_id = ?)", challengeID, userID).
+		First(&ch).Error
+	if err != nil {
+		return nil, errors.New("challenge not found")
+	}
+	return &ch, nil
+}
+
+// friendSet 用户所有已接受的好友
+func friendSet(userID string) (map[string]bool, error) {
+	var friends []model.Friend
+	if err := database.DB.Where("status = ? AND (user_id = ? OR friend_id = ?)", model.FriendStatusAccepted, userID, userID).
+		Find(&friends).Error; err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]bool, len(friends))
+	for _, f := range friends {
+		if f.UserID == userID {
+			set[f.FriendID] = true
+		} else {
+			set[f.UserID] = true
+		}
+	}
+	return set, nil
+}
+
+// CreateChallenge 创建挑战，创建者自动参加，同时邀请好友
+func (s *ChallengeService) CreateChallenge(userID string, req dto.CreateChallengeRequest) (*dto.ChallengeResponse, error) {
+	now := time.Now()
+	start := now
+	if req.StartTime != nil && req.StartTime.After(now) {
+		start = *req.StartTime
+	}
+	if !req.EndTime.After(start) {
+		return nil, errors.New("end time must be after start time")
+	}
+	if req.EndTime.Sub(start) > ChallengeMaxDuration {
+		return nil, errors.New("challenge is too long")
+	}
+	if req.Mode == model.ChallengeModeCollective && req.TargetMinutes <= 0 {
+		return nil, errors.New("target minutes is required for collective challenges")
+	}
+
+	ch := model.Challenge{
+		CreatorID: userID,
+		Title:     req.Title,
+		Mode:      req.Mode,
+		StartTime: start,
+		EndTime:   req.EndTime,
+		Status:    model.ChallengeStatusActive,
+	}
+	if req.Mode == model.ChallengeModeCollective {
+		ch.TargetMinutes = req.TargetMinutes
+	}
+	if req.TagName != "" {
+		tag, err := (&TagService{}).FindTag(req.TagName)
+		if err != nil {
+			return nil, err
+		}
+		ch.TagID = &tag.ID
+	}
+
+	invitees, err := s.validateInvitees(userID, req.FriendIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&ch).Error; err != nil {
+			return err
+		}
+		creator := model.ChallengeParticipant{ChallengeID: ch.ID, UserID: userID, Status: model.ChallengeParticipantAccepted}
+		if err := tx.Create(&creator).Error; err != nil {
+			return err
+		}
+		_, err := s.createInvites(tx, &ch, userID, invitees)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyInvites(&ch, userID, invitees)
+
+	loaded, err := s.loadChallenge(userID, ch.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponse(loaded, userID)
+}
+
+// validateInvitees 去重并确认被邀请者都是好友
+func (s *ChallengeService) validateInvitees(userID string, friendIDs []string) ([]string, error) {
+	if len(friendIDs) == 0 {
+		return nil, nil
+	}
+	friends, err := friendSet(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	invitees := make([]string, 0, len(friendIDs))
+	for _, id := range removeDuplicate(friendIDs) {
+		if id == userID {
+			continue
+		}
+		if !friends[id] {
+			return nil, errors.New("can only invite friends")
+		}
+		invitees = append(invitees, id)
+	}
+	return invitees, nil
+}
+
+// createInvites 写入邀请，已经在挑战中的用户跳过，返回新邀请的用户
+func (s *ChallengeService) createInvites(tx *gorm.DB, ch *model.Challenge, inviterID string, invitees []string) ([]string, error) {
+	var invited []string
+	for _, id := range invitees {
+		p := model.ChallengeParticipant{
+			ChallengeID: ch.ID,
+			UserID:      id,
+			InviterID:   &inviterID,
+			Status:      model.ChallengeParticipantInvited,
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&p)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected > 0 {
+			invited = append(invited, id)
+		}
+	}
+	return invited, nil
+}
+
+// notifyInvites 复用通知流程发送挑战邀请
+func (s *ChallengeService) notifyInvites(ch *model.Challenge, inviterID string, invitees []string) {
+	if len(invitees) == 0 {
+		return
+	}
+	var inviter model.User
+	database.DB.Select("id", "nickname").First(&inviter, "id = ?", inviterID)
+
+	notificationService := &NotificationService{}
+	content := fmt.Sprintf("%s invited you to the challenge: %s", inviter.Nickname, ch.Title)
+	for _, id := range invitees {
+		if err := notificationService.Notify(id, model.NotificationTypeInvite, "Challenge Invitation", content, &ch.ID); err != nil {
+			log.Printf("[ChallengeService] Failed to notify user %s: %v", id, err)
+		}
+	}
+}
+
+// Invite 挑战进行中时，创建者可以继续邀请好友
+func (s *ChallengeService) Invite(userID, challengeID string, friendIDs []string) (*dto.ChallengeResponse, error) {
+	ch, err := s.loadChallenge(userID, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	if ch.CreatorID != userID {
+		return nil, errors.New("only the creator can invite")
+	}
+	if ch.Status != model.ChallengeStatusActive {
+		return nil, errors.New("challenge already closed")
+	}
+
+	invitees, err := s.validateInvitees(userID, friendIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var invited []string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		invited, err = s.createInvites(tx, ch, userID, invitees)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.notifyInvites(ch, userID, invited)
+
+	if ch, err = s.loadChallenge(userID, challengeID); err != nil {
+		return nil, err
+	}
+	return s.toResponse(ch, userID)
+}
+
+// Respond 接受或拒绝挑战邀请
+func (s *ChallengeService) Respond(userID, challengeID, action string) (*dto.ChallengeResponse, error) {
+	ch, err := s.loadChallenge(userID, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	if ch.Status != model.ChallengeStatusActive {
+		return nil, errors.New("challenge already closed")
+	}
+
+	status := model.ChallengeParticipantAccepted
+	if action == "decline" {
+		status = model.ChallengeParticipantDeclined
+	}
+	result := database.DB.Model(&model.ChallengeParticipant{}).
+		Where("challenge_id = ? AND user_id = ? AND status = ?", challengeID, userID, model.ChallengeParticipantInvited).
+		Update("status", status)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, errors.New("invitation not found")
+	}
+
+	if ch, err = s.loadChallenge(userID, challengeID); err != nil {
+		return nil, err
+	}
+	resp, err := s.toResponse(ch, userID)
+	if err != nil {
+		return nil, err
+	}
+	if status == model.ChallengeParticipantAccepted {
+		s.broadcast(ch, "challenge_updated", resp)
+	}
+	return resp, nil
+}
+
+// GetChallenges 我参与或被邀请的挑战 (不含已拒绝的)
+func (s *ChallengeService) GetChallenges(userID string, q dto.ChallengeQuery) ([]dto.ChallengeResponse, error) {
+	query := database.DB.Preload("Creator").Preload("Tag").Preload("Participants.User").
+		Where("id IN (SELECT challenge_id FROM challenge_participants WHERE user_id = ? AND status <> ?)", userID, model.ChallengeParticipantDeclined)
+	if q.Status != "" {
+		query = query.Where("status = ?", q.Status)
+	}
+
+	var challenges []model.Challenge
+	if err := query.Order("end_time DESC").Limit(50).Find(&challenges).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.ChallengeResponse, 0, len(challenges))
+	for i := range challenges {
+		resp, err := s.toResponse(&challenges[i], userID)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *resp)
+	}
+	return items, nil
+}
+
+// GetChallenge 挑战详情及榜单
+func (s *ChallengeService) GetChallenge(userID, challengeID string) (*dto.ChallengeResponse, error) {
+	ch, err := s.loadChallenge(userID, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponse(ch, userID)
+}
+
+// broadcast 向挑战中所有未拒绝的参与者推送事件 (MyStatus 按接收者分别填写)
+func (s *ChallengeService) broadcast(ch *model.Challenge, event string, resp *dto.ChallengeResponse) {
+	for _, p := range ch.Participants {
+		if p.Status == model.ChallengeParticipantDeclined {
+			continue
+		}
+		item := *resp
+		item.MyStatus = p.Status
+		emitToUser(p.UserID, event, dto.ChallengeUpdatedEvent{Challenge: item})
+	}
+}
+
+// PublishProgress 用户的学习时长变化后，向其参与的进行中挑战推送最新榜单
+func (s *ChallengeService) PublishProgress(userID string) error {
+	var ids []string
+	if err := database.DB.Model(&model.Challenge{}).
+		Where("status = ? AND start_time <= ?", model.ChallengeStatusActive, time.Now()).
+		Where("id IN (SELECT challenge_id FROM challenge_participants WHERE user_id = ? AND status = ?)", userID, model.ChallengeParticipantAccepted).
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		ch, err := s.loadChallenge(userID, id)
+		if err != nil {
+			continue
+		}
+		resp, err := s.toResponse(ch, userID)
+		if err != nil {
+			return err
+		}
+		s.broadcast(ch, "challenge_updated", resp)
+	}
+	return nil
+}
+
+// PublishProgressAsync 在后台推送挑战榜单，不影响主流程
+func (s *ChallengeService) PublishProgressAsync(userID string) {
+	go func() {
+		if err := s.PublishProgress(userID); err != nil {
+			log.Printf("[ChallengeService] Failed to publish progress for user %s: %v", userID, err)
+		}
+	}()
+}
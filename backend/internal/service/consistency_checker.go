@@ -0,0 +1,52 @@
+package service
+
+import (
+	"log"
+	"time"
+)
+
+// ConsistencyCheckInterval 聚合数据一致性检查的间隔
+var ConsistencyCheckInterval = 6 * time.Hour
+
+// consistencyLogLimit 每次检查最多打印多少条差异
+const consistencyLogLimit = 20
+
+// StartConsistencyChecker 定期按原始会话与经验流水核对 DailyStat、UserTagStat 和排行榜，只报告差异不修复
+// 发现差异后用 go run ./cmd/rebuild 修复
+// 在 main.go 中 go service.StartConsistencyChecker() 调用
+func StartConsistencyChecker() {
+	ticker := time.NewTicker(ConsistencyCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if acquireJobLock("consistency_checker", ConsistencyCheckInterval) {
+			runConsistencyCheck()
+		}
+	}
+}
+
+func runConsistencyCheck() {
+	report, err := RebuildAggregates(nil, true)
+	if err != nil {
+		log.Printf("[ConsistencyChecker] Check failed: %v\n", err)
+		return
+	}
+	if len(report.Drifts) == 0 {
+		log.Printf("[ConsistencyChecker] %d users checked, no drift\n", report.Users)
+		return
+	}
+
+	// 检查期间刚结束的会话也可能表现为差异，偶发的单条差异可以等下一轮确认
+	users := make(map[string]bool)
+	for _, d := range report.Drifts {
+		users[d.UserID] = true
+	}
+	log.Printf("[ConsistencyChecker] %d users checked, %d drifts across %d users\n", report.Users, len(report.Drifts), len(users))
+	for i, d := range report.Drifts {
+		if i >= consistencyLogLimit {
+			log.Printf("[ConsistencyChecker] ... %d more\n", len(report.Drifts)-consistencyLogLimit)
+			break
+		}
+		log.Printf("[ConsistencyChecker] %s user=%s key=%s %s: %d -> %d\n", d.Target, d.UserID, d.Key, d.Field, d.Before, d.After)
+	}
+}
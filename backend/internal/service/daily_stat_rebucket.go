@@ -0,0 +1,147 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/pkg/database"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RebucketChange 某一天的每日统计变化
+type RebucketChange struct {
+	Date   time.Time
+	Before int
+	After  int
+}
+
+// RebucketReport 单个用户重新归档的结果
+type RebucketReport struct {
+	UserID       string
+	FromTimezone string
+	ToTimezone   string
+	Changes      []RebucketChange
+}
+
+// RebucketDailyStats 按用户当前时区 (User.Timezone) 重新归档 DailyStat.TotalMinutes
+// 学习分钟数全部来自学习会话，按新时区重新计算；经验 (DailyStat.XP) 以流水为准，保留在原日期上
+func RebucketDailyStats(userID string, dryRun bool) (*RebucketReport, error) {
+	if dryRun {
+		return rebucketDailyStats(database.DB, userID, true)
+	}
+
+	var report *RebucketReport
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		report, err = rebucketDailyStats(tx, userID, false)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// rebucketDailyStats 在给定事务中重新归档，供修改时区时与保存用户资料放在同一事务里
+func rebucketDailyStats(tx *gorm.DB, userID string, dryRun bool) (*RebucketReport, error) {
+	var user model.User
+	if err := tx.Select("id", "timezone", "stats_timezone").First(&user, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+
+	// 旧数据没有记录归档时区，当时按服务器本地时区取日期
+	fromLoc := time.Local
+	if user.StatsTimezone != "" {
+		fromLoc = loadLocation(user.StatsTimezone)
+	}
+	toLoc := loadLocation(user.Timezone)
+
+	report := &RebucketReport{
+		UserID:       userID,
+		FromTimezone: fromLoc.String(),
+		ToTimezone:   toLoc.String(),
+	}
+
+	// 1. 已结束的学习会话在新时区下归属到哪一天 (与 updateDailyStats 一致，跨天会话按日拆分)
+	var sessions []model.StudySession
+	if err := tx.Preload("Pauses").
+		Where("user_id = ? AND end_time IS NOT NULL AND duration_minutes IS NOT NULL AND type <> ?", userID, model.SessionTypeRest).
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	target := make(map[time.Time]int)
+	for _, sess := range sessions {
+		spans := focusSpans(sess.StartTime, *sess.EndTime, sess.Pauses)
+		for _, day := range splitSpansByDay(spans, toLoc) {
+			target[day.Date] += day.Minutes
+		}
+	}
+
+	// 2. 现有 DailyStat
+	var stats []model.DailyStat
+	if err := tx.Where("user_id = ?", userID).Find(&stats).Error; err != nil {
+		return nil, err
+	}
+
+	current := make(map[time.Time]int)
+	hasXP := make(map[time.Time]bool)
+	for _, st := range stats {
+		d := dateOnly(st.Date)
+		current[d] += st.TotalMinutes
+		hasXP[d] = st.XP != 0
+	}
+
+	// 3. 对比差异
+	dates := make(map[time.Time]bool)
+	for d := range current {
+		dates[d] = true
+	}
+	for d := range target {
+		dates[d] = true
+	}
+	for d := range dates {
+		if current[d] != target[d] {
+			report.Changes = append(report.Changes, RebucketChange{Date: d, Before: current[d], After: target[d]})
+		}
+	}
+	sort.Slice(report.Changes, func(i, j int) bool {
+		return report.Changes[i].Date.Before(report.Changes[j].Date)
+	})
+
+	if dryRun {
+		return report, nil
+	}
+
+	// 4. 写回
+	for _, c := range report.Changes {
+		// 没有学习也没有经验的日期直接删除
+		if c.After == 0 && !hasXP[c.Date] {
+			if err := tx.Where("user_id = ? AND date = ?", userID, c.Date).Delete(&model.DailyStat{}).Error; err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "date"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"total_minutes": c.After}),
+		}).Create(&model.DailyStat{
+			UserID:       userID,
+			Date:         c.Date,
+			TotalMinutes: c.After,
+		}).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Model(&model.User{}).Where("id = ?", userID).Update("stats_timezone", user.Timezone).Error; err != nil {
+		return nil, err
+	}
+	// 日期整体平移后连续记录需要重算
+	if err := (&StreakService{}).RecomputeStreak(tx, userID); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
@@ -28,6 +28,7 @@ func (s *FriendService) SendRequest(userID, friendID string) (*model.Friend, boo
 			if err := database.DB.Save(&reverseReq).Error; err != nil {
 				return nil, false, err
 			}
+			s.evaluateFriendAchievements(&reverseReq)
 			return &reverseReq, true, nil // true 表示是"匹配成功/自动接受"
 		case model.FriendStatusAccepted:
 			return nil, false, errors.New("already friends")
@@ -130,9 +131,17 @@ func (s *FriendService) HandleRequest(userID, reqID string, action string) (*mod
 	if err := database.DB.Save(&req).Error; err != nil {
 		return nil, err
 	}
+	s.evaluateFriendAchievements(&req)
 	return &req, nil
 }
 
+// evaluateFriendAchievements 好友关系建立后，双方的好友数都发生了变化
+func (s *FriendService) evaluateFriendAchievements(f *model.Friend) {
+	achievementService := &AchievementService{}
+	achievementService.EvaluateAsync(f.UserID, AchievementTriggerFriend)
+	achievementService.EvaluateAsync(f.FriendID, AchievementTriggerFriend)
+}
+
 // GetFriendList 获取好友列表 (最复杂的部分)
 func (s *FriendService) GetFriendList(userID string, q dto.FriendQuery) (*dto.FriendListResponse, error) {
 	var friends []model.Friend
@@ -156,6 +165,16 @@ func (s *FriendService) GetFriendList(userID string, q dto.FriendQuery) (*dto.Fr
 		return nil, err
 	}
 
+	// 在线状态来自 PresenceService (多端连接计数)，Redis 不可用时退化为按是否在房间内判断
+	targetIDs := make([]string, len(friends))
+	for i, f := range friends {
+		targetIDs[i] = f.FriendID
+		if f.FriendID == userID {
+			targetIDs[i] = f.UserID
+		}
+	}
+	presence, presenceErr := (&PresenceService{}).Statuses(targetIDs)
+
 	// 转换逻辑：找出"对方"是谁
 	items := make([]dto.FriendItem, 0, len(friends))
 	for _, f := range friends {
@@ -185,12 +204,22 @@ func (s *FriendService) GetFriendList(userID string, q dto.FriendQuery) (*dto.Fr
 			}
 		}
 
+		presenceStatus := model.PresenceOffline
+		if isOnline {
+			presenceStatus = model.PresenceOnline
+		}
+		if presenceErr == nil {
+			presenceStatus = presence[targetUser.ID]
+			isOnline = presenceStatus != model.PresenceOffline
+		}
+
 		items = append(items, dto.FriendItem{
 			ID:        targetUser.ID,
 			Nickname:  targetUser.Nickname,
 			AvatarURL: targetUser.AvatarUrl,
 			Bio:       targetUser.Bio,
 			IsOnline:  isOnline,
+			Presence:  presenceStatus,
 			Status:    status,
 			RoomID:    roomID,
 			RoomName:  roomName,
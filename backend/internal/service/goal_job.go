@@ -0,0 +1,98 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/pkg/database"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// GoalReminderHour 用户本地时间几点之后开始提醒未完成的目标
+var GoalReminderHour = 20
+
+// StartGoalJob 启动目标结算与提醒任务
+// 在 main.go 中 go service.StartGoalJob() 调用
+func StartGoalJob() {
+	interval := 10 * time.Minute
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if acquireJobLock("goal_job", interval) {
+			runGoalJob()
+		}
+	}
+}
+
+func runGoalJob() {
+	var goals []model.StudyGoal
+	if err := database.DB.Where("is_active = ?", true).Find(&goals).Error; err != nil {
+		log.Printf("[GoalJob] Error fetching goals: %v\n", err)
+		return
+	}
+
+	s := &GoalService{}
+	now := time.Now()
+	locations := make(map[string]*time.Location)
+
+	for i := range goals {
+		goal := &goals[i]
+		loc, ok := locations[goal.UserID]
+		if !ok {
+			loc = userLocation(database.DB, goal.UserID)
+			locations[goal.UserID] = loc
+		}
+
+		// 1. 结算已结束的周期
+		if err := s.settlePeriods(goal, loc, now); err != nil {
+			log.Printf("[GoalJob] Failed to finalize goal %s: %v\n", goal.ID, err)
+		}
+
+		// 2. 当前周期快结束还没完成，提醒一次
+		if err := s.remindIfAtRisk(goal, loc, now); err != nil {
+			log.Printf("[GoalJob] Failed to remind goal %s: %v\n", goal.ID, err)
+		}
+	}
+}
+
+// remindIfAtRisk 每日目标在当天晚上、每周目标在周日晚上仍未完成时发送提醒
+// 用 Redis SETNX 保证每个周期只提醒一次 (多实例部署也不会重复)
+func (s *GoalService) remindIfAtRisk(goal *model.StudyGoal, loc *time.Location, now time.Time) error {
+	localNow := now.In(loc)
+	if localNow.Hour() < GoalReminderHour {
+		return nil
+	}
+
+	today := localDate(now, loc)
+	start, end := goalPeriodRange(goal.Period, today)
+	if !today.Equal(end) {
+		return nil
+	}
+
+	actual, err := goalActualMinutes(goal, start, end, loc)
+	if err != nil {
+		return err
+	}
+	if actual >= goal.TargetMinutes {
+		return nil
+	}
+
+	ctx := context.Background()
+	key := fmt.Sprintf("goal:reminder:%s:%s", goal.ID, start.Format("2006-01-02"))
+	ok, err := database.RDB.SetNX(ctx, key, 1, 8*24*time.Hour).Result()
+	if err != nil || !ok {
+		return err
+	}
+
+	periodName := "today"
+	if goal.Period == model.GoalPeriodWeekly {
+		periodName = "this week"
+	}
+	content := fmt.Sprintf("You've studied %d of %d minutes %s. %d minutes to go!",
+		actual, goal.TargetMinutes, periodName, goal.TargetMinutes-actual)
+
+	notificationService := &NotificationService{}
+	return notificationService.Notify(goal.UserID, model.NotificationTypeGoal, "Goal at risk", content, &goal.ID)
+}
@@ -0,0 +1,308 @@
+package service
+
+import (
+	"backend/internal/dto"
+	"backend/internal/model"
+	"backend/pkg/database"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GoalService struct{}
+
+// goalPeriodRange 返回 day 所在周期的起止日期 (含两端，UTC 0 点表示的本地日期)，周以周一开始
+func goalPeriodRange(period model.GoalPeriod, day time.Time) (time.Time, time.Time) {
+	if period == model.GoalPeriodWeekly {
+		offset := (int(day.Weekday()) + 6) % 7
+		start := day.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, 6)
+	}
+	return day, day
+}
+
+// goalActualMinutes 统计 [from, to] 内计入目标的学习分钟数
+// 直接按会话计算 (跨天会话按日拆分)，这样全局目标与按标签目标口径一致
+func goalActualMinutes(goal *model.StudyGoal, from, to time.Time, loc *time.Location) (int, error) {
+	fromT := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	toT := time.Date(to.Year(), to.Month(), to.Day()+1, 0, 0, 0, 0, loc)
+
+	query := database.DB.Preload("Pauses").
+		Where("user_id = ? AND type <> ? AND end_time IS NOT NULL AND start_time < ? AND end_time > ?",
+			goal.UserID, model.SessionTypeRest, toT, fromT)
+	if goal.TagID != nil {
+		query = query.Where("tag_id = ?", *goal.TagID)
+	}
+
+	var sessions []model.StudySession
+	if err := query.Find(&sessions).Error; err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, sess := range sessions {
+		for _, day := range splitSpansByDay(focusSpans(sess.StartTime, *sess.EndTime, sess.Pauses), loc) {
+			if !day.Date.Before(from) && !day.Date.After(to) {
+				total += day.Minutes
+			}
+		}
+	}
+	return total, nil
+}
+
+// progress 计算目标在 now 所在周期的进度
+func (s *GoalService) progress(goal *model.StudyGoal, loc *time.Location, now time.Time) (*dto.GoalProgress, error) {
+	start, end := goalPeriodRange(goal.Period, localDate(now, loc))
+	actual, err := goalActualMinutes(goal, start, end, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	ratio := float64(actual) / float64(goal.TargetMinutes)
+	if ratio > 1 {
+		ratio = 1
+	}
+
+	resp := &dto.GoalProgress{
+		GoalID:        goal.ID,
+		Period:        goal.Period,
+		TagID:         goal.TagID,
+		TargetMinutes: goal.TargetMinutes,
+		ActualMinutes: actual,
+		Progress:      ratio,
+		Met:           actual >= goal.TargetMinutes,
+		IsActive:      goal.IsActive,
+		PeriodStart:   start.Format("2006-01-02"),
+		PeriodEnd:     end.Format("2006-01-02"),
+	}
+	if goal.Tag != nil {
+		resp.TagName = goal.Tag.Name
+	}
+	return resp, nil
+}
+
+// CreateGoal 创建目标，同一周期同一标签 (或全局) 只能有一个生效的目标
+func (s *GoalService) CreateGoal(userID string, req dto.CreateGoalRequest) (*dto.GoalProgress, error) {
+	var tagID *string
+	if req.TagID != "" {
+		tagID = &req.TagID
+	} else if req.TagName != "" {
+		tagService := &TagService{}
+		tag, err := tagService.FindOrCreateTag(req.TagName)
+		if err != nil {
+			return nil, err
+		}
+		tagID = &tag.ID
+	}
+
+	query := database.DB.Model(&model.StudyGoal{}).
+		Where("user_id = ? AND period = ? AND is_active = ?", userID, req.Period, true)
+	if tagID != nil {
+		query = query.Where("tag_id = ?", *tagID)
+	} else {
+		query = query.Where("tag_id IS NULL")
+	}
+	var count int64
+	query.Count(&count)
+	if count > 0 {
+		return nil, errors.New("an active goal for this period already exists")
+	}
+
+	goal := model.StudyGoal{
+		UserID:        userID,
+		TagID:         tagID,
+		Period:        req.Period,
+		TargetMinutes: req.TargetMinutes,
+		IsActive:      true,
+	}
+	if err := database.DB.Create(&goal).Error; err != nil {
+		return nil, err
+	}
+	database.DB.Preload("Tag").First(&goal, "id = ?", goal.ID)
+
+	return s.progress(&goal, userLocation(database.DB, userID), time.Now())
+}
+
+// GetGoals 获取用户的所有目标及当前周期进度
+func (s *GoalService) GetGoals(userID string, activeOnly bool) ([]dto.GoalProgress, error) {
+	query := database.DB.Preload("Tag").Where("user_id = ?", userID)
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var goals []model.StudyGoal
+	if err := query.Order("created_at ASC").Find(&goals).Error; err != nil {
+		return nil, err
+	}
+
+	loc := userLocation(database.DB, userID)
+	now := time.Now()
+	items := make([]dto.GoalProgress, 0, len(goals))
+	for i := range goals {
+		p, err := s.progress(&goals[i], loc, now)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *p)
+	}
+	return items, nil
+}
+
+// UpdateGoal 修改目标值或启停目标
+func (s *GoalService) UpdateGoal(userID, goalID string, req dto.UpdateGoalRequest) (*dto.GoalProgress, error) {
+	var goal model.StudyGoal
+	if err := database.DB.Preload("Tag").Where("id = ? AND user_id = ?", goalID, userID).First(&goal).Error; err != nil {
+		return nil, errors.New("goal not found")
+	}
+
+	loc := userLocation(database.DB, userID)
+	now := time.Now()
+
+	updates := map[string]interface{}{}
+	if req.IsActive != nil && *req.IsActive != goal.IsActive {
+		if *req.IsActive {
+			// 重新启用：停用期间的周期不补结算
+			updates["activated_at"] = now
+			goal.ActivatedAt = &now
+		} else {
+			// 停用后定时任务不再处理该目标，先按修改前的目标值结算已结束的周期
+			if err := s.settlePeriods(&goal, loc, now); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if req.TargetMinutes != nil {
+		updates["target_minutes"] = *req.TargetMinutes
+		goal.TargetMinutes = *req.TargetMinutes
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+		goal.IsActive = *req.IsActive
+	}
+	if len(updates) > 0 {
+		if err := database.DB.Model(&goal).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return s.progress(&goal, loc, now)
+}
+
+// DeleteGoal 删除目标 (软删除，已结算的历史记录保留)
+func (s *GoalService) DeleteGoal(userID, goalID string) error {
+	var goal model.StudyGoal
+	if err := database.DB.Where("id = ? AND user_id = ?", goalID, userID).First(&goal).Error; err != nil {
+		return errors.New("goal not found")
+	}
+
+	// 删除前结算已结束的周期
+	if goal.IsActive {
+		if err := s.settlePeriods(&goal, userLocation(database.DB, userID), time.Now()); err != nil {
+			return err
+		}
+	}
+
+	result := database.DB.Delete(&goal)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("goal not found")
+	}
+	return nil
+}
+
+// GetGoalHistory 获取已结算周期的达成情况 (按周期倒序)
+func (s *GoalService) GetGoalHistory(userID string, q dto.GoalHistoryQuery) (*dto.GoalHistoryResponse, error) {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize < 1 || q.PageSize > 100 {
+		q.PageSize = 20
+	}
+
+	// 已删除目标的历史记录仍然返回
+	query := database.DB.Model(&model.GoalRecord{}).
+		Preload("Goal", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("user_id = ?", userID)
+	if q.GoalID != "" {
+		query = query.Where("goal_id = ?", q.GoalID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var records []model.GoalRecord
+	if err := query.Order("period_start DESC").
+		Offset((q.Page - 1) * q.PageSize).
+		Limit(q.PageSize).
+		Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.GoalRecordResponse, 0, len(records))
+	for _, r := range records {
+		items = append(items, dto.GoalRecordResponse{
+			GoalID:        r.GoalID,
+			Period:        r.Goal.Period,
+			TagID:         r.Goal.TagID,
+			PeriodStart:   r.PeriodStart.Format("2006-01-02"),
+			PeriodEnd:     r.PeriodEnd.Format("2006-01-02"),
+			TargetMinutes: r.TargetMinutes,
+			ActualMinutes: r.ActualMinutes,
+			Met:           r.Met,
+			CreatedAt:     r.CreatedAt,
+		})
+	}
+
+	return &dto.GoalHistoryResponse{
+		Items:    items,
+		Total:    total,
+		Page:     q.Page,
+		PageSize: q.PageSize,
+	}, nil
+}
+
+// settlePeriods 结算 now 之前所有已结束且未结算的周期
+// 从目标创建 (或最近一次重新启用) 所在周期、最近一条结算记录之后开始，任务漏跑或停机多个周期也能补上
+func (s *GoalService) settlePeriods(goal *model.StudyGoal, loc *time.Location, now time.Time) error {
+	curStart, _ := goalPeriodRange(goal.Period, localDate(now, loc))
+
+	since := goal.CreatedAt
+	if goal.ActivatedAt != nil && goal.ActivatedAt.After(since) {
+		since = *goal.ActivatedAt
+	}
+	start, _ := goalPeriodRange(goal.Period, localDate(since, loc))
+
+	var last model.GoalRecord
+	if database.DB.Where("goal_id = ?", goal.ID).Order("period_start DESC").Limit(1).Find(&last).RowsAffected > 0 {
+		if next := dateOnly(last.PeriodEnd).AddDate(0, 0, 1); next.After(start) {
+			start = next
+		}
+	}
+
+	for start.Before(curStart) {
+		_, end := goalPeriodRange(goal.Period, start)
+		actual, err := goalActualMinutes(goal, start, end, loc)
+		if err != nil {
+			return err
+		}
+
+		// 停用与定时任务可能同时结算同一周期，由唯一索引去重
+		if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.GoalRecord{
+			GoalID:        goal.ID,
+			UserID:        goal.UserID,
+			PeriodStart:   start,
+			PeriodEnd:     end,
+			TargetMinutes: goal.TargetMinutes,
+			ActualMinutes: actual,
+			Met:           actual >= goal.TargetMinutes,
+		}).Error; err != nil {
+			return err
+		}
+		start = end.AddDate(0, 0, 1)
+	}
+	return nil
+}
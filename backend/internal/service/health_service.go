@@ -63,6 +63,9 @@ func (s *HealthService) CheckIn(userID string, req dto.HealthCheckInRequest) err
 			Columns:   []clause.Column{{Name: "user_id"}, {Name: "date"}},
 			DoNothing: true,
 		}).Create(&data).Error
+		if err == nil {
+			(&QuestService{}).RefreshAsync(userID, QuestTriggerHealth)
+		}
 		return err
 	}
 
@@ -70,6 +73,9 @@ func (s *HealthService) CheckIn(userID string, req dto.HealthCheckInRequest) err
 		Columns:   []clause.Column{{Name: "user_id"}, {Name: "date"}},
 		DoUpdates: clause.Assignments(updates),
 	}).Create(&data).Error
+	if err == nil {
+		(&QuestService{}).RefreshAsync(userID, QuestTriggerHealth)
+	}
 
 	return err
 }
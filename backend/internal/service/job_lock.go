@@ -0,0 +1,20 @@
+package service
+
+import (
+	"backend/pkg/database"
+	"context"
+	"log"
+	"time"
+)
+
+// acquireJobLock 多实例部署时每个周期只让一个实例执行后台任务
+// 锁的有效期略短于任务周期，持有锁的实例崩溃后下一个周期由其他实例接手；
+// Redis 不可用时退化为各实例各自执行 (任务本身对重复执行是安全的，只是多做一次)
+func acquireJobLock(name string, interval time.Duration) bool {
+	ok, err := database.RDB.SetNX(context.Background(), "job:lock:"+name, 1, interval-interval/10).Result()
+	if err != nil {
+		log.Printf("[JobLock] Failed to acquire lock %s: %v\n", name, err)
+		return true
+	}
+	return ok
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/pkg/database"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LeaderboardArchiveDelay 周期结束多久之后归档，留出时间让回收任务结束掉线的会话
+var LeaderboardArchiveDelay = time.Hour
+
+// StartLeaderboardArchiver 启动榜单归档任务：把已结束的周榜、月榜和赛季榜写入 Postgres，并通知周冠军
+// 在 main.go 中 go service.StartLeaderboardArchiver() 调用
+func StartLeaderboardArchiver() {
+	interval := 30 * time.Minute
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if acquireJobLock("leaderboard_archiver", interval) {
+			runLeaderboardArchiver()
+		}
+	}
+}
+
+func runLeaderboardArchiver() {
+	ref := time.Now().Add(-LeaderboardArchiveDelay)
+	s := &RankingService{}
+
+	// 1. 已结束的周榜 (周一到周日)
+	// 任务停过一段时间也能补上：往回检查到 Redis Key 可能还没过期的最早一周，已归档或 Key 已不存在的周期会被跳过
+	// (周期用周中/月中的日期定位，避免 UTC 0 点表示的日期换算到本地时区后落到前一天)
+	today := localDate(ref, time.Local)
+	weekTTL := weekRankingPeriod(ref).TTL
+	for day := today.AddDate(0, 0, -7); !day.Before(today.Add(-weekTTL).AddDate(0, 0, -7)); day = day.AddDate(0, 0, -7) {
+		weekStart, weekEnd := goalPeriodRange(model.GoalPeriodWeekly, day)
+		if err := s.archiveLeaderboard(weekRankingPeriod(weekStart.AddDate(0, 0, 3)), weekStart, weekEnd, nil); err != nil {
+			log.Printf("[LeaderboardArchiver] Failed to archive week %s: %v\n", weekStart.Format("2006-01-02"), err)
+		}
+	}
+
+	// 2. 已结束的月榜，同样往回检查到 TTL 覆盖的最早一个月
+	thisMonth := today.AddDate(0, 0, 1-today.Day())
+	monthTTL := monthRankingPeriod(ref).TTL
+	for monthStart := thisMonth.AddDate(0, -1, 0); !monthStart.AddDate(0, 1, -1).Before(today.Add(-monthTTL)); monthStart = monthStart.AddDate(0, -1, 0) {
+		monthEnd := monthStart.AddDate(0, 1, -1)
+		if err := s.archiveLeaderboard(monthRankingPeriod(monthStart.AddDate(0, 0, 14)), monthStart, monthEnd, nil); err != nil {
+			log.Printf("[LeaderboardArchiver] Failed to archive month %s: %v\n", monthStart.Format("2006-01"), err)
+		}
+	}
+
+	// 3. 已结束但还没归档的赛季
+	var seasons []model.RankingSeason
+	if err := database.DB.
+		Where("end_date < ?", localDate(ref, time.Local)).
+		Where("NOT EXISTS (SELECT 1 FROM leaderboard_snapshots ls WHERE ls.season_id = ranking_seasons.id)").
+		Find(&seasons).Error; err != nil {
+		log.Printf("[LeaderboardArchiver] Error fetching seasons: %v\n", err)
+		return
+	}
+	for i := range seasons {
+		season := &seasons[i]
+		if err := s.archiveLeaderboard(seasonRankingPeriod(season.ID), dateOnly(season.StartDate), dateOnly(season.EndDate), &season.ID); err != nil {
+			log.Printf("[LeaderboardArchiver] Failed to archive season %s: %v\n", season.ID, err)
+		}
+	}
+}
+
+// archiveLeaderboard 把一个已结束周期的全站榜单完整写入快照 (已归档或榜单为空时跳过)
+// 快照以 PeriodKey 唯一，多实例同时归档也只会有一个成功，只有成功的实例发送冠军通知
+func (s *RankingService) archiveLeaderboard(period rankingPeriod, start, end time.Time, seasonID *string) error {
+	var exists int64
+	database.DB.Model(&model.LeaderboardSnapshot{}).Where("period_key = ?", period.Suffix).Count(&exists)
+	if exists > 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	results, err := database.RDB.ZRevRangeWithScores(ctx, rankingKey(period, ""), 0, -1).Result()
+	if err != nil {
+		return err
+	}
+
+	entries := make([]model.LeaderboardEntry, 0, len(results))
+	for _, z := range results {
+		// 会话被修正后可能残留 0 分成员
+		if z.Score <= 0 {
+			continue
+		}
+		// 分钟数相同的并列同一名次，下一名跳过并列人数 (1, 1, 3)
+		rank := len(entries) + 1
+		if n := len(entries); n > 0 && entries[n-1].Minutes == int(z.Score) {
+			rank = entries[n-1].Rank
+		}
+		entries = append(entries, model.LeaderboardEntry{
+			UserID:  z.Member.(string),
+			Rank:    rank,
+			Minutes: int(z.Score),
+		})
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	snapshot := model.LeaderboardSnapshot{
+		Scope:        string(period.Scope),
+		PeriodKey:    period.Suffix,
+		SeasonID:     seasonID,
+		PeriodStart:  start,
+		PeriodEnd:    end,
+		Participants: len(entries),
+	}
+	created := false
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&snapshot)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		for i := range entries {
+			entries[i].SnapshotID = snapshot.ID
+		}
+		if err := tx.CreateInBatches(&entries, 500).Error; err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	if err != nil || !created {
+		return err
+	}
+	log.Printf("[LeaderboardArchiver] Archived %s (%d participants)\n", period.Suffix, len(entries))
+
+	// 周冠军通知 (并列第一都通知)
+	if period.Scope == RankingScopeWeek {
+		notificationService := &NotificationService{}
+		for _, champion := range entries {
+			if champion.Rank != 1 {
+				break
+			}
+			content := fmt.Sprintf("You topped the weekly leaderboard (%s ~ %s) with %d minutes of study. Congratulations!",
+				start.Format("2006-01-02"), end.Format("2006-01-02"), champion.Minutes)
+			if err := notificationService.Notify(champion.UserID, model.NotificationTypeRank, "Weekly champion", content, &snapshot.ID); err != nil {
+				log.Printf("[LeaderboardArchiver] Failed to notify champion %s: %v\n", champion.UserID, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"backend/internal/dto"
+	"backend/internal/model"
+	"backend/pkg/database"
+	"errors"
+)
+
+// GetLeaderboardHistory 历史榜单列表 (按周期倒序)，附带每期冠军
+func (s *RankingService) GetLeaderboardHistory(q dto.LeaderboardHistoryQuery) (*dto.LeaderboardSnapshotListResponse, error) {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize < 1 || q.PageSize > 100 {
+		q.PageSize = 20
+	}
+
+	query := database.DB.Model(&model.LeaderboardSnapshot{})
+	if q.Scope != "" {
+		query = query.Where("scope = ?", q.Scope)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var snapshots []model.LeaderboardSnapshot
+	if err := query.Order("period_end DESC, scope ASC").
+		Offset((q.Page - 1) * q.PageSize).
+		Limit(q.PageSize).
+		Find(&snapshots).Error; err != nil {
+		return nil, err
+	}
+
+	// 批量查询每期第一名
+	snapshotIDs := make([]string, 0, len(snapshots))
+	for _, snap := range snapshots {
+		snapshotIDs = append(snapshotIDs, snap.ID)
+	}
+	var champions []model.LeaderboardEntry
+	if len(snapshotIDs) > 0 {
+		if err := database.DB.Preload("User").
+			Where("snapshot_id IN ? AND rank = 1", snapshotIDs).
+			Find(&champions).Error; err != nil {
+			return nil, err
+		}
+	}
+	championMap := make(map[string]*model.LeaderboardEntry)
+	for i := range champions {
+		championMap[champions[i].SnapshotID] = &champions[i]
+	}
+
+	items := make([]dto.LeaderboardSnapshotResponse, 0, len(snapshots))
+	for i := range snapshots {
+		item := toSnapshotResponse(&snapshots[i])
+		if c, ok := championMap[snapshots[i].ID]; ok {
+			champion := toLeaderboardEntryItem(c)
+			item.Champion = &champion
+		}
+		items = append(items, item)
+	}
+
+	return &dto.LeaderboardSnapshotListResponse{
+		Items:    items,
+		Total:    total,
+		Page:     q.Page,
+		PageSize: q.PageSize,
+	}, nil
+}
+
+// GetLeaderboardSnapshot 某一期榜单的前 N 名，以及当前用户在该期的名次
+func (s *RankingService) GetLeaderboardSnapshot(userID, snapshotID string, limit int) (*dto.LeaderboardSnapshotDetailResponse, error) {
+	var snapshot model.LeaderboardSnapshot
+	if err := database.DB.First(&snapshot, "id = ?", snapshotID).Error; err != nil {
+		return nil, errors.New("snapshot not found")
+	}
+
+	var entries []model.LeaderboardEntry
+	if err := database.DB.Preload("User").
+		Where("snapshot_id = ?", snapshotID).
+		Order("rank ASC").
+		Limit(limit).
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	resp := &dto.LeaderboardSnapshotDetailResponse{
+		Snapshot: toSnapshotResponse(&snapshot),
+		Items:    make([]dto.RankingItem, 0, len(entries)),
+	}
+	for i := range entries {
+		item := toLeaderboardEntryItem(&entries[i])
+		resp.Items = append(resp.Items, item)
+		if entries[i].Rank == 1 && resp.Snapshot.Champion == nil {
+			resp.Snapshot.Champion = &item
+		}
+		if entries[i].UserID == userID {
+			me := item
+			resp.Me = &me
+		}
+	}
+
+	if resp.Me == nil {
+		var mine model.LeaderboardEntry
+		if err := database.DB.Preload("User").
+			Where("snapshot_id = ? AND user_id = ?", snapshotID, userID).
+			First(&mine).Error; err == nil {
+			me := toLeaderboardEntryItem(&mine)
+			resp.Me = &me
+		}
+	}
+	return resp, nil
+}
+
+// GetMyLeaderboardHistory 当前用户在历史榜单中的名次 (按周期倒序)
+func (s *RankingService) GetMyLeaderboardHistory(userID string, q dto.LeaderboardHistoryQuery) (*dto.MyLeaderboardHistoryResponse, error) {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize < 1 || q.PageSize > 100 {
+		q.PageSize = 20
+	}
+
+	query := database.DB.Model(&model.LeaderboardEntry{}).
+		Joins("JOIN leaderboard_snapshots ON leaderboard_snapshots.id = leaderboard_entries.snapshot_id").
+		Where("leaderboard_entries.user_id = ?", userID)
+	if q.Scope != "" {
+		query = query.Where("leaderboard_snapshots.scope = ?", q.Scope)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var entries []model.LeaderboardEntry
+	if err := query.Preload("Snapshot").
+		Order("leaderboard_snapshots.period_end DESC, leaderboard_snapshots.scope ASC").
+		Offset((q.Page - 1) * q.PageSize).
+		Limit(q.PageSize).
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.MyLeaderboardRecord, 0, len(entries))
+	for i := range entries {
+		items = append(items, dto.MyLeaderboardRecord{
+			Snapshot: toSnapshotResponse(&entries[i].Snapshot),
+			Rank:     entries[i].Rank,
+			Minutes:  entries[i].Minutes,
+		})
+	}
+
+	return &dto.MyLeaderboardHistoryResponse{
+		Items:    items,
+		Total:    total,
+		Page:     q.Page,
+		PageSize: q.PageSize,
+	}, nil
+}
+
+func toSnapshotResponse(snapshot *model.LeaderboardSnapshot) dto.LeaderboardSnapshotResponse {
+	return dto.LeaderboardSnapshotResponse{
+		ID:           snapshot.ID,
+		Scope:        snapshot.Scope,
+		SeasonID:     snapshot.SeasonID,
+		PeriodStart:  snapshot.PeriodStart.Format("2006-01-02"),
+		PeriodEnd:    snapshot.PeriodEnd.Format("2006-01-02"),
+		Participants: snapshot.Participants,
+	}
+}
+
+func toLeaderboardEntryItem(entry *model.LeaderboardEntry) dto.RankingItem {
+	return dto.RankingItem{
+		Rank:      entry.Rank,
+		UserID:    entry.UserID,
+		Minutes:   entry.Minutes,
+		Nickname:  entry.User.Nickname,
+		AvatarURL: entry.User.AvatarUrl,
+	}
+}
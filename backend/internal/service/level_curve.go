@@ -0,0 +1,123 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+)
+
+// levelCurve 等级曲线：QuadraticMaxLevel 级以内 XP = Coefficient * L^2，之后每级固定 LinearStep，最高 MaxLevel 级
+type levelCurve struct {
+	Coefficient       float64 `json:"coefficient"`
+	QuadraticMaxLevel int     `json:"quadraticMaxLevel"`
+	LinearStep        int     `json:"linearStep"`
+	MaxLevel          int     `json:"maxLevel"`
+}
+
+func (c levelCurve) validate() error {
+	if c.Coefficient <= 0 || c.QuadraticMaxLevel <= 0 || c.LinearStep <= 0 || c.MaxLevel <= 0 {
+		return errors.New("coefficient, quadraticMaxLevel, linearStep and maxLevel must be positive")
+	}
+	return nil
+}
+
+// LevelCurveConfig 一个版本的等级曲线 (总等级 + 标签技能等级)
+// 调整曲线时新增一个版本而不是修改旧版本，levelmigrate 需要旧版本来对比每个用户的等级变化
+type LevelCurveConfig struct {
+	Version int        `json:"version"`
+	Global  levelCurve `json:"global"`
+	Tag     levelCurve `json:"tag"`
+}
+
+// builtinLevelCurve 版本 1，未配置 LEVEL_CURVES_FILE 时使用
+// 总等级：80 级 60,000 分钟，之后每级 27,000，最高 100 级
+// 技能等级：前期升级更快，40 级 9,600 XP，之后每级 2,400，最高 50 级
+var builtinLevelCurve = LevelCurveConfig{
+	Version: 1,
+	Global:  levelCurve{Coefficient: 9.375, QuadraticMaxLevel: 80, LinearStep: 27000, MaxLevel: 100},
+	Tag:     levelCurve{Coefficient: 6, QuadraticMaxLevel: 40, LinearStep: 2400, MaxLevel: 50},
+}
+
+var (
+	levelCurvesOnce    sync.Once
+	levelCurveVersions map[int]LevelCurveConfig
+	currentLevelCurve  LevelCurveConfig
+)
+
+// loadLevelCurves 读取 LEVEL_CURVES_FILE 指向的 JSON 文件，格式为
+// {"versions": [{"version": 1, "global": {...}, "tag": {...}}, {"version": 2, ...}]}
+// 版本号最大的为当前曲线；文件缺失或内容非法时退回内置曲线
+func loadLevelCurves() {
+	levelCurveVersions = map[int]LevelCurveConfig{builtinLevelCurve.Version: builtinLevelCurve}
+	currentLevelCurve = builtinLevelCurve
+
+	path := os.Getenv("LEVEL_CURVES_FILE")
+	if path == "" {
+		return
+	}
+
+	versions, err := readLevelCurves(path)
+	if err != nil {
+		log.Printf("Failed to load level curves from %s, using builtin curve: %v", path, err)
+		return
+	}
+
+	for _, v := range versions {
+		levelCurveVersions[v.Version] = v
+		if v.Version >= currentLevelCurve.Version {
+			currentLevelCurve = v
+		}
+	}
+	log.Printf("Level curve version %d loaded from %s", currentLevelCurve.Version, path)
+}
+
+func readLevelCurves(path string) ([]LevelCurveConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Versions []LevelCurveConfig `json:"versions"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if len(file.Versions) == 0 {
+		return nil, errors.New("no versions defined")
+	}
+
+	seen := make(map[int]bool, len(file.Versions))
+	for _, v := range file.Versions {
+		if v.Version <= 0 {
+			return nil, fmt.Errorf("invalid version %d", v.Version)
+		}
+		if seen[v.Version] {
+			return nil, fmt.Errorf("duplicate version %d", v.Version)
+		}
+		seen[v.Version] = true
+		if err := v.Global.validate(); err != nil {
+			return nil, fmt.Errorf("version %d global curve: %v", v.Version, err)
+		}
+		if err := v.Tag.validate(); err != nil {
+			return nil, fmt.Errorf("version %d tag curve: %v", v.Version, err)
+		}
+	}
+	return file.Versions, nil
+}
+
+// CurrentLevelCurve 当前生效的等级曲线
+func CurrentLevelCurve() LevelCurveConfig {
+	levelCurvesOnce.Do(loadLevelCurves)
+	return currentLevelCurve
+}
+
+// levelCurveVersion 按版本号查找曲线，找不到时 ok 为 false
+func levelCurveVersion(version int) (LevelCurveConfig, bool) {
+	levelCurvesOnce.Do(loadLevelCurves)
+	c, ok := levelCurveVersions[version]
+	return c, ok
+}
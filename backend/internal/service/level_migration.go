@@ -0,0 +1,103 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/pkg/database"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// LevelMove 等级曲线切换后总等级发生变化的用户
+type LevelMove struct {
+	UserID     string
+	Nickname   string
+	OldVersion int
+	OldLevel   int
+	NewLevel   int
+	TotalXP    int
+}
+
+// LevelMigrationReport 等级曲线迁移的结果
+type LevelMigrationReport struct {
+	Version int // 迁移到的曲线版本
+	Users   int // 检查的用户数
+	Moved   []LevelMove
+}
+
+// levelMigrationBatch 每批处理的用户数
+const levelMigrationBatch = 500
+
+// MigrateLevelCurve 把仍停留在旧版本曲线上的用户切换到当前曲线，并报告等级发生变化的用户
+// 等级本身按经验实时计算，迁移只更新 User.LevelCurveVersion，旧等级按用户记录的旧版本曲线重新计算得到
+// userIDs 为空表示所有用户
+func MigrateLevelCurve(userIDs []string, dryRun bool) (*LevelMigrationReport, error) {
+	current := CurrentLevelCurve()
+	report := &LevelMigrationReport{Version: current.Version}
+	levelService := &LevelService{}
+
+	query := database.DB.Model(&model.User{}).
+		Select("id", "nickname", "prestige", "prestige_xp", "level_curve_version").
+		Where("level_curve_version <> ?", current.Version)
+	if len(userIDs) > 0 {
+		query = query.Where("id IN ?", userIDs)
+	}
+
+	var users []model.User
+	err := query.FindInBatches(&users, levelMigrationBatch, func(tx *gorm.DB, batch int) error {
+		ids := make([]string, len(users))
+		for i, u := range users {
+			ids[i] = u.ID
+		}
+
+		var totals []struct {
+			UserID string
+			XP     int
+		}
+		if err := database.DB.Model(&model.DailyStat{}).
+			Select("user_id, COALESCE(SUM(xp), 0) as xp").
+			Where("user_id IN ?", ids).
+			Group("user_id").
+			Scan(&totals).Error; err != nil {
+			return err
+		}
+		xpMap := make(map[string]int, len(totals))
+		for _, t := range totals {
+			xpMap[t.UserID] = t.XP
+		}
+
+		for i := range users {
+			u := &users[i]
+			old, ok := levelCurveVersion(u.LevelCurveVersion)
+			if !ok {
+				log.Printf("Level curve version %d of user %s is not configured, comparing against builtin curve", u.LevelCurveVersion, u.ID)
+				old = builtinLevelCurve
+			}
+
+			xp := xpMap[u.ID]
+			oldLevel := levelService.userLevel(old.Global, u, xp).Level
+			newLevel := levelService.userLevel(current.Global, u, xp).Level
+			if oldLevel != newLevel {
+				report.Moved = append(report.Moved, LevelMove{
+					UserID:     u.ID,
+					Nickname:   u.Nickname,
+					OldVersion: u.LevelCurveVersion,
+					OldLevel:   oldLevel,
+					NewLevel:   newLevel,
+					TotalXP:    xp,
+				})
+			}
+		}
+		report.Users += len(users)
+
+		if dryRun {
+			return nil
+		}
+		return database.DB.Model(&model.User{}).Where("id IN ?", ids).
+			Update("level_curve_version", current.Version).Error
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
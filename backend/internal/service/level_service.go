@@ -2,41 +2,104 @@ package service
 
 import (
 	"backend/internal/dto"
+	"backend/internal/model"
+	"backend/pkg/database"
+	"errors"
 	"math"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type LevelService struct{}
 
 // CalculateLevel 根据总分钟数计算等级信息
 func (s *LevelService) CalculateLevel(totalMinutes int) dto.LevelInfo {
+	return s.calculate(CurrentLevelCurve().Global, totalMinutes)
+}
+
+// CalculateTagLevel 根据某个标签下的 XP 计算技能等级
+func (s *LevelService) CalculateTagLevel(xp int) dto.LevelInfo {
+	return s.calculate(CurrentLevelCurve().Tag, xp)
+}
+
+// CalculateUserLevel 用户的总等级，转生消耗的经验不计入展示等级
+func (s *LevelService) CalculateUserLevel(user *model.User, totalXP int) dto.LevelInfo {
+	return s.userLevel(CurrentLevelCurve().Global, user, totalXP)
+}
+
+func (s *LevelService) userLevel(curve levelCurve, user *model.User, totalXP int) dto.LevelInfo {
+	// 转生后经验被撤销 (例如会话审核驳回) 时不低于 0
+	info := s.calculate(curve, max(totalXP-user.PrestigeXP, 0))
+	info.Prestige = user.Prestige
+	info.LifetimeXP = totalXP
+	info.CanPrestige = info.Level >= curve.MaxLevel
+	return info
+}
+
+// Prestige 满级后转生：消耗满级所需的经验，展示等级从 0 开始
+// 累计经验、DailyStat 和排行榜都不受影响
+func (s *LevelService) Prestige(userID string) (*dto.LevelInfo, error) {
+	curve := CurrentLevelCurve().Global
+
+	var info dto.LevelInfo
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var user model.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", userID).Error; err != nil {
+			return errors.New("user not found")
+		}
+
+		totalXP := (&XPService{}).TotalXP(tx, userID)
+		if !s.userLevel(curve, &user, totalXP).CanPrestige {
+			return errors.New("max level not reached")
+		}
+
+		user.Prestige++
+		user.PrestigeXP += curve.minXPForLevel(curve.MaxLevel)
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"prestige":    user.Prestige,
+			"prestige_xp": user.PrestigeXP,
+		}).Error; err != nil {
+			return err
+		}
+
+		info = s.userLevel(curve, &user, totalXP)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+func (s *LevelService) calculate(curve levelCurve, xp int) dto.LevelInfo {
 	level := 0
-	
-	// 1. 判断是否超过 80 级 (60,000 分钟)
-	if totalMinutes <= 60000 {
-		// 公式: Minutes = 9.375 * L^2
-		// L = Sqrt(Minutes / 9.375)
-		val := math.Sqrt(float64(totalMinutes) / 9.375)
+	quadraticCap := curve.minXPForLevel(curve.QuadraticMaxLevel)
+
+	// 1. 判断是否超过二次曲线部分
+	if xp <= quadraticCap {
+		// L = Sqrt(XP / Coefficient)
+		val := math.Sqrt(float64(xp) / curve.Coefficient)
 		level = int(math.Floor(val))
 	} else {
-		// 公式: Minutes = 60000 + 27000 * (L - 80)
-		// L = 80 + (Minutes - 60000) / 27000
-		extra := totalMinutes - 60000
-		level = 80 + int(math.Floor(float64(extra)/27000.0))
+		// L = QuadraticMaxLevel + (XP - cap) / LinearStep
+		extra := xp - quadraticCap
+		level = curve.QuadraticMaxLevel + int(math.Floor(float64(extra)/float64(curve.LinearStep)))
 	}
 
-	// 限制最高 100 级
-	if level > 100 {
-		level = 100
+	// 限制最高等级
+	if level > curve.MaxLevel {
+		level = curve.MaxLevel
 	}
 
 	// 2. 计算当前等级的 Floor XP 和 Next XP
-	floorXP := s.calculateMinXPForLevel(level)
-	nextXP := s.calculateMinXPForLevel(level + 1)
-	
+	floorXP := curve.minXPForLevel(level)
+	nextXP := curve.minXPForLevel(level + 1)
+
 	// 3. 计算进度
 	progress := 0.0
 	if nextXP > floorXP {
-		progress = float64(totalMinutes-floorXP) / float64(nextXP-floorXP) * 100
+		progress = float64(xp-floorXP) / float64(nextXP-floorXP) * 100
 		if progress > 100 {
 			progress = 100
 		}
@@ -47,24 +110,24 @@ func (s *LevelService) CalculateLevel(totalMinutes int) dto.LevelInfo {
 
 	return dto.LevelInfo{
 		Level:        level,
-		CurrentXP:    totalMinutes,
+		CurrentXP:    xp,
 		LevelFloorXP: floorXP,
 		NextLevelXP:  nextXP,
 		Progress:     progress,
+		MaxLevel:     curve.MaxLevel,
 	}
 }
 
-// calculateMinXPForLevel 计算达到某等级所需的最小 XP
-func (s *LevelService) calculateMinXPForLevel(level int) int {
+// minXPForLevel 计算达到某等级所需的最小 XP
+func (c levelCurve) minXPForLevel(level int) int {
 	if level <= 0 {
 		return 0
 	}
-	
-	if level <= 80 {
-		// XP = 9.375 * L^2
-		return int(math.Ceil(9.375 * float64(level) * float64(level)))
-	} else {
-		// XP = 60000 + 27000 * (L - 80)
-		return 60000 + 27000*(level-80)
+
+	if level <= c.QuadraticMaxLevel {
+		// XP = Coefficient * L^2
+		return int(math.Ceil(c.Coefficient * float64(level) * float64(level)))
 	}
+	// XP = cap + LinearStep * (L - QuadraticMaxLevel)
+	return c.minXPForLevel(c.QuadraticMaxLevel) + c.LinearStep*(level-c.QuadraticMaxLevel)
 }
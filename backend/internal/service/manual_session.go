@@ -0,0 +1,130 @@
+package service
+
+import (
+	"backend/internal/dto"
+	"backend/internal/model"
+	"backend/pkg/database"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 补录会话的规则 (可在启动时按需调整)
+var (
+	ManualSessionMaxMinutes   = 6 * 60 // 单次补录的最长时长
+	ManualSessionLookbackDays = 7      // 最多补录多少天以内的学习
+	ManualSessionXPRate       = 0.5    // 补录时长折算为经验的比例 (每日统计与 Tag 时长仍按实际分钟计入)
+	ManualSessionInRankings   = false  // 补录时长是否进入排行榜
+)
+
+// AuditActionManualCreate 审计动作：补录会话
+const AuditActionManualCreate = "manual_create"
+
+// scaleDayMinutes 按比例折算每天的分钟数 (向下取整)
+func scaleDayMinutes(days []dayMinutes, rate float64) []dayMinutes {
+	if rate >= 1 {
+		return days
+	}
+	scaled := make([]dayMinutes, 0, len(days))
+	for _, day := range days {
+		scaled = append(scaled, dayMinutes{Date: day.Date, Minutes: int(float64(day.Minutes) * rate)})
+	}
+	return scaled
+}
+
+// CreateManualSession 补录一段已经结束的离线学习
+// 校验：开始早于结束、不晚于当前时间、不超过最长时长与回溯天数、不与已有会话 (包括进行中的) 重叠
+func (s *StudyService) CreateManualSession(userID string, req dto.ManualSessionRequest, clientIP, userAgent string) (*model.StudySession, error) {
+	now := time.Now()
+	start := req.StartTime
+	end := req.EndTime
+
+	if !end.After(start) {
+		return nil, errors.New("endTime must be after startTime")
+	}
+	if end.After(now) {
+		return nil, errors.New("cannot log a session that ends in the future")
+	}
+	if end.Sub(start) > time.Duration(ManualSessionMaxMinutes)*time.Minute {
+		return nil, errors.New("manual session is too long")
+	}
+	if start.Before(now.AddDate(0, 0, -ManualSessionLookbackDays)) {
+		return nil, errors.New("manual session is too far in the past")
+	}
+
+	sessionType := req.Type
+	if sessionType == "" {
+		sessionType = model.SessionTypeLearning
+	}
+
+	// 处理标签
+	var tagID *string
+	if req.TagID != "" {
+		tagID = &req.TagID
+	} else if req.TagName != "" {
+		tagService := &TagService{}
+		tag, err := tagService.FindOrCreateTag(req.TagName)
+		if err != nil {
+			return nil, err
+		}
+		tagID = &tag.ID
+	}
+
+	duration := int(end.Sub(start).Minutes())
+	session := model.StudySession{
+		UserID:          userID,
+		Type:            sessionType,
+		StartTime:       start,
+		EndTime:         &end,
+		DurationMinutes: &duration,
+		TagID:           tagID,
+		IsManual:        true,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// 锁住用户行，串行化同一用户的补录，避免并发请求绕过重叠检查
+		var locked model.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&locked, "id = ?", userID).Error; err != nil {
+			return err
+		}
+
+		// 区间重叠：existing.start < end && existing.end > start，进行中的会话视为持续到现在
+		var overlap int64
+		tx.Model(&model.StudySession{}).
+			Where("user_id = ? AND start_time < ? AND COALESCE(end_time, ?) > ?", userID, end, now, start).
+			Count(&overlap)
+		if overlap > 0 {
+			return errors.New("manual session overlaps with an existing session")
+		}
+
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+
+		credited, err := s.creditSession(tx, &session, []timeSpan{{Start: start, End: end}})
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&model.StudySessionAudit{
+			SessionID:       session.ID,
+			UserID:          userID,
+			Action:          AuditActionManualCreate,
+			StartTime:       start,
+			EndTime:         end,
+			DurationMinutes: duration,
+			CreditedMinutes: credited,
+			Note:            req.Note,
+			ClientIP:        clientIP,
+			UserAgent:       userAgent,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	(&AchievementService{}).EvaluateAsync(userID, AchievementTriggerSession)
+	return &session, nil
+}
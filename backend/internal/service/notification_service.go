@@ -90,3 +90,13 @@ func (s *NotificationService) mapToResponse(n model.Notification) *dto.Notificat
 		CreatedAt: n.CreatedAt,
 	}
 }
+
+// Notify 创建通知并通过 Socket 实时推送给用户 (供后台任务等服务端场景使用)
+func (s *NotificationService) Notify(userID string, nType model.NotificationType, title, content string, relatedID *string) error {
+	notif, err := s.CreateNotification(userID, nType, title, content, relatedID)
+	if err != nil {
+		return err
+	}
+	emitToUser(userID, "new_notification", dto.NewNotificationEvent{Notification: *notif})
+	return nil
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/pkg/database"
+	"log"
+	"time"
+)
+
+// StartPomodoroScheduler 启动番茄钟阶段调度
+// 在 main.go 中 go service.StartPomodoroScheduler() 调用
+func StartPomodoroScheduler() {
+	interval := 10 * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if acquireJobLock("pomodoro_scheduler", interval) {
+			advanceDuePomodoros()
+		}
+	}
+}
+
+func advanceDuePomodoros() {
+	var plans []model.PomodoroPlan
+
+	// 查询所有阶段已到点的运行中计划
+	if err := database.DB.Where("status = ? AND phase_ends_at <= ?", model.PomodoroStatusRunning, time.Now()).
+		Find(&plans).Error; err != nil {
+		log.Printf("[Pomodoro] Error fetching plans: %v\n", err)
+		return
+	}
+
+	s := &StudyService{}
+	for i := range plans {
+		if err := s.advancePomodoro(&plans[i]); err != nil {
+			log.Printf("[Pomodoro] Failed to advance plan %s: %v\n", plans[i].ID, err)
+		}
+	}
+}
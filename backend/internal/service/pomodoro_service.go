@@ -0,0 +1,180 @@
+package service
+
+import (
+	"backend/internal/dto"
+	"backend/internal/model"
+	"backend/pkg/database"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// phaseSessionType 专注阶段记为 learning，长短休息都记为 rest
+func phaseSessionType(phase model.PomodoroPhase) model.SessionType {
+	if phase == model.PomodoroPhaseFocus {
+		return model.SessionTypeLearning
+	}
+	return model.SessionTypeRest
+}
+
+// phaseMinutes 返回计划中某个阶段的时长
+func phaseMinutes(plan *model.PomodoroPlan, phase model.PomodoroPhase) int {
+	switch phase {
+	case model.PomodoroPhaseShortBreak:
+		return plan.ShortBreakMinutes
+	case model.PomodoroPhaseLongBreak:
+		return plan.LongBreakMinutes
+	default:
+		return plan.FocusMinutes
+	}
+}
+
+// GetActivePomodoro 获取用户正在运行的番茄计划
+func (s *StudyService) GetActivePomodoro(userID string) (*model.PomodoroPlan, error) {
+	var plan model.PomodoroPlan
+	err := database.DB.Where("user_id = ? AND status = ?", userID, model.PomodoroStatusRunning).
+		Order("created_at DESC").
+		First(&plan).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// stopPomodoro 结束番茄计划 (用户手动结束 / 会话被回收 / 会话被取消)
+func (s *StudyService) stopPomodoro(planID string, status model.PomodoroStatus) {
+	var plan model.PomodoroPlan
+	if err := database.DB.First(&plan, "id = ? AND status = ?", planID, model.PomodoroStatusRunning).Error; err != nil {
+		return
+	}
+
+	if err := database.DB.Model(&plan).Update("status", status).Error; err != nil {
+		log.Printf("[Pomodoro] Failed to stop plan %s: %v", planID, err)
+		return
+	}
+	plan.Status = status
+
+	emitToUser(plan.UserID, "pomodoro_finished", dto.PomodoroPhaseEvent{
+		PlanID:            plan.ID,
+		Status:            plan.Status,
+		Phase:             plan.CurrentPhase,
+		Cycle:             plan.CurrentCycle,
+		PreviousSessionID: plan.CurrentSessionID,
+		PhaseEndsAt:       plan.PhaseEndsAt,
+	})
+}
+
+// advancePomodoro 当前阶段到点后结束对应会话，并开启下一阶段的会话
+func (s *StudyService) advancePomodoro(plan *model.PomodoroPlan) error {
+	if plan.CurrentSessionID == nil {
+		s.stopPomodoro(plan.ID, model.PomodoroStatusCancelled)
+		return nil
+	}
+
+	var current model.StudySession
+	if err := database.DB.Preload("Pauses").First(&current, "id = ?", *plan.CurrentSessionID).Error; err != nil {
+		// 会话已被删除 (例如用户取消)，计划随之作废
+		s.stopPomodoro(plan.ID, model.PomodoroStatusCancelled)
+		return nil
+	}
+	if current.EndTime != nil {
+		s.stopPomodoro(plan.ID, model.PomodoroStatusCancelled)
+		return nil
+	}
+	// 暂停中的阶段不切换，恢复时 PhaseEndsAt 会被顺延
+	if openPause(&current) != nil {
+		return nil
+	}
+
+	// 1. 以计划的阶段结束时间收尾，避免调度延迟导致多记时长
+	phaseEnd := plan.PhaseEndsAt
+	if err := s.finishSession(&current, phaseEnd, model.SessionEndReasonPomodoro); err != nil {
+		return err
+	}
+
+	// 2. 计算下一阶段
+	nextPhase := model.PomodoroPhaseFocus
+	nextCycle := plan.CurrentCycle
+	if plan.CurrentPhase == model.PomodoroPhaseFocus {
+		if plan.TotalCycles > 0 && plan.CurrentCycle >= plan.TotalCycles {
+			s.stopPomodoro(plan.ID, model.PomodoroStatusCompleted)
+			return nil
+		}
+		nextPhase = model.PomodoroPhaseShortBreak
+		if plan.CurrentCycle%plan.CyclesBeforeLongBreak == 0 {
+			nextPhase = model.PomodoroPhaseLongBreak
+		}
+	} else {
+		nextCycle++
+	}
+
+	// 3. 创建下一阶段的会话并推进计划
+	next := model.StudySession{
+		UserID:     plan.UserID,
+		Type:       phaseSessionType(nextPhase),
+		StartTime:  phaseEnd,
+		TagID:      plan.TagID,
+		PomodoroID: &plan.ID,
+		SyncKey:    newSyncKey(),
+	}
+	previousSessionID := plan.CurrentSessionID
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&next).Error; err != nil {
+			return err
+		}
+
+		plan.CurrentPhase = nextPhase
+		plan.CurrentCycle = nextCycle
+		plan.CurrentSessionID = &next.ID
+		plan.PhaseEndsAt = phaseEnd.Add(time.Duration(phaseMinutes(plan, nextPhase)) * time.Minute)
+		return tx.Model(plan).
+			Select("current_phase", "current_cycle", "current_session_id", "phase_ends_at").
+			Updates(plan).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	// 新阶段沿用客户端心跳，先写入一次避免刚切换就被 Reaper 回收
+	ctx := context.Background()
+	database.RDB.Set(ctx, fmt.Sprintf("study:heartbeat:%s", next.ID), time.Now().Unix(), 3*time.Minute)
+
+	emitToUser(plan.UserID, "pomodoro_phase_changed", dto.PomodoroPhaseEvent{
+		PlanID:            plan.ID,
+		Status:            plan.Status,
+		Phase:             plan.CurrentPhase,
+		Cycle:             plan.CurrentCycle,
+		SessionID:         plan.CurrentSessionID,
+		PreviousSessionID: previousSessionID,
+		PhaseEndsAt:       plan.PhaseEndsAt,
+	})
+
+	return nil
+}
+
+// ToPomodoroResponse 模型转 DTO
+func (s *StudyService) ToPomodoroResponse(plan *model.PomodoroPlan) dto.PomodoroPlanResponse {
+	return dto.PomodoroPlanResponse{
+		ID:                    plan.ID,
+		TagID:                 plan.TagID,
+		FocusMinutes:          plan.FocusMinutes,
+		ShortBreakMinutes:     plan.ShortBreakMinutes,
+		LongBreakMinutes:      plan.LongBreakMinutes,
+		CyclesBeforeLongBreak: plan.CyclesBeforeLongBreak,
+		TotalCycles:           plan.TotalCycles,
+		Status:                plan.Status,
+		CurrentPhase:          plan.CurrentPhase,
+		CurrentCycle:          plan.CurrentCycle,
+		CurrentSessionID:      plan.CurrentSessionID,
+		PhaseEndsAt:           plan.PhaseEndsAt,
+	}
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"backend/internal/dto"
+	"backend/internal/model"
+	"backend/pkg/database"
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// PresenceService 多端在线状态
+// 每个 Socket 连接在 Redis ZSET 中占一个成员 (score 为最近心跳时间)，成员数即引用计数：
+// 第一个连接建立 / 最后一个连接断开时才算上线 / 下线、进入 / 离开房间。
+// 实例崩溃留下的连接在 PresenceTTL 后不再计数，并由 StartPresenceSweeper 补发下线 / 离开房间，多实例部署下同样适用。
+type PresenceService struct{}
+
+// PresenceTTL 超过该时间没有心跳的连接视为已断开
+const PresenceTTL = 90 * time.Second
+
+// PresenceHeartbeatInterval Socket 层刷新本实例连接心跳的间隔
+const PresenceHeartbeatInterval = 30 * time.Second
+
+// PresenceConn 本实例上的一个连接，用于批量心跳
+type PresenceConn struct {
+	UserID   string
+	SocketID string
+	RoomID   string // 不在房间内为空
+}
+
+func presenceConnKey(userID string) string {
+	return fmt.Sprintf("presence:conn:%s", userID)
+}
+
+func presenceRoomKey(roomID, userID string) string {
+	return fmt.Sprintf("presence:room:%s:%s", roomID, userID)
+}
+
+func presenceAwayKey(userID string) string {
+	return fmt.Sprintf("presence:away:%s", userID)
+}
+
+// 有连接记录的用户 / 房间成员索引，供 StartPresenceSweeper 找到崩溃实例留下的连接
+const (
+	presenceUsersKey = "presence:users"
+	presenceRoomsKey = "presence:rooms" // 成员为 roomID:userID
+)
+
+func presenceRoomMember(roomID, userID string) string {
+	return roomID + ":" + userID
+}
+
+// staleBefore 早于该分数的成员已过期
+func staleBefore(now time.Time) string {
+	return strconv.FormatInt(now.Add(-PresenceTTL).Unix(), 10)
+}
+
+// addConn 加入连接，返回该连接是否是唯一的有效连接
+func addConn(ctx context.Context, key, socketID string) (bool, error) {
+	now := time.Now()
+	var added *redis.IntCmd
+	var count *redis.IntCmd
+	_, err := database.RDB.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, key, "-inf", "("+staleBefore(now))
+		added = pipe.ZAdd(ctx, key, redis.Z{Score: float64(now.Unix()), Member: socketID})
+		count = pipe.ZCard(ctx, key)
+		pipe.Expire(ctx, key, 2*PresenceTTL)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return added.Val() == 1 && count.Val() == 1, nil
+}
+
+// removeConn 移除连接，返回移除后是否已经没有有效连接
+func removeConn(ctx context.Context, key, socketID string) (bool, error) {
+	now := time.Now()
+	var removed *redis.IntCmd
+	var count *redis.IntCmd
+	_, err := database.RDB.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		removed = pipe.ZRem(ctx, key, socketID)
+		pipe.ZRemRangeByScore(ctx, key, "-inf", "("+staleBefore(now))
+		count = pipe.ZCard(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return removed.Val() == 1 && count.Val() == 0, nil
+}
+
+// Connect 记录新连接，第一个连接时通知好友上线
+func (s *PresenceService) Connect(userID, socketID string) (bool, error) {
+	ctx := context.Background()
+	first, err := addConn(ctx, presenceConnKey(userID), socketID)
+	if err != nil {
+		return true, err
+	}
+	database.RDB.SAdd(ctx, presenceUsersKey, userID)
+	if first {
+		s.PublishAsync(userID)
+	}
+	return first, nil
+}
+
+// Disconnect 移除连接，最后一个连接断开时清除离开状态并通知好友下线
+func (s *PresenceService) Disconnect(userID, socketID string) (bool, error) {
+	ctx := context.Background()
+	last, err := removeConn(ctx, presenceConnKey(userID), socketID)
+	if err != nil {
+		return true, err
+	}
+	if last {
+		database.RDB.SRem(ctx, presenceUsersKey, userID)
+		database.RDB.Del(ctx, presenceAwayKey(userID))
+		s.PublishAsync(userID)
+	}
+	return last, nil
+}
+
+// JoinRoom 记录连接进入房间，返回是否是该用户在房间内的第一个连接 (需要广播 user_joined)
+// Redis 出错时按第一个连接处理，退化为单连接的行为
+func (s *PresenceService) JoinRoom(userID, roomID, socketID string) (bool, error) {
+	ctx := context.Background()
+	first, err := addConn(ctx, presenceRoomKey(roomID, userID), socketID)
+	if err != nil {
+		return true, err
+	}
+	database.RDB.SAdd(ctx, presenceRoomsKey, presenceRoomMember(roomID, userID))
+	return first, nil
+}
+
+// LeaveRoom 记录连接离开房间，返回是否是最后一个连接 (需要写 LeftAt 并广播 user_left)
+func (s *PresenceService) LeaveRoom(userID, roomID, socketID string) (bool, error) {
+	ctx := context.Background()
+	last, err := removeConn(ctx, presenceRoomKey(roomID, userID), socketID)
+	if err != nil {
+		return true, err
+	}
+	if last {
+		database.RDB.SRem(ctx, presenceRoomsKey, presenceRoomMember(roomID, userID))
+	}
+	return last, nil
+}
+
+// ClearRoom 清除用户在房间内的所有连接 (被踢出 / 封禁)
+func (s *PresenceService) ClearRoom(userID, roomID string) error {
+	ctx := context.Background()
+	database.RDB.SRem(ctx, presenceRoomsKey, presenceRoomMember(roomID, userID))
+	return database.RDB.Del(ctx, presenceRoomKey(roomID, userID)).Err()
+}
+
+// Heartbeat 刷新本实例连接的心跳，避免正常连接被当作过期
+func (s *PresenceService) Heartbeat(conns []PresenceConn) error {
+	if len(conns) == 0 {
+		return nil
+	}
+	ctx := context.Background()
+	score := float64(time.Now().Unix())
+	_, err := database.RDB.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, c := range conns {
+			keys := []string{presenceConnKey(c.UserID)}
+			if c.RoomID != "" {
+				keys = append(keys, presenceRoomKey(c.RoomID, c.UserID))
+			}
+			for _, key := range keys {
+				// XX: 只刷新已存在的成员，已被移除 (断开 / 踢出) 的连接不会复活
+				pipe.ZAddXX(ctx, key, redis.Z{Score: score, Member: c.SocketID})
+				pipe.Expire(ctx, key, 2*PresenceTTL)
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// SetAway 手动设置离开 / 取消离开，最后一个连接断开时自动清除
+func (s *PresenceService) SetAway(userID string, away bool) error {
+	ctx := context.Background()
+	var err error
+	if away {
+		err = database.RDB.Set(ctx, presenceAwayKey(userID), 1, 24*time.Hour).Err()
+	} else {
+		err = database.RDB.Del(ctx, presenceAwayKey(userID)).Err()
+	}
+	if err != nil {
+		return err
+	}
+	s.PublishAsync(userID)
+	return nil
+}
+
+// Statuses 批量查询在线状态
+// 优先级：没有有效连接为 offline，有进行中的会话为 studying，手动离开为 away，否则 online
+func (s *PresenceService) Statuses(userIDs []string) (map[string]model.PresenceStatus, error) {
+	result := make(map[string]model.PresenceStatus, len(userIDs))
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	ctx := context.Background()
+	minScore := "(" + staleBefore(time.Now())
+	counts := make([]*redis.IntCmd, len(userIDs))
+	aways := make([]*redis.IntCmd, len(userIDs))
+	if _, err := database.RDB.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, uid := range userIDs {
+			counts[i] = pipe.ZCount(ctx, presenceConnKey(uid), minScore, "+inf")
+			aways[i] = pipe.Exists(ctx, presenceAwayKey(uid))
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	var studying []string
+	if err := database.DB.Model(&model.StudySession{}).
+		Where("user_id IN ? AND end_time IS NULL", userIDs).
+		Distinct().Pluck("user_id", &studying).Error; err != nil {
+		return nil, err
+	}
+	studyingSet := make(map[string]bool, len(studying))
+	for _, uid := range studying {
+		studyingSet[uid] = true
+	}
+
+	for i, uid := range userIDs {
+		switch {
+		case counts[i].Val() == 0:
+			result[uid] = model.PresenceOffline
+		case studyingSet[uid]:
+			result[uid] = model.PresenceStudying
+		case aways[i].Val() > 0:
+			result[uid] = model.PresenceAway
+		default:
+			result[uid] = model.PresenceOnline
+		}
+	}
+	return result, nil
+}
+
+// Publish 把用户当前状态推送给所有好友
+func (s *PresenceService) Publish(userID string) error {
+	statuses, err := s.Statuses([]string{userID})
+	if err != nil {
+		return err
+	}
+	friends, err := friendSet(userID)
+	if err != nil {
+		return err
+	}
+
+	event := dto.PresenceUpdatedEvent{UserID: userID, Status: statuses[userID]}
+	for fid := range friends {
+		emitToUser(fid, "presence_updated", event)
+	}
+	return nil
+}
+
+// PublishAsync 异步推送状态，失败只记录日志
+func (s *PresenceService) PublishAsync(userID string) {
+	go func() {
+		if err := s.Publish(userID); err != nil {
+			log.Printf("[Presence] Failed to publish presence for user %s: %v\n", userID, err)
+		}
+	}()
+}
+
+// GetFriendsPresence 所有好友的在线状态及所在房间
+func (s *PresenceService) GetFriendsPresence(userID string) ([]dto.FriendPresence, error) {
+	friends, err := friendSet(userID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(friends))
+	for fid := range friends {
+		ids = append(ids, fid)
+	}
+	sort.Strings(ids)
+
+	statuses, err := s.Statuses(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var members []model.RoomMember
+	if len(ids) > 0 {
+		if err := database.DB.Select("user_id", "room_id").
+			Where("user_id IN ? AND left_at IS NULL", ids).
+			Find(&members).Error; err != nil {
+			return nil, err
+		}
+	}
+	rooms := make(map[string]string, len(members))
+	for _, m := range members {
+		rooms[m.UserID] = m.RoomID
+	}
+
+	resp := make([]dto.FriendPresence, 0, len(ids))
+	for _, fid := range ids {
+		item := dto.FriendPresence{UserID: fid, Status: statuses[fid]}
+		if roomID, ok := rooms[fid]; ok && item.Status != model.PresenceOffline {
+			item.RoomID = &roomID
+		}
+		resp = append(resp, item)
+	}
+	return resp, nil
+}
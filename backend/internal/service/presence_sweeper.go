@@ -0,0 +1,90 @@
+package service
+
+import (
+	"backend/internal/dto"
+	"backend/pkg/database"
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// StartPresenceSweeper 定时清理崩溃实例留下的连接 (超过 PresenceTTL 没有心跳)
+// 用户的最后一个连接过期时通知好友下线，房间内的最后一个连接过期时写 LeftAt 并广播 user_left
+// 由 socket.InitSocket 启动；多实例同时清理时由 SREM 的结果保证每个用户 / 房间只处理一次
+func StartPresenceSweeper() {
+	ticker := time.NewTicker(PresenceTTL)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		sweepPresence()
+	}
+}
+
+func sweepPresence() {
+	ctx := context.Background()
+	s := &PresenceService{}
+
+	users, err := database.RDB.SMembers(ctx, presenceUsersKey).Result()
+	if err != nil {
+		log.Printf("[PresenceSweeper] Failed to load users: %v\n", err)
+		return
+	}
+	for _, userID := range users {
+		if !sweepKey(ctx, presenceConnKey(userID), presenceUsersKey, userID) {
+			continue
+		}
+		log.Printf("[PresenceSweeper] User %s has no live connections, marking offline\n", userID)
+		database.RDB.Del(ctx, presenceAwayKey(userID))
+		s.PublishAsync(userID)
+	}
+
+	members, err := database.RDB.SMembers(ctx, presenceRoomsKey).Result()
+	if err != nil {
+		log.Printf("[PresenceSweeper] Failed to load room members: %v\n", err)
+		return
+	}
+	roomService := &RoomService{}
+	for _, member := range members {
+		roomID, userID, ok := strings.Cut(member, ":")
+		if !ok {
+			database.RDB.SRem(ctx, presenceRoomsKey, member)
+			continue
+		}
+		if !sweepKey(ctx, presenceRoomKey(roomID, userID), presenceRoomsKey, member) {
+			continue
+		}
+		log.Printf("[PresenceSweeper] User %s has no live connections in room %s, leaving\n", userID, roomID)
+		if err := roomService.LeaveRoom(userID, roomID); err != nil {
+			log.Printf("[PresenceSweeper] Failed to leave room %s for user %s: %v\n", roomID, userID, err)
+			continue
+		}
+		emitToRoom(roomID, "user_left", dto.UserLeftEvent{UserID: userID})
+	}
+}
+
+// sweepKey 移除 key 中过期的连接，没有剩余连接时把 member 移出索引
+// 返回 true 表示由本次调用负责处理下线 / 离开
+func sweepKey(ctx context.Context, key, indexKey, member string) bool {
+	var count *redis.IntCmd
+	if _, err := database.RDB.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, key, "-inf", "("+staleBefore(time.Now()))
+		count = pipe.ZCard(ctx, key)
+		return nil
+	}); err != nil || count.Val() > 0 {
+		return false
+	}
+
+	removed, err := database.RDB.SRem(ctx, indexKey, member).Result()
+	if err != nil || removed == 0 {
+		return false // 正常断开或其他实例已经处理
+	}
+	// 清理期间恰好有新连接，放回索引，交给正常的断开流程处理
+	if n, _ := database.RDB.ZCard(ctx, key).Result(); n > 0 {
+		database.RDB.SAdd(ctx, indexKey, member)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,411 @@
+package service
+
+import (
+	"backend/internal/dto"
+	"backend/internal/model"
+	"backend/pkg/database"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math/rand"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// QuestMetric 任务依据的统计指标，都只统计任务周期内的数据
+type QuestMetric string
+
+const (
+	QuestMetricStudyMinutes      QuestMetric = "study_minutes"       // 学习分钟数 (可限定标签)
+	QuestMetricFriendRoomMinutes QuestMetric = "friend_room_minutes" // 和好友同在一个自习室时的学习分钟数
+	QuestMetricBlogs             QuestMetric = "blogs"               // 发布的博客数 (可限定标签)
+	QuestMetricHealthDays        QuestMetric = "health_days"         // 健康打卡天数
+)
+
+// QuestTrigger 触发任务进度更新的事件，每种事件只重新计算相关的指标
+type QuestTrigger string
+
+const (
+	QuestTriggerSession QuestTrigger = "session"
+	QuestTriggerBlog    QuestTrigger = "blog"
+	QuestTriggerHealth  QuestTrigger = "health"
+)
+
+var questTriggerMetrics = map[QuestTrigger][]QuestMetric{
+	QuestTriggerSession: {QuestMetricStudyMinutes, QuestMetricFriendRoomMinutes},
+	QuestTriggerBlog:    {QuestMetricBlogs},
+	QuestTriggerHealth:  {QuestMetricHealthDays},
+}
+
+// QuestTemplate 任务模板
+type QuestTemplate struct {
+	Code     string
+	Title    string // 限定标签的任务用 %s 占位标签名
+	Period   model.GoalPeriod
+	Metric   QuestMetric
+	Target   int
+	RewardXP int
+	UserTag  bool // 从用户经验最高的几个标签中选一个作为限定标签，用户没有标签时不会抽到
+}
+
+// 每个周期为用户抽取的任务数
+const (
+	DailyQuestCount  = 3
+	WeeklyQuestCount = 3
+)
+
+// QuestTemplates 任务模板，Code 一旦上线不能修改 (UserQuest 按 Code 找回模板)
+var QuestTemplates = []QuestTemplate{
+	{Code: "daily_study_30", Title: "Study for 30 minutes", Period: model.GoalPeriodDaily, Metric: QuestMetricStudyMinutes, Target: 30, RewardXP: 20},
+	{Code: "daily_study_90", Title: "Study for 90 minutes", Period: model.GoalPeriodDaily, Metric: QuestMetricStudyMinutes, Target: 90, RewardXP: 50},
+	{Code: "daily_study_tag_45", Title: "Study %s for 45 minutes", Period: model.GoalPeriodDaily, Metric: QuestMetricStudyMinutes, Target: 45, RewardXP: 40, UserTag: true},
+	{Code: "daily_room_friends_60", Title: "Study 60 minutes in a room with friends", Period: model.GoalPeriodDaily, Metric: QuestMetricFriendRoomMinutes, Target: 60, RewardXP: 60},
+	{Code: "daily_health_checkin", Title: "Check in your health data", Period: model.GoalPeriodDaily, Metric: QuestMetricHealthDays, Target: 1, RewardXP: 10},
+	{Code: "daily_blog", Title: "Publish a blog", Period: model.GoalPeriodDaily, Metric: QuestMetricBlogs, Target: 1, RewardXP: 30},
+
+	{Code: "weekly_study_600", Title: "Study for 10 hours this week", Period: model.GoalPeriodWeekly, Metric: QuestMetricStudyMinutes, Target: 600, RewardXP: 200},
+	{Code: "weekly_room_friends_300", Title: "Study 5 hours in rooms with friends this week", Period: model.GoalPeriodWeekly, Metric: QuestMetricFriendRoomMinutes, Target: 300, RewardXP: 200},
+	{Code: "weekly_health_5", Title: "Check in health data 5 days this week", Period: model.GoalPeriodWeekly, Metric: QuestMetricHealthDays, Target: 5, RewardXP: 80},
+	{Code: "weekly_blog_tag", Title: "Write a blog tagged %s", Period: model.GoalPeriodWeekly, Metric: QuestMetricBlogs, Target: 1, RewardXP: 120, UserTag: true},
+	{Code: "weekly_blogs_3", Title: "Publish 3 blogs this week", Period: model.GoalPeriodWeekly, Metric: QuestMetricBlogs, Target: 3, RewardXP: 150},
+}
+
+func questTemplate(code string) (QuestTemplate, bool) {
+	for _, t := range QuestTemplates {
+		if t.Code == code {
+			return t, true
+		}
+	}
+	return QuestTemplate{}, false
+}
+
+// friendRoomSpansSQL 用户和好友同在一个自习室的区间：双方成员记录的交集 (未离开的算到 now)
+const friendRoomSpansSQL = `
+	SELECT GREATEST(me.joined_at, f.joined_at) AS "start",
+		LEAST(COALESCE(me.left_at, ?), COALESCE(f.left_at, ?)) AS "end"
+	FROM room_members me
+	JOIN room_members f ON f.room_id = me.room_id AND f.user_id <> me.user_id
+	JOIN friends fr ON fr.status = ? AND (
+		(fr.user_id = me.user_id AND fr.friend_id = f.user_id) OR (fr.friend_id = me.user_id AND fr.user_id = f.user_id))
+	WHERE me.user_id = ?
+		AND me.joined_at < ? AND (me.left_at IS NULL OR me.left_at > ?)
+		AND f.joined_at < ? AND (f.left_at IS NULL OR f.left_at > ?)`
+
+// friendRoomSpans 查询 [from, to) 内用户和至少一位好友同在一个自习室的区间，合并后按时间排序
+func friendRoomSpans(userID string, from, to, now time.Time) ([]timeSpan, error) {
+	var rows []timeSpan
+	if err := database.DB.Raw(friendRoomSpansSQL, now, now, model.FriendStatusAccepted, userID, to, from, to, from).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return mergeSpans(rows), nil
+}
+
+type QuestService struct{}
+
+// ensureQuests 返回用户当前日 / 周周期的任务，周期内第一次访问时按模板生成
+func (s *QuestService) ensureQuests(userID string, loc *time.Location, now time.Time) ([]model.UserQuest, error) {
+	today := localDate(now, loc)
+
+	var quests []model.UserQuest
+	for _, period := range []model.GoalPeriod{model.GoalPeriodDaily, model.GoalPeriodWeekly} {
+		start, end := goalPeriodRange(period, today)
+
+		var current []model.UserQuest
+		find := func() error {
+			return database.DB.Where("user_id = ? AND period = ? AND period_start = ?", userID, period, start).
+				Order("created_at ASC, code ASC").
+				Find(&current).Error
+		}
+		if err := find(); err != nil {
+			return nil, err
+		}
+
+		if len(current) == 0 {
+			generated, err := s.generate(userID, period, start, end)
+			if err != nil {
+				return nil, err
+			}
+			if len(generated) > 0 {
+				// 并发生成时抽取结果相同，唯一索引冲突直接忽略
+				if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&generated).Error; err != nil {
+					return nil, err
+				}
+				if err := find(); err != nil {
+					return nil, err
+				}
+			}
+		}
+		quests = append(quests, current...)
+	}
+	return quests, nil
+}
+
+// generate 为一个周期抽取任务，同一用户同一周期的抽取结果固定
+func (s *QuestService) generate(userID string, period model.GoalPeriod, start, end time.Time) ([]model.UserQuest, error) {
+	count := DailyQuestCount
+	if period == model.GoalPeriodWeekly {
+		count = WeeklyQuestCount
+	}
+
+	var candidates []QuestTemplate
+	for _, t := range QuestTemplates {
+		if t.Period == period {
+			candidates = append(candidates, t)
+		}
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(userID + string(period) + start.Format("2006-01-02")))
+	r := rand.New(rand.NewSource(int64(h.Sum64())))
+	r.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+
+	var topTags []model.UserTagStat
+	if err := database.DB.Preload("Tag").Where("user_id = ?", userID).
+		Order("xp DESC, tag_id ASC").Limit(5).Find(&topTags).Error; err != nil {
+		return nil, err
+	}
+
+	quests := make([]model.UserQuest, 0, count)
+	for _, t := range candidates {
+		if len(quests) >= count {
+			break
+		}
+
+		q := model.UserQuest{
+			UserID:      userID,
+			Code:        t.Code,
+			Period:      period,
+			PeriodStart: start,
+			PeriodEnd:   end,
+			Title:       t.Title,
+			Target:      t.Target,
+			RewardXP:    t.RewardXP,
+		}
+		if t.UserTag {
+			if len(topTags) == 0 {
+				continue
+			}
+			stat := topTags[r.Intn(len(topTags))]
+			tagID := stat.TagID
+			q.TagID = &tagID
+			q.Title = fmt.Sprintf(t.Title, stat.Tag.Name)
+		}
+		quests = append(quests, q)
+	}
+	return quests, nil
+}
+
+// questMetricValue 计算任务周期内的指标值
+func questMetricValue(q *model.UserQuest, metric QuestMetric, loc *time.Location) (int, error) {
+	fromT := time.Date(q.PeriodStart.Year(), q.PeriodStart.Month(), q.PeriodStart.Day(), 0, 0, 0, 0, loc)
+	toT := time.Date(q.PeriodEnd.Year(), q.PeriodEnd.Month(), q.PeriodEnd.Day()+1, 0, 0, 0, 0, loc)
+
+	switch metric {
+	case QuestMetricStudyMinutes, QuestMetricFriendRoomMinutes:
+		// 补录会话和待审核 / 被驳回的会话不计入任务
+		query := database.DB.Preload("Pauses").
+			Where("user_id = ? AND type <> ? AND is_manual = ? AND end_time IS NOT NULL AND start_time < ? AND end_time > ?",
+				q.UserID, model.SessionTypeRest, false, toT, fromT).
+			Where("review_status NOT IN ?", []model.SessionReviewStatus{model.SessionReviewPending, model.SessionReviewRejected})
+		if q.TagID != nil {
+			query = query.Where("tag_id = ?", *q.TagID)
+		}
+
+		// 好友同房任务只计入专注时间中与好友同在房间的部分
+		var friendSpans []timeSpan
+		if metric == QuestMetricFriendRoomMinutes {
+			var err error
+			if friendSpans, err = friendRoomSpans(q.UserID, fromT, toT, time.Now()); err != nil {
+				return 0, err
+			}
+			if len(friendSpans) == 0 {
+				return 0, nil
+			}
+		}
+
+		var sessions []model.StudySession
+		if err := query.Find(&sessions).Error; err != nil {
+			return 0, err
+		}
+
+		total := 0
+		for _, sess := range sessions {
+			spans := focusSpans(sess.StartTime, *sess.EndTime, sess.Pauses)
+			if metric == QuestMetricFriendRoomMinutes {
+				spans = intersectSpans(spans, friendSpans)
+			}
+			for _, day := range splitSpansByDay(spans, loc) {
+				if !day.Date.Before(q.PeriodStart) && !day.Date.After(q.PeriodEnd) {
+					total += day.Minutes
+				}
+			}
+		}
+		return total, nil
+
+	case QuestMetricBlogs:
+		var count int64
+		query := database.DB.Model(&model.Blog{}).
+			Where("user_id = ? AND status = ? AND created_at >= ? AND created_at < ?", q.UserID, model.BlogStatusPublished, fromT, toT)
+		if q.TagID != nil {
+			query = query.Where("id IN (SELECT blog_id FROM blog_tags WHERE tag_id = ?)", *q.TagID)
+		}
+		err := query.Count(&count).Error
+		return int(count), err
+
+	case QuestMetricHealthDays:
+		var count int64
+		err := database.DB.Model(&model.HealthData{}).
+			Where("user_id = ? AND date >= ? AND date <= ?", q.UserID, q.PeriodStart, q.PeriodEnd).
+			Count(&count).Error
+		return int(count), err
+
+	default:
+		return 0, fmt.Errorf("unknown quest metric %s", metric)
+	}
+}
+
+// refresh 重新计算当前周期任务的进度，metrics 为 nil 表示所有指标；达到目标的任务立即完成并发放奖励
+func (s *QuestService) refresh(userID string, metrics []QuestMetric) ([]model.UserQuest, error) {
+	loc := userLocation(database.DB, userID)
+	quests, err := s.ensureQuests(userID, loc, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[QuestMetric]bool, len(metrics))
+	for _, m := range metrics {
+		wanted[m] = true
+	}
+
+	for i := range quests {
+		q := &quests[i]
+		if q.CompletedAt != nil {
+			continue
+		}
+		tpl, ok := questTemplate(q.Code)
+		if !ok || (metrics != nil && !wanted[tpl.Metric]) {
+			continue
+		}
+
+		value, err := questMetricValue(q, tpl.Metric, loc)
+		if err != nil {
+			return nil, err
+		}
+
+		if value >= q.Target {
+			if err := s.complete(q, loc); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if value != q.Progress {
+			if err := database.DB.Model(&model.UserQuest{}).Where("id = ?", q.ID).Update("progress", value).Error; err != nil {
+				return nil, err
+			}
+			q.Progress = value
+			emitToUser(userID, "quest_progress", dto.QuestEvent{Quest: toQuestResponse(q)})
+		}
+	}
+	return quests, nil
+}
+
+// complete 完成任务并通过经验流水发放奖励 (与学习、博客经验走同一条路径，计入 DailyStat.XP 和 UserTagStat.XP)
+func (s *QuestService) complete(q *model.UserQuest, loc *time.Location) error {
+	now := time.Now()
+	completed := false
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// 条件更新保证并发刷新时奖励只发一次
+		result := tx.Model(&model.UserQuest{}).
+			Where("id = ? AND completed_at IS NULL", q.ID).
+			Updates(map[string]interface{}{"progress": q.Target, "completed_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		completed = true
+
+		return (&XPService{}).Grant(tx, XPGrant{
+			UserID:      q.UserID,
+			Source:      model.XPSourceQuest,
+			ReferenceID: q.ID,
+			TagID:       q.TagID,
+			Amount:      q.RewardXP,
+			Date:        localDate(now, loc),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	q.Progress = q.Target
+	q.CompletedAt = &now
+	if !completed {
+		return nil
+	}
+
+	emitToUser(q.UserID, "quest_completed", dto.QuestEvent{Quest: toQuestResponse(q)})
+
+	notificationService := &NotificationService{}
+	content := fmt.Sprintf("%s (+%d XP)", q.Title, q.RewardXP)
+	if err := notificationService.Notify(q.UserID, model.NotificationTypeQuest, "Quest completed", content, &q.ID); err != nil {
+		log.Printf("[QuestService] Failed to notify user %s: %v", q.UserID, err)
+	}
+	return nil
+}
+
+// Refresh 事件发生后更新相关任务的进度
+func (s *QuestService) Refresh(userID string, trigger QuestTrigger) error {
+	_, err := s.refresh(userID, questTriggerMetrics[trigger])
+	return err
+}
+
+// RefreshAsync 在后台更新任务进度，不影响主流程
+func (s *QuestService) RefreshAsync(userID string, trigger QuestTrigger) {
+	go func() {
+		if err := s.Refresh(userID, trigger); err != nil {
+			log.Printf("[QuestService] Failed to refresh %s quests for user %s: %v", trigger, userID, err)
+		}
+	}()
+}
+
+// GetQuests 当前周期的每日 / 每周任务 (查询时顺便重新计算进度，周期内首次查询前的学习也能计入)
+func (s *QuestService) GetQuests(userID string) (*dto.QuestListResponse, error) {
+	quests, err := s.refresh(userID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.QuestListResponse{Daily: []dto.QuestResponse{}, Weekly: []dto.QuestResponse{}}
+	for i := range quests {
+		item := toQuestResponse(&quests[i])
+		if quests[i].Period == model.GoalPeriodWeekly {
+			resp.Weekly = append(resp.Weekly, item)
+		} else {
+			resp.Daily = append(resp.Daily, item)
+		}
+	}
+	return resp, nil
+}
+
+func toQuestResponse(q *model.UserQuest) dto.QuestResponse {
+	return dto.QuestResponse{
+		ID:          q.ID,
+		Code:        q.Code,
+		Title:       q.Title,
+		Period:      q.Period,
+		TagID:       q.TagID,
+		Target:      q.Target,
+		Progress:    q.Progress,
+		RewardXP:    q.RewardXP,
+		Completed:   q.CompletedAt != nil,
+		CompletedAt: q.CompletedAt,
+		PeriodStart: q.PeriodStart.Format("2006-01-02"),
+		PeriodEnd:   q.PeriodEnd.Format("2006-01-02"),
+	}
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"backend/internal/model"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RankingScope 榜单周期
+type RankingScope string
+
+const (
+	RankingScopeDay    RankingScope = "day"
+	RankingScopeWeek   RankingScope = "week"
+	RankingScopeMonth  RankingScope = "month"
+	RankingScopeSeason RankingScope = "season"
+	RankingScopeAll    RankingScope = "all"
+)
+
+// ParseRankingScope 兼容前端传 ["week"]、week|all 之类的写法，无法识别时按周榜处理
+func ParseRankingScope(s string) RankingScope {
+	s = strings.ToLower(strings.Trim(s, `[]"' `))
+	switch RankingScope(s) {
+	case RankingScopeDay, RankingScopeWeek, RankingScopeMonth, RankingScopeSeason, RankingScopeAll:
+		return RankingScope(s)
+	}
+	if s == "total" || strings.Contains(s, "all") {
+		return RankingScopeAll
+	}
+	return RankingScopeWeek
+}
+
+// rankingPeriod 一个具体的榜单周期，例如 2024 年第 3 周
+// Redis Key 形如 ranking:{suffix} (全站) 或 ranking:tag:{tagID}:{suffix} (单个标签)
+type rankingPeriod struct {
+	Scope  RankingScope
+	Suffix string
+	TTL    time.Duration // 最后一次写入后多久过期，0 表示不过期
+}
+
+// 周期榜单按服务器时区划分，和会话的结束时间对应
+func totalRankingPeriod() rankingPeriod {
+	return rankingPeriod{Scope: RankingScopeAll, Suffix: "total"}
+}
+
+func dayRankingPeriod(t time.Time) rankingPeriod {
+	return rankingPeriod{Scope: RankingScopeDay, Suffix: "day:" + t.In(time.Local).Format("2006-01-02"), TTL: 2 * 24 * time.Hour}
+}
+
+func weekRankingPeriod(t time.Time) rankingPeriod {
+	year, week := t.In(time.Local).ISOWeek()
+	return rankingPeriod{Scope: RankingScopeWeek, Suffix: fmt.Sprintf("week:%d-%d", year, week), TTL: 14 * 24 * time.Hour}
+}
+
+func monthRankingPeriod(t time.Time) rankingPeriod {
+	return rankingPeriod{Scope: RankingScopeMonth, Suffix: "month:" + t.In(time.Local).Format("2006-01"), TTL: 62 * 24 * time.Hour}
+}
+
+// 赛季榜不过期，赛季结束后仍可查询
+func seasonRankingPeriod(seasonID string) rankingPeriod {
+	return rankingPeriod{Scope: RankingScopeSeason, Suffix: "season:" + seasonID}
+}
+
+// rankingKey 榜单的 Redis Key，tagID 为空表示全站榜
+func rankingKey(p rankingPeriod, tagID string) string {
+	if tagID == "" {
+		return "ranking:" + p.Suffix
+	}
+	return fmt.Sprintf("ranking:tag:%s:%s", tagID, p.Suffix)
+}
+
+// seasonsAt 包含 t 所在日期的赛季
+func seasonsAt(db *gorm.DB, t time.Time) ([]model.RankingSeason, error) {
+	day := localDate(t, time.Local)
+	var seasons []model.RankingSeason
+	err := db.Where("start_date <= ? AND end_date >= ?", day, day).Order("start_date ASC").Find(&seasons).Error
+	return seasons, err
+}
+
+// rankingPeriodsAt 在 t 结束的会话需要计入的所有榜单周期
+func rankingPeriodsAt(db *gorm.DB, t time.Time) ([]rankingPeriod, error) {
+	seasons, err := seasonsAt(db, t)
+	if err != nil {
+		return nil, err
+	}
+	return rankingPeriodsWith(t, seasons), nil
+}
+
+// rankingPeriodsWith 同 rankingPeriodsAt，赛季由调用方给出 (批量重算时避免逐个会话查库)
+func rankingPeriodsWith(t time.Time, seasons []model.RankingSeason) []rankingPeriod {
+	periods := []rankingPeriod{
+		totalRankingPeriod(),
+		dayRankingPeriod(t),
+		weekRankingPeriod(t),
+		monthRankingPeriod(t),
+	}
+
+	day := localDate(t, time.Local)
+	for _, season := range seasons {
+		if !dateOnly(season.StartDate).After(day) && !dateOnly(season.EndDate).Before(day) {
+			periods = append(periods, seasonRankingPeriod(season.ID))
+		}
+	}
+	return periods
+}
+
+// activeRankingPeriods 仍在有效期内的榜单周期 (当前和上一个日 / 周 / 月，以及所有赛季)，更早的周期已经过期，不再重建
+func activeRankingPeriods(now time.Time, seasons []model.RankingSeason) []rankingPeriod {
+	firstOfMonth := time.Date(now.In(time.Local).Year(), now.In(time.Local).Month(), 1, 0, 0, 0, 0, time.Local)
+	periods := []rankingPeriod{
+		totalRankingPeriod(),
+		dayRankingPeriod(now),
+		dayRankingPeriod(now.AddDate(0, 0, -1)),
+		weekRankingPeriod(now),
+		weekRankingPeriod(now.AddDate(0, 0, -7)),
+		monthRankingPeriod(now),
+		monthRankingPeriod(firstOfMonth.AddDate(0, 0, -1)),
+	}
+
+	for _, season := range seasons {
+		periods = append(periods, seasonRankingPeriod(season.ID))
+	}
+	return periods
+}
+
+// resolveRankingPeriod 查询榜单时定位具体周期；赛季榜不传 seasonID 时取当前赛季
+func resolveRankingPeriod(db *gorm.DB, scope RankingScope, seasonID string, now time.Time) (rankingPeriod, *model.RankingSeason, error) {
+	switch scope {
+	case RankingScopeDay:
+		return dayRankingPeriod(now), nil, nil
+	case RankingScopeMonth:
+		return monthRankingPeriod(now), nil, nil
+	case RankingScopeAll:
+		return totalRankingPeriod(), nil, nil
+	case RankingScopeSeason:
+		var season model.RankingSeason
+		if seasonID != "" {
+			if err := db.First(&season, "id = ?", seasonID).Error; err != nil {
+				return rankingPeriod{}, nil, errors.New("season not found")
+			}
+		} else {
+			seasons, err := seasonsAt(db, now)
+			if err != nil {
+				return rankingPeriod{}, nil, err
+			}
+			if len(seasons) == 0 {
+				return rankingPeriod{}, nil, errors.New("no active season")
+			}
+			season = seasons[0]
+		}
+		return seasonRankingPeriod(season.ID), &season, nil
+	default:
+		return weekRankingPeriod(now), nil, nil
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"backend/internal/model"
 	"backend/pkg/database"
 	"context"
-	"fmt"
+	"errors"
+	"math"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -14,127 +16,309 @@ import (
 
 type RankingService struct{}
 
-// GetGlobalRankings 获取全站排行榜 (支持按 Tag 筛选)
-func (s *RankingService) GetGlobalRankings(scope string, limit int, tagName string) ([]dto.RankingItem, error) {
+// GetGlobalRankings 获取全站排行榜 (支持按 Tag 筛选)，同时返回当前用户的名次
+func (s *RankingService) GetGlobalRankings(userID string, scope RankingScope, seasonID string, limit int, tagName string) (*dto.GlobalRankingResponse, error) {
 	ctx := context.Background()
-	
-	// === 场景 A: 按具体标签筛选 (走 DB) ===
-	if tagName != "" {
-		// 1. 解析标签 (处理别名)
-		tagService := &TagService{}
-		tag, err := tagService.FindOrCreateTag(tagName) // 这里稍微有点副作用：如果是新词会创建。也可以用 SearchTags 但那个是模糊。
-		// 其实 FindOrCreateTag 没问题，查榜单时顺便创建 Tag 记录是可以接受的，或者专门写个 FindTagByName。
-		// 为了 MVP，复用 FindOrCreateTag。
-		if err != nil {
-			return nil, err
-		}
 
-		// 2. 查询 user_tag_stats (联表查询 User 信息)
-		var results []struct {
-			UserID       string
-			TotalMinutes int
-			Nickname     string
-			AvatarUrl    *string
-		}
+	// 1. 确定周期
+	period, season, err := resolveRankingPeriod(database.DB, scope, seasonID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	resp := &dto.GlobalRankingResponse{Scope: string(period.Scope)}
+	if season != nil {
+		resp.Season = toSeasonResponse(season)
+	}
 
-		err = database.DB.Table("user_tag_stats").
-			Select("user_tag_stats.user_id, user_tag_stats.total_minutes, users.nickname, users.avatar_url").
-			Joins("JOIN users ON users.id = user_tag_stats.user_id").
-			Where("user_tag_stats.tag_id = ?", tag.ID).
-			Order("user_tag_stats.total_minutes DESC").
-			Limit(limit).
-			Scan(&results).Error
-		
+	// 2. 按标签筛选时解析别名 (只查不建)，走该标签自己的 ZSET
+	tagID := ""
+	if tagName != "" {
+		tagService := &TagService{}
+		tag, err := tagService.FindTag(tagName)
 		if err != nil {
 			return nil, err
 		}
-
-		// 3. 组装
-		items := make([]dto.RankingItem, len(results))
-		for i, r := range results {
-			items[i] = dto.RankingItem{
-				UserID:    r.UserID,
-				Minutes:   r.TotalMinutes,
-				Nickname:  r.Nickname,
-				AvatarURL: r.AvatarUrl,
-			}
-		}
-		return items, nil
+		tagID = tag.ID
+		resp.TagID = &tag.ID
 	}
+	key := rankingKey(period, tagID)
 
-	// === 场景 B: 全局总榜 (走 Redis) ===
-	var key string
-
-	// 确定 Redis Key
-	if scope == "week" {
-		year, week := time.Now().ISOWeek()
-		key = fmt.Sprintf("ranking:week:%d-%d", year, week)
-	} else {
-		key = "ranking:total"
+	// 3. 从 Redis 获取前 N 名 (ZRevRangeWithScores: 分数从高到低)
+	redisResults, err := database.RDB.ZRevRangeWithScores(ctx, key, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	resp.Items, err = rankingItems(redisResults, 1)
+	if err != nil {
+		return nil, err
 	}
 
-	// 1. 从 Redis 获取前 N 名 (ZRevRangeWithScores: 分数从高到低)
-	// Start: 0, Stop: limit - 1
-	redisResults, err := database.RDB.ZRevRangeWithScores(ctx, key, 0, int64(limit-1)).Result()
+	// 4. 当前用户的名次：在前 N 名里直接取，否则单独查
+	for i := range resp.Items {
+		if resp.Items[i].UserID == userID {
+			me := resp.Items[i]
+			resp.Me = &me
+			return resp, nil
+		}
+	}
+	resp.Me, err = rankingItemOf(ctx, key, userID)
 	if err != nil {
 		return nil, err
 	}
+	return resp, nil
+}
 
-	if len(redisResults) == 0 {
+// rankingItems 把 ZSET 结果组装为榜单项，firstRank 为第一个元素的名次
+func rankingItems(results []redis.Z, firstRank int) ([]dto.RankingItem, error) {
+	if len(results) == 0 {
 		return []dto.RankingItem{}, nil
 	}
 
-	// 2. 提取 UserIDs 并构建分数 Map
-	userIDs := make([]string, 0, len(redisResults))
-	scoreMap := make(map[string]int)
-
-	for _, z := range redisResults {
-		uid := z.Member.(string) // Member 是 interface{}，需要断言
-		userIDs = append(userIDs, uid)
-		scoreMap[uid] = int(z.Score)
+	// 1. 提取 UserIDs
+	userIDs := make([]string, 0, len(results))
+	for _, z := range results {
+		userIDs = append(userIDs, z.Member.(string)) // Member 是 interface{}，需要断言
 	}
 
-	// 3. 批量查询用户信息 (Postgres)
+	// 2. 批量查询用户信息 (Postgres)
 	var users []model.User
 	if err := database.DB.Where("id IN ?", userIDs).Find(&users).Error; err != nil {
 		return nil, err
 	}
 
-	// 4. 组装结果 (注意：DB返回的 users 顺序可能和 userIDs 不一致，需要重新对齐)
+	// 3. 组装结果 (注意：DB返回的 users 顺序可能和 userIDs 不一致，需要重新对齐)
 	userMap := make(map[string]model.User)
 	for _, u := range users {
 		userMap[u.ID] = u
 	}
 
-	items := make([]dto.RankingItem, 0, len(userIDs))
-	for _, uid := range userIDs {
+	items := make([]dto.RankingItem, 0, len(results))
+	for i, z := range results {
+		uid := z.Member.(string)
 		if u, ok := userMap[uid]; ok {
 			items = append(items, dto.RankingItem{
+				Rank:      firstRank + i,
 				UserID:    uid,
-				Minutes:   scoreMap[uid],
+				Minutes:   int(z.Score),
 				Nickname:  u.Nickname,
 				AvatarURL: u.AvatarUrl,
 			})
 		}
 	}
+	return items, nil
+}
+
+// rankingItemOf 查询单个用户在榜单中的名次，未上榜时 Rank 为 0
+func rankingItemOf(ctx context.Context, key, userID string) (*dto.RankingItem, error) {
+	var user model.User
+	if err := database.DB.Select("id", "nickname", "avatar_url").First(&user, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	item := &dto.RankingItem{
+		UserID:    userID,
+		Nickname:  user.Nickname,
+		AvatarURL: user.AvatarUrl,
+	}
+
+	rank, err := database.RDB.ZRevRank(ctx, key, userID).Result()
+	if err == redis.Nil {
+		return item, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	score, err := database.RDB.ZScore(ctx, key, userID).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	item.Rank = int(rank) + 1
+	item.Minutes = int(score)
+	return item, nil
+}
+
+// GetMyRanking 获取当前用户在榜单中的名次、前后各 K 名用户和百分位
+// 全站 / 标签榜直接用 ZREVRANK + ZREVRANGE，好友榜在好友圈内排名
+func (s *RankingService) GetMyRanking(userID string, q dto.MyRankingQuery) (*dto.MyRankingResponse, error) {
+	ctx := context.Background()
+	if q.K <= 0 {
+		q.K = 3
+	}
+	if q.K > 20 {
+		q.K = 20
+	}
+
+	// 1. 确定榜单 Key
+	period, season, err := resolveRankingPeriod(database.DB, ParseRankingScope(q.Scope), q.Season, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	resp := &dto.MyRankingResponse{
+		Scope: string(period.Scope),
+		Above: []dto.RankingItem{},
+		Below: []dto.RankingItem{},
+	}
+	if season != nil {
+		resp.Season = toSeasonResponse(season)
+	}
+
+	tagID := ""
+	if q.Tag != "" {
+		tagService := &TagService{}
+		tag, err := tagService.FindTag(q.Tag)
+		if err != nil {
+			return nil, err
+		}
+		tagID = tag.ID
+		resp.TagID = &tag.ID
+	}
+	key := rankingKey(period, tagID)
+
+	// 2. 好友圈：拿到完整排名后在内存中截取
+	if q.Friends {
+		list, err := s.friendRankingList(ctx, userID, key)
+		if err != nil {
+			return nil, err
+		}
+		resp.Total = int64(len(list))
+		for i, item := range list {
+			if item.UserID != userID {
+				continue
+			}
+			resp.Me = item
+			resp.Above = list[max(0, i-q.K):i]
+			resp.Below = list[i+1 : min(len(list), i+1+q.K)]
+			break
+		}
+		resp.Percentile = rankingPercentile(resp.Me.Rank, resp.Total)
+		return resp, nil
+	}
+
+	// 3. 全站 / 标签榜
+	me, err := rankingItemOf(ctx, key, userID)
+	if err != nil {
+		return nil, err
+	}
+	resp.Me = *me
+	resp.Total, err = database.RDB.ZCard(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	// 未上榜时展示榜单末尾的 K 人，方便用户知道差多少能上榜
+	idx := resp.Total
+	if me.Rank > 0 {
+		idx = int64(me.Rank - 1)
+	}
+	if idx > 0 {
+		start := max(0, idx-int64(q.K))
+		above, err := database.RDB.ZRevRangeWithScores(ctx, key, start, idx-1).Result()
+		if err != nil {
+			return nil, err
+		}
+		if resp.Above, err = rankingItems(above, int(start)+1); err != nil {
+			return nil, err
+		}
+	}
+	if me.Rank > 0 {
+		below, err := database.RDB.ZRevRangeWithScores(ctx, key, idx+1, idx+int64(q.K)).Result()
+		if err != nil {
+			return nil, err
+		}
+		if resp.Below, err = rankingItems(below, int(idx)+2); err != nil {
+			return nil, err
+		}
+	}
+
+	resp.Percentile = rankingPercentile(me.Rank, resp.Total)
+	return resp, nil
+}
+
+// rankingPercentile 名次 rank (从 1 开始) 在 total 人中超过了百分之多少的人，保留一位小数
+func rankingPercentile(rank int, total int64) float64 {
+	if rank <= 0 || total <= 0 {
+		return 0
+	}
+	p := float64(total-int64(rank)) / float64(total) * 100
+	return math.Round(p*10) / 10
+}
+
+// GetSeasons 获取所有赛季 (按开始日期倒序)
+func (s *RankingService) GetSeasons() ([]dto.RankingSeasonResponse, error) {
+	var seasons []model.RankingSeason
+	if err := database.DB.Order("start_date DESC").Find(&seasons).Error; err != nil {
+		return nil, err
+	}
 
+	items := make([]dto.RankingSeasonResponse, 0, len(seasons))
+	for i := range seasons {
+		items = append(items, *toSeasonResponse(&seasons[i]))
+	}
 	return items, nil
 }
 
+// CreateSeason 创建赛季，赛季之间不能重叠
+// 赛季开始日期早于今天时，已有的会话不会自动计入，需要执行 go run ./cmd/rebuild 补算
+func (s *RankingService) CreateSeason(name string, startDate, endDate time.Time) (*model.RankingSeason, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("season name cannot be empty")
+	}
+	startDate, endDate = dateOnly(startDate), dateOnly(endDate)
+	if endDate.Before(startDate) {
+		return nil, errors.New("season end date must not be before start date")
+	}
+
+	var overlaps int64
+	database.DB.Model(&model.RankingSeason{}).
+		Where("start_date <= ? AND end_date >= ?", endDate, startDate).
+		Count(&overlaps)
+	if overlaps > 0 {
+		return nil, errors.New("season overlaps an existing season")
+	}
+
+	season := model.RankingSeason{Name: name, StartDate: startDate, EndDate: endDate}
+	if err := database.DB.Create(&season).Error; err != nil {
+		return nil, err
+	}
+	return &season, nil
+}
+
+func toSeasonResponse(season *model.RankingSeason) *dto.RankingSeasonResponse {
+	return &dto.RankingSeasonResponse{
+		ID:        season.ID,
+		Name:      season.Name,
+		StartDate: season.StartDate.Format("2006-01-02"),
+		EndDate:   season.EndDate.Format("2006-01-02"),
+	}
+}
+
 // GetFriendRankings 获取好友圈排行榜
-func (s *RankingService) GetFriendRankings(myID string, scope string, limit int) ([]dto.RankingItem, error) {
+func (s *RankingService) GetFriendRankings(myID string, scope RankingScope, seasonID string, limit int) ([]dto.RankingItem, error) {
 	ctx := context.Background()
 
 	// 1. 确定 Key
-	var key string
-	if scope == "week" {
-		year, week := time.Now().ISOWeek()
-		key = fmt.Sprintf("ranking:week:%d-%d", year, week)
-	} else {
-		key = "ranking:total"
+	period, _, err := resolveRankingPeriod(database.DB, scope, seasonID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	rankingList, err := s.friendRankingList(ctx, myID, rankingKey(period, ""))
+	if err != nil {
+		return nil, err
 	}
 
-	// 2. 获取所有好友 ID (复用 FriendService 的逻辑或直接查库)
+	// 截取 limit
+	if len(rankingList) > limit {
+		rankingList = rankingList[:limit]
+	}
+
+	return rankingList, nil
+}
+
+// friendRankingList 好友圈 (含自己) 在某个榜单上的完整排名，没有分数的好友排在最后
+func (s *RankingService) friendRankingList(ctx context.Context, myID, key string) ([]dto.RankingItem, error) {
+	// 1. 获取所有好友 ID (复用 FriendService 的逻辑或直接查库)
 	// 这里直接查库获取 accepted 的好友 ID
 	var friends []model.Friend
 	// 查询作为 User 和作为 Friend 的所有关系
@@ -158,7 +342,7 @@ func (s *RankingService) GetFriendRankings(myID string, scope string, limit int)
 	// 去重 (以防数据异常，虽然逻辑上不应该重复)
 	targetIDs = removeDuplicate(targetIDs)
 
-	// 3. 批量从 Redis 获取这些 ID 的分数 (Pipeline 优化)
+	// 2. 批量从 Redis 获取这些 ID 的分数 (Pipeline 优化)
 	pipe := database.RDB.Pipeline()
 	cmds := make(map[string]*redis.FloatCmd) // 存储命令结果
 
@@ -174,7 +358,7 @@ func (s *RankingService) GetFriendRankings(myID string, scope string, limit int)
 		// 在 go-redis v9 中，pipe.Exec 可能会返回 Nil 错误，我们稍后逐个检查 cmd.Err()
 	}
 
-	// 4. 获取用户信息
+	// 3. 获取用户信息
 	var users []model.User
 	if err := database.DB.Where("id IN ?", targetIDs).Find(&users).Error; err != nil {
 		return nil, err
@@ -184,7 +368,7 @@ func (s *RankingService) GetFriendRankings(myID string, scope string, limit int)
 		userMap[u.ID] = u
 	}
 
-	// 5. 组装列表
+	// 4. 组装列表
 	var rankingList []dto.RankingItem
 	for _, id := range targetIDs {
 		cmd := cmds[id]
@@ -205,14 +389,16 @@ func (s *RankingService) GetFriendRankings(myID string, scope string, limit int)
 		}
 	}
 
-	// 6. 内存排序 (分数从高到低)
-	sort.Slice(rankingList, func(i, j int) bool {
-		return rankingList[i].Minutes > rankingList[j].Minutes
+	// 5. 内存排序 (分数从高到低，同分按用户 ID 排，保证每次请求顺序一致)
+	sort.SliceStable(rankingList, func(i, j int) bool {
+		if rankingList[i].Minutes != rankingList[j].Minutes {
+			return rankingList[i].Minutes > rankingList[j].Minutes
+		}
+		return rankingList[i].UserID < rankingList[j].UserID
 	})
 
-	// 7. 截取 limit
-	if len(rankingList) > limit {
-		rankingList = rankingList[:limit]
+	for i := range rankingList {
+		rankingList[i].Rank = i + 1
 	}
 
 	return rankingList, nil
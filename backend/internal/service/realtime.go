@@ -0,0 +1,41 @@
+package service
+
+// 服务层无法直接依赖 socket 包 (socket 已经依赖 service)，
+// 因此由 socket.InitSocket 在启动时注入推送函数，后台任务通过它向客户端发事件。
+var emitFunc func(room, event string, data interface{})
+
+// SetEmitter 注入 Socket 推送函数 (room 为房间 ID 或以 UserID 命名的私有房间)
+func SetEmitter(fn func(room, event string, data interface{})) {
+	emitFunc = fn
+}
+
+// emitToUser 向指定用户的私有房间推送事件，Socket 未初始化时静默忽略
+func emitToUser(userID, event string, data interface{}) {
+	if emitFunc == nil {
+		return
+	}
+	emitFunc(userID, event, data)
+}
+
+// emitToRoom 向房间内所有人推送事件
+func emitToRoom(roomID, event string, data interface{}) {
+	if emitFunc == nil {
+		return
+	}
+	emitFunc(roomID, event, data)
+}
+
+var evictFunc func(userID, roomID string)
+
+// SetRoomEvictor 注入把用户的连接移出 Socket 房间的函数 (踢出 / 封禁时使用)
+func SetRoomEvictor(fn func(userID, roomID string)) {
+	evictFunc = fn
+}
+
+// evictFromRoom 让用户所有连接离开房间，Socket 未初始化时静默忽略
+func evictFromRoom(userID, roomID string) {
+	if evictFunc == nil {
+		return
+	}
+	evictFunc(userID, roomID)
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"backend/internal/dto"
+	"backend/internal/model"
+	"backend/pkg/database"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type RoomMessageService struct{}
+
+// RoomMessageMaxLength 单条房间消息的最大字符数
+const RoomMessageMaxLength = 2000
+
+// RoomHistoryReplayLimit join_room 时补发的最近消息条数
+const RoomHistoryReplayLimit = 50
+
+// likeEscaper 转义 LIKE 通配符，关键词按字面匹配
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func toMessageEvent(m *model.RoomMessage) dto.NewMessageEvent {
+	return dto.NewMessageEvent{
+		ID:        m.ID,
+		Content:   m.Content,
+		CreatedAt: m.CreatedAt,
+		Sender:    dto.UserSimple{ID: m.Sender.ID, Nickname: m.Sender.Nickname, AvatarURL: m.Sender.AvatarUrl},
+	}
+}
+
+// SaveMessage 保存房间消息 (Socket send_message 调用)
+// 只有当前在房间内且未被禁言的用户可以发送
+func (s *RoomMessageService) SaveMessage(userID, roomID, content string) (*dto.NewMessageEvent, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, errors.New("message cannot be empty")
+	}
+	if utf8.RuneCountInString(content) > RoomMessageMaxLength {
+		return nil, errors.New("message is too long")
+	}
+
+	var count int64
+	database.DB.Model(&model.RoomMember{}).
+		Where("room_id = ? AND user_id = ? AND left_at IS NULL", roomID, userID).
+		Count(&count)
+	if count == 0 {
+		return nil, errors.New("not in room")
+	}
+	if until := (&RoomModerationService{}).MutedUntil(roomID, userID); until != nil {
+		return nil, errors.New("you are muted until " + until.Format(time.RFC3339))
+	}
+
+	msg := model.RoomMessage{RoomID: roomID, SenderID: userID, Content: content}
+	if err := database.DB.Create(&msg).Error; err != nil {
+		return nil, err
+	}
+	if err := database.DB.First(&msg.Sender, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+
+	event := toMessageEvent(&msg)
+	return &event, nil
+}
+
+// canRead 公开房间所有人可读，私密房间只有房主和进入过房间的人可读
+func (s *RoomMessageService) canRead(userID, roomID string) error {
+	var room model.Room
+	if err := database.DB.First(&room, "id = ?", roomID).Error; err != nil {
+		return errors.New("room not found")
+	}
+	if !room.IsPrivate || room.CreatorID == userID {
+		return nil
+	}
+	var count int64
+	database.DB.Model(&model.RoomMember{}).Where("room_id = ? AND user_id = ?", roomID, userID).Count(&count)
+	if count == 0 {
+		return errors.New("permission denied")
+	}
+	return nil
+}
+
+// GetMessages 房间聊天记录，按时间倒序，用上一页最早一条消息的 ID 作为游标向前翻页
+func (s *RoomMessageService) GetMessages(userID, roomID string, q dto.RoomMessageQuery) (*dto.RoomMessageListResponse, error) {
+	if err := s.canRead(userID, roomID); err != nil {
+		return nil, err
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	db := database.DB.Model(&model.RoomMessage{}).Preload("Sender").Where("room_id = ?", roomID)
+	if q.Before != "" {
+		var cursor model.RoomMessage
+		if err := database.DB.Select("id", "created_at").
+			First(&cursor, "id = ? AND room_id = ?", q.Before, roomID).Error; err != nil {
+			return nil, errors.New("invalid cursor")
+		}
+		db = db.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+	if kw := strings.TrimSpace(q.Q); kw != "" {
+		db = db.Where("content ILIKE ?", "%"+likeEscaper.Replace(kw)+"%")
+	}
+
+	// 多取一条判断是否还有更早的消息
+	var messages []model.RoomMessage
+	if err := db.Order("created_at DESC, id DESC").Limit(limit + 1).Find(&messages).Error; err != nil {
+		return nil, err
+	}
+
+	resp := &dto.RoomMessageListResponse{}
+	if len(messages) > limit {
+		messages = messages[:limit]
+		resp.NextCursor = messages[limit-1].ID
+	}
+	resp.Items = make([]dto.NewMessageEvent, len(messages))
+	for i := range messages {
+		resp.Items[i] = toMessageEvent(&messages[i])
+	}
+	return resp, nil
+}
+
+// RecentMessages 最近 limit 条消息，按时间正序 (join_room 补发历史)
+func (s *RoomMessageService) RecentMessages(roomID string, limit int) ([]dto.NewMessageEvent, error) {
+	var messages []model.RoomMessage
+	if err := database.DB.Preload("Sender").
+		Where("room_id = ?", roomID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&messages).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.NewMessageEvent, len(messages))
+	for i := range messages {
+		items[len(messages)-1-i] = toMessageEvent(&messages[i])
+	}
+	return items, nil
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"backend/internal/dto"
+	"backend/internal/model"
+	"backend/pkg/database"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RoomModerationService 房间管理：踢出、封禁、禁言，所有操作写入 RoomModerationLog
+type RoomModerationService struct{}
+
+// memberRole 用户在房间中的角色，房主固定为 owner，其他人取最近一次进入房间时的角色
+func memberRole(db *gorm.DB, room *model.Room, userID string) string {
+	if room.CreatorID == userID {
+		return "owner"
+	}
+	var role string
+	db.Model(&model.RoomMember{}).Select("role").
+		Where("room_id = ? AND user_id = ?", room.ID, userID).
+		Order("joined_at DESC").Limit(1).Scan(&role)
+	if role == "" {
+		role = "member"
+	}
+	return role
+}
+
+// authorize 只有房主和管理员可以操作，且只能操作角色比自己低的成员
+func (s *RoomModerationService) authorize(actorID, roomID, targetID string) (*model.Room, error) {
+	var room model.Room
+	if err := database.DB.First(&room, "id = ?", roomID).Error; err != nil {
+		return nil, errors.New("room not found")
+	}
+	if actorID == targetID {
+		return nil, errors.New("cannot moderate yourself")
+	}
+	actorWeight := getRoleWeight(memberRole(database.DB, &room, actorID))
+	if actorWeight < getRoleWeight("admin") {
+		return nil, errors.New("permission denied")
+	}
+	if getRoleWeight(memberRole(database.DB, &room, targetID)) >= actorWeight {
+		return nil, errors.New("permission denied")
+	}
+	return &room, nil
+}
+
+func (s *RoomModerationService) writeLog(db *gorm.DB, roomID, actorID, targetID string, action model.ModerationAction, reason string, minutes int) error {
+	return db.Create(&model.RoomModerationLog{
+		RoomID:          roomID,
+		ActorID:         actorID,
+		TargetID:        targetID,
+		Action:          action,
+		Reason:          reason,
+		DurationMinutes: minutes,
+	}).Error
+}
+
+// removeFromRoom 关闭目标在房间内的记录，返回目标当时是否在房间内
+func (s *RoomModerationService) removeFromRoom(db *gorm.DB, roomID, targetID string) (bool, error) {
+	result := db.Model(&model.RoomMember{}).
+		Where("room_id = ? AND user_id = ? AND left_at IS NULL", roomID, targetID).
+		Update("left_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// afterRemove 让目标的连接离开 Socket 房间，通知本人和房间其他人
+func (s *RoomModerationService) afterRemove(roomID, targetID string, event dto.RoomKickedEvent) {
+	if err := (&PresenceService{}).ClearRoom(targetID, roomID); err != nil {
+		log.Printf("[Moderation] Failed to clear presence of user %s: %v\n", targetID, err)
+	}
+	evictFromRoom(targetID, roomID)
+	emitToUser(targetID, "room_kicked", event)
+	emitToRoom(roomID, "user_left", dto.UserLeftEvent{UserID: targetID})
+}
+
+// Kick 把成员踢出房间 (可以立即重新加入)
+func (s *RoomModerationService) Kick(actorID, roomID, targetID, reason string) error {
+	if _, err := s.authorize(actorID, roomID, targetID); err != nil {
+		return err
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		removed, err := s.removeFromRoom(tx, roomID, targetID)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return errors.New("user is not in the room")
+		}
+		return s.writeLog(tx, roomID, actorID, targetID, model.ModerationKick, reason, 0)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.afterRemove(roomID, targetID, dto.RoomKickedEvent{RoomID: roomID, Action: model.ModerationKick, Reason: reason})
+	return nil
+}
+
+// Ban 封禁用户，minutes 为 0 表示永久；用户在房间内时同时踢出
+func (s *RoomModerationService) Ban(actorID, roomID, targetID, reason string, minutes int) error {
+	if _, err := s.authorize(actorID, roomID, targetID); err != nil {
+		return err
+	}
+
+	ban := model.RoomBan{RoomID: roomID, UserID: targetID, BannedBy: actorID, Reason: reason}
+	if minutes > 0 {
+		expiresAt := time.Now().Add(time.Duration(minutes) * time.Minute)
+		ban.ExpiresAt = &expiresAt
+	}
+
+	removed := false
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "room_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"banned_by", "reason", "expires_at", "created_at"}),
+		}).Create(&ban).Error; err != nil {
+			return err
+		}
+		var err error
+		if removed, err = s.removeFromRoom(tx, roomID, targetID); err != nil {
+			return err
+		}
+		return s.writeLog(tx, roomID, actorID, targetID, model.ModerationBan, reason, minutes)
+	})
+	if err != nil {
+		return err
+	}
+
+	event := dto.RoomKickedEvent{RoomID: roomID, Action: model.ModerationBan, Reason: reason, ExpiresAt: ban.ExpiresAt}
+	if removed {
+		s.afterRemove(roomID, targetID, event)
+	} else {
+		emitToUser(targetID, "room_kicked", event)
+	}
+	return nil
+}
+
+// Unban 解除封禁
+func (s *RoomModerationService) Unban(actorID, roomID, targetID string) error {
+	if _, err := s.authorize(actorID, roomID, targetID); err != nil {
+		return err
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("room_id = ? AND user_id = ?", roomID, targetID).Delete(&model.RoomBan{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("ban not found")
+		}
+		return s.writeLog(tx, roomID, actorID, targetID, model.ModerationUnban, "", 0)
+	})
+}
+
+// Mute 禁言 minutes 分钟，期间 send_message 会被拒绝
+func (s *RoomModerationService) Mute(actorID, roomID, targetID, reason string, minutes int) error {
+	if minutes <= 0 {
+		return errors.New("mute duration is required")
+	}
+	if _, err := s.authorize(actorID, roomID, targetID); err != nil {
+		return err
+	}
+
+	mute := model.RoomMute{
+		RoomID:    roomID,
+		UserID:    targetID,
+		MutedBy:   actorID,
+		Reason:    reason,
+		ExpiresAt: time.Now().Add(time.Duration(minutes) * time.Minute),
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "room_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"muted_by", "reason", "expires_at", "created_at"}),
+		}).Create(&mute).Error; err != nil {
+			return err
+		}
+		return s.writeLog(tx, roomID, actorID, targetID, model.ModerationMute, reason, minutes)
+	})
+	if err != nil {
+		return err
+	}
+
+	emitToUser(targetID, "room_muted", dto.RoomMutedEvent{RoomID: roomID, Reason: reason, MutedUntil: &mute.ExpiresAt})
+	return nil
+}
+
+// Unmute 解除禁言
+func (s *RoomModerationService) Unmute(actorID, roomID, targetID string) error {
+	if _, err := s.authorize(actorID, roomID, targetID); err != nil {
+		return err
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("room_id = ? AND user_id = ? AND expires_at > ?", roomID, targetID, time.Now()).
+			Delete(&model.RoomMute{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("mute not found")
+		}
+		return s.writeLog(tx, roomID, actorID, targetID, model.ModerationUnmute, "", 0)
+	})
+	if err != nil {
+		return err
+	}
+
+	emitToUser(targetID, "room_muted", dto.RoomMutedEvent{RoomID: roomID})
+	return nil
+}
+
+// Moderate 按 action 分发 (Socket moderate_member 调用)
+func (s *RoomModerationService) Moderate(actorID string, p dto.ModerateMemberPayload) error {
+	switch p.Action {
+	case model.ModerationKick:
+		return s.Kick(actorID, p.RoomID, p.TargetUserID, p.Reason)
+	case model.ModerationBan:
+		return s.Ban(actorID, p.RoomID, p.TargetUserID, p.Reason, max(p.DurationMinutes, 0))
+	case model.ModerationUnban:
+		return s.Unban(actorID, p.RoomID, p.TargetUserID)
+	case model.ModerationMute:
+		return s.Mute(actorID, p.RoomID, p.TargetUserID, p.Reason, p.DurationMinutes)
+	case model.ModerationUnmute:
+		return s.Unmute(actorID, p.RoomID, p.TargetUserID)
+	default:
+		return errors.New("invalid action")
+	}
+}
+
+// IsBanned 用户当前是否被房间封禁 (JoinRoom 调用)
+func (s *RoomModerationService) IsBanned(roomID, userID string) bool {
+	var count int64
+	database.DB.Model(&model.RoomBan{}).
+		Where("room_id = ? AND user_id = ? AND (expires_at IS NULL OR expires_at > ?)", roomID, userID, time.Now()).
+		Count(&count)
+	return count > 0
+}
+
+// MutedUntil 用户在房间内的禁言到期时间，未被禁言返回 nil (send_message 调用)
+func (s *RoomModerationService) MutedUntil(roomID, userID string) *time.Time {
+	var mute model.RoomMute
+	if err := database.DB.Where("room_id = ? AND user_id = ? AND expires_at > ?", roomID, userID, time.Now()).
+		First(&mute).Error; err != nil {
+		return nil
+	}
+	return &mute.ExpiresAt
+}
+
+// requireModerator 查看封禁名单和操作记录需要房主或管理员权限
+func (s *RoomModerationService) requireModerator(userID, roomID string) error {
+	var room model.Room
+	if err := database.DB.First(&room, "id = ?", roomID).Error; err != nil {
+		return errors.New("room not found")
+	}
+	if getRoleWeight(memberRole(database.DB, &room, userID)) < getRoleWeight("admin") {
+		return errors.New("permission denied")
+	}
+	return nil
+}
+
+// GetBans 房间当前生效的封禁名单
+func (s *RoomModerationService) GetBans(userID, roomID string) ([]dto.RoomBanResponse, error) {
+	if err := s.requireModerator(userID, roomID); err != nil {
+		return nil, err
+	}
+
+	var bans []model.RoomBan
+	if err := database.DB.Preload("User").
+		Where("room_id = ? AND (expires_at IS NULL OR expires_at > ?)", roomID, time.Now()).
+		Order("created_at DESC").
+		Find(&bans).Error; err != nil {
+		return nil, err
+	}
+
+	resp := make([]dto.RoomBanResponse, len(bans))
+	for i, b := range bans {
+		resp[i] = dto.RoomBanResponse{
+			User:      dto.UserSimple{ID: b.User.ID, Nickname: b.User.Nickname, AvatarURL: b.User.AvatarUrl},
+			BannedBy:  b.BannedBy,
+			Reason:    b.Reason,
+			ExpiresAt: b.ExpiresAt,
+			CreatedAt: b.CreatedAt,
+		}
+	}
+	return resp, nil
+}
+
+// GetModerationLog 房间管理操作记录，按时间倒序分页
+func (s *RoomModerationService) GetModerationLog(userID, roomID string, page, pageSize int) (*dto.RoomModerationLogListResponse, error) {
+	if err := s.requireModerator(userID, roomID); err != nil {
+		return nil, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	var total int64
+	if err := database.DB.Model(&model.RoomModerationLog{}).Where("room_id = ?", roomID).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var logs []model.RoomModerationLog
+	if err := database.DB.Preload("Actor").Preload("Target").
+		Where("room_id = ?", roomID).
+		Order("created_at DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.RoomModerationLogResponse, len(logs))
+	for i, l := range logs {
+		items[i] = dto.RoomModerationLogResponse{
+			ID:              l.ID,
+			Action:          l.Action,
+			Actor:           dto.UserSimple{ID: l.Actor.ID, Nickname: l.Actor.Nickname, AvatarURL: l.Actor.AvatarUrl},
+			Target:          dto.UserSimple{ID: l.Target.ID, Nickname: l.Target.Nickname, AvatarURL: l.Target.AvatarUrl},
+			Reason:          l.Reason,
+			DurationMinutes: l.DurationMinutes,
+			CreatedAt:       l.CreatedAt,
+		}
+	}
+
+	return &dto.RoomModerationLogListResponse{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/pkg/database"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// StartRoomScheduleReminder 启动房间日程提醒任务：开始前 ReminderMinutes 分钟提醒回复了参加/可能参加的用户
+// 在 main.go 中 go service.StartRoomScheduleReminder() 调用
+func StartRoomScheduleReminder() {
+	interval := 1 * time.Minute
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if acquireJobLock("room_schedule_reminder", interval) {
+			runRoomScheduleReminder()
+		}
+	}
+}
+
+func runRoomScheduleReminder() {
+	now := time.Now()
+	var schedules []model.RoomSchedule
+	if err := database.DB.Preload("Room").
+		Where("reminder_minutes > 0 AND (ends_at IS NULL OR ends_at > ?)", now).
+		Find(&schedules).Error; err != nil {
+		log.Printf("[ScheduleReminder] Error fetching schedules: %v\n", err)
+		return
+	}
+
+	s := &RoomScheduleService{}
+	for i := range schedules {
+		sc := &schedules[i]
+		for _, start := range s.expand(sc, now, now.Add(time.Duration(sc.ReminderMinutes)*time.Minute)) {
+			if err := s.remind(sc, start, now); err != nil {
+				log.Printf("[ScheduleReminder] Failed to remind schedule %s: %v\n", sc.ID, err)
+			}
+		}
+	}
+}
+
+// remind 提醒某一场，用 Redis SETNX 保证每场只提醒一次 (多实例部署也不会重复)
+func (s *RoomScheduleService) remind(sc *model.RoomSchedule, start, now time.Time) error {
+	ctx := context.Background()
+	key := fmt.Sprintf("room:schedule:remind:%s:%d", sc.ID, start.Unix())
+	ok, err := database.RDB.SetNX(ctx, key, 1, time.Duration(sc.ReminderMinutes+sc.DurationMinutes)*time.Minute+time.Hour).Result()
+	if err != nil || !ok {
+		return err
+	}
+
+	var userIDs []string
+	if err := database.DB.Model(&model.RoomScheduleRSVP{}).
+		Where("schedule_id = ? AND occurrence_start = ? AND status IN ?", sc.ID, start,
+			[]model.RSVPStatus{model.RSVPGoing, model.RSVPMaybe}).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return err
+	}
+
+	minutes := max(int(start.Sub(now).Round(time.Minute).Minutes()), 1)
+	content := fmt.Sprintf("\"%s\" in %s starts in %d minutes.", sc.Title, sc.Room.Name, minutes)
+
+	notificationService := &NotificationService{}
+	for _, uid := range userIDs {
+		if err := notificationService.Notify(uid, model.NotificationTypeSchedule, "Study session starting soon", content, &sc.RoomID); err != nil {
+			log.Printf("[ScheduleReminder] Failed to notify user %s: %v\n", uid, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,378 @@
+package service
+
+import (
+	"backend/internal/dto"
+	"backend/internal/model"
+	"backend/pkg/database"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+type RoomScheduleService struct{}
+
+// DefaultScheduleDays 查询日程时默认展开的天数
+const DefaultScheduleDays = 14
+
+// ScheduleAttendanceRatio 在房间内的时长达到场次时长的该比例才算出席
+const ScheduleAttendanceRatio = 0.5
+
+// expand 展开日程在 [from, to) 内的开始时间
+func (s *RoomScheduleService) expand(sc *model.RoomSchedule, from, to time.Time) []time.Time {
+	if sc.RRule == "" {
+		if !sc.StartTime.Before(from) && sc.StartTime.Before(to) {
+			return []time.Time{sc.StartTime}
+		}
+		return nil
+	}
+	rule, err := parseRRule(sc.RRule)
+	if err != nil {
+		return nil
+	}
+	return rule.occurrences(sc.StartTime, loadLocation(sc.Timezone), from, to)
+}
+
+// scheduleEndsAt 计算最后一场的结束时间，不限次数的重复返回 nil
+func (s *RoomScheduleService) scheduleEndsAt(sc *model.RoomSchedule, rule *recurrenceRule) *time.Time {
+	duration := time.Duration(sc.DurationMinutes) * time.Minute
+	var last time.Time
+	switch {
+	case rule == nil:
+		last = sc.StartTime
+	case rule.Count > 0:
+		occ := rule.occurrences(sc.StartTime, loadLocation(sc.Timezone), sc.StartTime, time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC))
+		if len(occ) == 0 {
+			return nil
+		}
+		last = occ[len(occ)-1]
+	case rule.Until != nil:
+		last = *rule.untilIn(loadLocation(sc.Timezone))
+	default:
+		return nil
+	}
+	endsAt := last.Add(duration)
+	return &endsAt
+}
+
+// loadSchedule 查询房间下的日程
+func (s *RoomScheduleService) loadSchedule(roomID, scheduleID string) (*model.RoomSchedule, error) {
+	var sc model.RoomSchedule
+	if err := database.DB.Preload("Room").First(&sc, "id = ? AND room_id = ?", scheduleID, roomID).Error; err != nil {
+		return nil, errors.New("schedule not found")
+	}
+	return &sc, nil
+}
+
+// checkRoomAccess 私密房间只有房主和进入过房间的人才能查看日程、回复
+func checkRoomAccess(room *model.Room, userID string) error {
+	if !room.IsPrivate || room.CreatorID == userID {
+		return nil
+	}
+	var count int64
+	database.DB.Model(&model.RoomMember{}).Where("room_id = ? AND user_id = ?", room.ID, userID).Count(&count)
+	if count == 0 {
+		return errors.New("permission denied")
+	}
+	return nil
+}
+
+// CreateSchedule 房主创建一次性或重复的定时自习
+func (s *RoomScheduleService) CreateSchedule(userID, roomID string, req dto.CreateRoomScheduleRequest) (*dto.RoomScheduleResponse, error) {
+	var room model.Room
+	if err := database.DB.First(&room, "id = ?", roomID).Error; err != nil {
+		return nil, errors.New("room not found")
+	}
+	if room.CreatorID != userID {
+		return nil, errors.New("permission denied")
+	}
+
+	sc := model.RoomSchedule{
+		RoomID:          roomID,
+		CreatorID:       userID,
+		Title:           req.Title,
+		StartTime:       req.StartTime.Truncate(time.Minute),
+		DurationMinutes: req.DurationMinutes,
+		RRule:           strings.ToUpper(strings.TrimSpace(req.RRule)),
+		Timezone:        req.Timezone,
+		ReminderMinutes: 15,
+	}
+	if req.ReminderMinutes != nil {
+		sc.ReminderMinutes = *req.ReminderMinutes
+	}
+	if sc.Timezone == "" {
+		sc.Timezone = userLocation(database.DB, userID).String()
+	} else if err := ValidateTimezone(sc.Timezone); err != nil {
+		return nil, err
+	}
+
+	var rule *recurrenceRule
+	if sc.RRule != "" {
+		r, err := parseRRule(sc.RRule)
+		if err != nil {
+			return nil, errors.New("invalid rrule: " + err.Error())
+		}
+		rule = r
+		// INTERVAL 最大 52 周，两年内一定会出现第一场
+		if len(s.expand(&sc, sc.StartTime, sc.StartTime.AddDate(2, 0, 0))) == 0 {
+			return nil, errors.New("rrule has no occurrences")
+		}
+	} else if !sc.StartTime.After(time.Now()) {
+		return nil, errors.New("start time must be in the future")
+	}
+	sc.EndsAt = s.scheduleEndsAt(&sc, rule)
+
+	if err := database.DB.Create(&sc).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return s.toResponse(&sc, userID, now, now.AddDate(0, 0, DefaultScheduleDays))
+}
+
+// DeleteSchedule 删除日程 (房主或日程创建者)，RSVP 级联删除
+func (s *RoomScheduleService) DeleteSchedule(userID, roomID, scheduleID string) error {
+	sc, err := s.loadSchedule(roomID, scheduleID)
+	if err != nil {
+		return err
+	}
+	if sc.Room.CreatorID != userID && sc.CreatorID != userID {
+		return errors.New("permission denied")
+	}
+	return database.DB.Delete(&model.RoomSchedule{}, "id = ?", sc.ID).Error
+}
+
+// GetSchedules 房间的日程，展开未来 days 天内的场次 (包括正在进行的) 并附带 RSVP 统计
+func (s *RoomScheduleService) GetSchedules(userID, roomID string, days int) ([]dto.RoomScheduleResponse, error) {
+	var room model.Room
+	if err := database.DB.First(&room, "id = ?", roomID).Error; err != nil {
+		return nil, errors.New("room not found")
+	}
+	if err := checkRoomAccess(&room, userID); err != nil {
+		return nil, err
+	}
+	if days <= 0 {
+		days = DefaultScheduleDays
+	}
+
+	now := time.Now()
+	var schedules []model.RoomSchedule
+	if err := database.DB.Where("room_id = ? AND (ends_at IS NULL OR ends_at > ?)", roomID, now).
+		Order("start_time ASC").Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+
+	resp := make([]dto.RoomScheduleResponse, 0, len(schedules))
+	for i := range schedules {
+		item, err := s.toResponse(&schedules[i], userID, now, now.AddDate(0, 0, days))
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, *item)
+	}
+	return resp, nil
+}
+
+// toResponse 展开 [from, to) 内的场次，已开始但未结束的场次也包含在内
+func (s *RoomScheduleService) toResponse(sc *model.RoomSchedule, userID string, from, to time.Time) (*dto.RoomScheduleResponse, error) {
+	duration := time.Duration(sc.DurationMinutes) * time.Minute
+	starts := s.expand(sc, from.Add(-duration+time.Second), to)
+
+	resp := &dto.RoomScheduleResponse{
+		ID:              sc.ID,
+		RoomID:          sc.RoomID,
+		CreatorID:       sc.CreatorID,
+		Title:           sc.Title,
+		StartTime:       sc.StartTime,
+		DurationMinutes: sc.DurationMinutes,
+		RRule:           sc.RRule,
+		Timezone:        sc.Timezone,
+		ReminderMinutes: sc.ReminderMinutes,
+		Occurrences:     make([]dto.RoomOccurrenceResponse, len(starts)),
+	}
+	if len(starts) == 0 {
+		return resp, nil
+	}
+
+	var rsvps []model.RoomScheduleRSVP
+	if err := database.DB.Where("schedule_id = ? AND occurrence_start IN ?", sc.ID, starts).
+		Find(&rsvps).Error; err != nil {
+		return nil, err
+	}
+
+	index := make(map[int64]int, len(starts))
+	for i, start := range starts {
+		index[start.Unix()] = i
+		resp.Occurrences[i] = dto.RoomOccurrenceResponse{
+			ScheduleID: sc.ID,
+			Title:      sc.Title,
+			Start:      start,
+			End:        start.Add(duration),
+		}
+	}
+	for _, r := range rsvps {
+		i, ok := index[r.OccurrenceStart.Unix()]
+		if !ok {
+			continue
+		}
+		occ := &resp.Occurrences[i]
+		switch r.Status {
+		case model.RSVPGoing:
+			occ.Going++
+		case model.RSVPMaybe:
+			occ.Maybe++
+		case model.RSVPDeclined:
+			occ.Declined++
+		}
+		if r.UserID == userID {
+			occ.MyRSVP = r.Status
+		}
+	}
+	return resp, nil
+}
+
+// findOccurrence 校验 start 是日程中的一场，返回该场的开始时间
+func (s *RoomScheduleService) findOccurrence(sc *model.RoomSchedule, start time.Time) (time.Time, error) {
+	starts := s.expand(sc, start, start.Add(time.Second))
+	if len(starts) == 0 {
+		return time.Time{}, errors.New("occurrence not found")
+	}
+	return starts[0], nil
+}
+
+// RSVP 回复某一场是否参加，重复回复会覆盖之前的状态
+func (s *RoomScheduleService) RSVP(userID, roomID, scheduleID string, req dto.RSVPRequest) (*dto.RoomOccurrenceResponse, error) {
+	sc, err := s.loadSchedule(roomID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	start, err := s.findOccurrence(sc, req.OccurrenceStart)
+	if err != nil {
+		return nil, err
+	}
+	if !start.Add(time.Duration(sc.DurationMinutes) * time.Minute).After(time.Now()) {
+		return nil, errors.New("occurrence already ended")
+	}
+
+	if err := checkRoomAccess(&sc.Room, userID); err != nil {
+		return nil, err
+	}
+
+	rsvp := model.RoomScheduleRSVP{
+		ScheduleID:      sc.ID,
+		OccurrenceStart: start,
+		UserID:          userID,
+		Status:          req.Status,
+	}
+	if err := database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "schedule_id"}, {Name: "occurrence_start"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status", "updated_at"}),
+	}).Create(&rsvp).Error; err != nil {
+		return nil, err
+	}
+
+	resp, err := s.toResponse(sc, userID, start, start.Add(time.Second))
+	if err != nil {
+		return nil, err
+	}
+	for i := range resp.Occurrences {
+		if resp.Occurrences[i].Start.Equal(start) {
+			return &resp.Occurrences[i], nil
+		}
+	}
+	return nil, errors.New("occurrence not found")
+}
+
+// GetAttendance 某一场的实际出席情况
+// 由 RoomMember 的 JoinedAt/LeftAt 与场次时间取交集计算，还在房间内的记录按当前时间截止
+func (s *RoomScheduleService) GetAttendance(userID, roomID, scheduleID string, start time.Time) (*dto.RoomAttendanceResponse, error) {
+	sc, err := s.loadSchedule(roomID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRoomAccess(&sc.Room, userID); err != nil {
+		return nil, err
+	}
+	start, err = s.findOccurrence(sc, start)
+	if err != nil {
+		return nil, err
+	}
+	end := start.Add(time.Duration(sc.DurationMinutes) * time.Minute)
+
+	var members []model.RoomMember
+	if err := database.DB.Preload("User").
+		Where("room_id = ? AND joined_at < ? AND (left_at IS NULL OR left_at > ?)", roomID, end, start).
+		Order("joined_at ASC").
+		Find(&members).Error; err != nil {
+		return nil, err
+	}
+
+	var rsvps []model.RoomScheduleRSVP
+	if err := database.DB.Preload("User").
+		Where("schedule_id = ? AND occurrence_start = ?", sc.ID, start).
+		Find(&rsvps).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	attendees := make(map[string]*dto.RoomAttendee)
+	seconds := make(map[string]float64)
+	order := make([]string, 0)
+	attendee := func(u *model.User) *dto.RoomAttendee {
+		a, ok := attendees[u.ID]
+		if !ok {
+			a = &dto.RoomAttendee{User: dto.UserSimple{ID: u.ID, Nickname: u.Nickname, AvatarURL: u.AvatarUrl}}
+			attendees[u.ID] = a
+			order = append(order, u.ID)
+		}
+		return a
+	}
+
+	for i := range members {
+		m := &members[i]
+		from := m.JoinedAt
+		if from.Before(start) {
+			from = start
+		}
+		to := end
+		if m.LeftAt != nil && m.LeftAt.Before(to) {
+			to = *m.LeftAt
+		}
+		if now.Before(to) {
+			to = now
+		}
+		if !to.After(from) {
+			continue
+		}
+
+		a := attendee(&m.User)
+		seconds[m.UserID] += to.Sub(from).Seconds()
+		if a.FirstJoinedAt == nil {
+			joined := m.JoinedAt
+			a.FirstJoinedAt = &joined
+		}
+	}
+	for i := range rsvps {
+		attendee(&rsvps[i].User).RSVP = rsvps[i].Status
+	}
+
+	resp := &dto.RoomAttendanceResponse{
+		ScheduleID: sc.ID,
+		Title:      sc.Title,
+		Start:      start,
+		End:        end,
+		Attendees:  make([]dto.RoomAttendee, 0, len(order)),
+	}
+	for _, id := range order {
+		a := attendees[id]
+		a.Minutes = int(seconds[id] / 60)
+		a.Attended = float64(a.Minutes) >= float64(sc.DurationMinutes)*ScheduleAttendanceRatio
+		resp.Attendees = append(resp.Attendees, *a)
+	}
+	sort.SliceStable(resp.Attendees, func(i, j int) bool {
+		return resp.Attendees[i].Minutes > resp.Attendees[j].Minutes
+	})
+	return resp, nil
+}
@@ -202,6 +202,11 @@ func (s *RoomService) JoinRoom(userID, roomID string, password *string) error {
 		return errors.New("room not found")
 	}
 
+	// 1.1 检查封禁名单
+	if (&RoomModerationService{}).IsBanned(roomID, userID) {
+		return errors.New("you are banned from this room")
+	}
+
 	// 2. 检查密码 (如果是私密房间)
 	if room.IsPrivate {
 		if room.Password != nil && *room.Password != "" {
@@ -228,10 +233,8 @@ func (s *RoomService) JoinRoom(userID, roomID string, password *string) error {
 		return nil
 	}
 
-	role := "member"
-	if userID == room.CreatorID {
-		role = "owner"
-	}
+	// 沿用上一次进入时的角色，离开、断线重连或被踢出后重新进入不会丢失管理员身份
+	role := memberRole(database.DB, &room, userID)
 
 	member := model.RoomMember{
 		RoomID:   roomID,
@@ -282,18 +285,18 @@ func (s *RoomService) GetRoomMembers(roomID string) ([]dto.RoomMemberResponse, e
 
 	// 批量查询经验值
 	var stats []struct {
-		UserID       string
-		TotalMinutes int
+		UserID string
+		XP     int
 	}
 	database.DB.Model(&model.DailyStat{}).
-		Select("user_id, COALESCE(SUM(total_minutes), 0) as total_minutes").
+		Select("user_id, COALESCE(SUM(xp), 0) as xp").
 		Where("user_id IN ?", userIDs).
 		Group("user_id").
 		Scan(&stats)
 
 	xpMap := make(map[string]int)
 	for _, s := range stats {
-		xpMap[s.UserID] = s.TotalMinutes
+		xpMap[s.UserID] = s.XP
 	}
 
 	levelService := &LevelService{}
@@ -302,7 +305,7 @@ func (s *RoomService) GetRoomMembers(roomID string) ([]dto.RoomMemberResponse, e
 	resp := make([]dto.RoomMemberResponse, len(members))
 	for i, m := range members {
 		xp := xpMap[m.UserID]
-		levelInfo := levelService.CalculateLevel(xp)
+		levelInfo := levelService.CalculateUserLevel(&m.User, xp)
 
 		resp[i] = dto.RoomMemberResponse{
 			UserID:    m.UserID,
@@ -337,6 +340,31 @@ func getRoleWeight(role string) int {
 	return 1
 }
 
+// AuthorizeMember 校验用户当前在房间内且角色不低于 minRole (owner > admin > member)，返回当前角色
+// Socket 的房间事件统一通过它鉴权
+func (s *RoomService) AuthorizeMember(userID, roomID, minRole string) (string, error) {
+	var room model.Room
+	if err := database.DB.Select("id", "creator_id").First(&room, "id = ?", roomID).Error; err != nil {
+		return "", errors.New("room not found")
+	}
+
+	var member model.RoomMember
+	if err := database.DB.Select("role").
+		Where("room_id = ? AND user_id = ? AND left_at IS NULL", roomID, userID).
+		First(&member).Error; err != nil {
+		return "", errors.New("not in room")
+	}
+
+	role := member.Role
+	if room.CreatorID == userID {
+		role = "owner"
+	}
+	if getRoleWeight(role) < getRoleWeight(minRole) {
+		return "", errors.New("permission denied")
+	}
+	return role, nil
+}
+
 // UpdateMemberRole 设置管理员权限
 func (s *RoomService) UpdateMemberRole(operatorID, roomID, targetUserID, newRole string) error {
 	var room model.Room
@@ -356,8 +384,12 @@ func (s *RoomService) UpdateMemberRole(operatorID, roomID, targetUserID, newRole
 		return errors.New("invalid role")
 	}
 
+	// 更新最近一条记录 (不在线的成员也生效)，下次进入房间时沿用
+	latest := database.DB.Model(&model.RoomMember{}).Select("id").
+		Where("room_id = ? AND user_id = ?", roomID, targetUserID).
+		Order("joined_at DESC").Limit(1)
 	return database.DB.Model(&model.RoomMember{}).
-		Where("room_id = ? AND user_id = ? AND left_at IS NULL", roomID, targetUserID).
+		Where("id = (?)", latest).
 		Update("role", newRole).Error
 }
 
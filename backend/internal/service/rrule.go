@@ -0,0 +1,170 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// recurrenceRule RFC 5545 RRULE 的子集：FREQ=DAILY|WEEKLY，INTERVAL、BYDAY、COUNT、UNTIL
+// 例如工作日早上的打卡：FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR
+type recurrenceRule struct {
+	Freq      string
+	Interval  int
+	ByDay     []time.Weekday // 仅 WEEKLY 使用，为空时取第一次开始的星期
+	Count     int            // 0 表示不限
+	Until     *time.Time
+	UntilDate bool // UNTIL 只给了日期，包含 loc 本地的当天
+}
+
+// maxRecurrenceCount COUNT 的上限，避免展开时遍历过久
+const maxRecurrenceCount = 1000
+
+var rruleWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// parseRRule 解析 RRULE，允许带或不带 "RRULE:" 前缀
+func parseRRule(s string) (*recurrenceRule, error) {
+	s = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "RRULE:")
+	if s == "" {
+		return nil, errors.New("empty rrule")
+	}
+
+	r := &recurrenceRule{Interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rrule part %q", part)
+		}
+		switch key {
+		case "FREQ":
+			if value != "DAILY" && value != "WEEKLY" {
+				return nil, fmt.Errorf("unsupported FREQ %s", value)
+			}
+			r.Freq = value
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 52 {
+				return nil, fmt.Errorf("invalid INTERVAL %s", value)
+			}
+			r.Interval = n
+		case "BYDAY":
+			for _, d := range strings.Split(value, ",") {
+				wd, ok := rruleWeekdays[d]
+				if !ok {
+					return nil, fmt.Errorf("invalid BYDAY %s", d)
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > maxRecurrenceCount {
+				return nil, fmt.Errorf("invalid COUNT %s", value)
+			}
+			r.Count = n
+		case "UNTIL":
+			t, dateOnly, err := parseRRuleUntil(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid UNTIL %s", value)
+			}
+			r.Until = &t
+			r.UntilDate = dateOnly
+		default:
+			return nil, fmt.Errorf("unsupported rrule part %s", key)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, errors.New("FREQ is required")
+	}
+	if r.Count > 0 && r.Until != nil {
+		return nil, errors.New("COUNT and UNTIL cannot be used together")
+	}
+	if len(r.ByDay) > 0 && r.Freq != "WEEKLY" {
+		return nil, errors.New("BYDAY is only supported with FREQ=WEEKLY")
+	}
+	return r, nil
+}
+
+// parseRRuleUntil 解析 UNTIL，dateOnly 表示只给了日期 (此时返回 UTC 0 点表示的日期)
+func parseRRuleUntil(value string) (t time.Time, dateOnly bool, err error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.Parse("20060102", value); err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, errors.New("invalid time")
+}
+
+// untilIn 重复的截止时间 (含)，只给日期时取 loc 本地当天的最后一秒
+func (r *recurrenceRule) untilIn(loc *time.Location) *time.Time {
+	if r.Until == nil || !r.UntilDate {
+		return r.Until
+	}
+	u := time.Date(r.Until.Year(), r.Until.Month(), r.Until.Day()+1, 0, 0, 0, 0, loc).Add(-time.Second)
+	return &u
+}
+
+// occurrences 展开 [from, to) 内的开始时间
+// 按 loc 的本地时间重复 (跨夏令时仍保持同一钟点)，dtstart 为第一次开始时间
+func (r *recurrenceRule) occurrences(dtstart time.Time, loc *time.Location, from, to time.Time) []time.Time {
+	local := dtstart.In(loc)
+	startDay := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+
+	byDay := make(map[time.Weekday]bool, 7)
+	for _, d := range r.ByDay {
+		byDay[d] = true
+	}
+	if len(byDay) == 0 {
+		byDay[local.Weekday()] = true
+	}
+	// WEEKLY 的 INTERVAL 以第一次开始所在的周 (周一开始) 为基准
+	startWeek := startDay.AddDate(0, 0, -((int(startDay.Weekday()) + 6) % 7))
+
+	// 没有 COUNT 时不需要从头数，直接从 from 的前一天开始
+	first := startDay
+	if r.Count == 0 {
+		lf := from.In(loc)
+		if jump := time.Date(lf.Year(), lf.Month(), lf.Day()-1, 0, 0, 0, 0, loc); jump.After(startDay) {
+			first = jump
+		}
+	}
+
+	until := r.untilIn(loc)
+
+	var result []time.Time
+	n := 0
+	for day := first; ; day = day.AddDate(0, 0, 1) {
+		occ := time.Date(day.Year(), day.Month(), day.Day(), local.Hour(), local.Minute(), local.Second(), 0, loc)
+		if !occ.Before(to) || (until != nil && occ.After(*until)) {
+			break
+		}
+
+		days := int(dateOnly(day).Sub(dateOnly(startDay)).Hours() / 24)
+		match := false
+		switch r.Freq {
+		case "DAILY":
+			match = days%r.Interval == 0
+		case "WEEKLY":
+			weeks := int(dateOnly(day).Sub(dateOnly(startWeek)).Hours()/24) / 7
+			match = byDay[day.Weekday()] && weeks%r.Interval == 0
+		}
+		if !match || occ.Before(dtstart) {
+			continue
+		}
+
+		n++
+		if r.Count > 0 && n > r.Count {
+			break
+		}
+		if !occ.Before(from) {
+			result = append(result, occ)
+		}
+	}
+	return result
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRRule(t *testing.T) {
+	tests := []struct {
+		rule    string
+		wantErr bool
+	}{
+		{rule: "FREQ=DAILY"},
+		{rule: "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE"},
+		{rule: "freq=daily;count=3"},
+		{rule: "FREQ=DAILY;UNTIL=20240103"},
+		{rule: "FREQ=DAILY;UNTIL=20240103T120000Z"},
+		{rule: "", wantErr: true},
+		{rule: "INTERVAL=2", wantErr: true},
+		{rule: "FREQ=MONTHLY", wantErr: true},
+		{rule: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{rule: "FREQ=DAILY;BYDAY=MO", wantErr: true},
+		{rule: "FREQ=WEEKLY;BYDAY=XX", wantErr: true},
+		{rule: "FREQ=DAILY;COUNT=1001", wantErr: true},
+		{rule: "FREQ=DAILY;COUNT=3;UNTIL=20240103", wantErr: true},
+		{rule: "FREQ=DAILY;UNTIL=2024-01-03", wantErr: true},
+		{rule: "FREQ=DAILY;BYMONTH=1", wantErr: true},
+	}
+	for _, tt := range tests {
+		_, err := parseRRule(tt.rule)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseRRule(%q): err = %v, wantErr %v", tt.rule, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRecurrenceOccurrences(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("load location: %v", err)
+	}
+	at := func(s string) time.Time {
+		v, err := time.ParseInLocation("2006-01-02 15:04", s, ny)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		name    string
+		rule    string
+		dtstart string
+		from    string
+		to      string
+		want    []string // RFC 3339 UTC，便于看出夏令时的偏移
+	}{
+		{
+			// 以第一次开始所在的周 (1 月 1 日周一) 为基准，隔周的周一、周三；开始之前的周一不算
+			name:    "weekly interval anchored on start week",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE",
+			dtstart: "2024-01-03 09:00",
+			from:    "2024-01-01 00:00",
+			to:      "2024-02-01 00:00",
+			want: []string{
+				"2024-01-03T14:00:00Z", "2024-01-15T14:00:00Z", "2024-01-17T14:00:00Z",
+				"2024-01-29T14:00:00Z", "2024-01-31T14:00:00Z",
+			},
+		},
+		{
+			// 不限次数时直接跳到 from 附近展开，周数仍按开始所在的周对齐
+			name:    "weekly interval after skip ahead",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE",
+			dtstart: "2024-01-03 09:00",
+			from:    "2024-02-27 00:00",
+			to:      "2024-03-09 00:00",
+			want:    []string{"2024-02-28T14:00:00Z"},
+		},
+		{
+			name:    "daily interval after skip ahead",
+			rule:    "FREQ=DAILY;INTERVAL=3",
+			dtstart: "2024-01-01 09:00",
+			from:    "2024-01-30 00:00",
+			to:      "2024-02-05 00:00",
+			want:    []string{"2024-01-31T14:00:00Z", "2024-02-03T14:00:00Z"},
+		},
+		{
+			// 有 COUNT 时必须从第一次开始计数，from 之前的场次也占用次数
+			name:    "count counted from dtstart",
+			rule:    "FREQ=DAILY;COUNT=5",
+			dtstart: "2024-01-01 09:00",
+			from:    "2024-01-04 00:00",
+			to:      "2024-02-01 00:00",
+			want:    []string{"2024-01-04T14:00:00Z", "2024-01-05T14:00:00Z"},
+		},
+		{
+			name:    "count with interval",
+			rule:    "FREQ=DAILY;INTERVAL=3;COUNT=3",
+			dtstart: "2024-01-01 09:00",
+			from:    "2024-01-01 00:00",
+			to:      "2024-02-01 00:00",
+			want:    []string{"2024-01-01T14:00:00Z", "2024-01-04T14:00:00Z", "2024-01-07T14:00:00Z"},
+		},
+		{
+			name:    "weekly count",
+			rule:    "FREQ=WEEKLY;BYDAY=TU,TH;COUNT=3",
+			dtstart: "2024-01-02 09:00",
+			from:    "2024-01-05 00:00",
+			to:      "2024-02-01 00:00",
+			want:    []string{"2024-01-09T14:00:00Z"},
+		},
+		{
+			// 3 月 10 日开始夏令时，本地钟点不变，UTC 提前一小时
+			name:    "dst spring forward",
+			rule:    "FREQ=DAILY",
+			dtstart: "2024-03-08 09:00",
+			from:    "2024-03-09 00:00",
+			to:      "2024-03-12 00:00",
+			want:    []string{"2024-03-09T14:00:00Z", "2024-03-10T13:00:00Z", "2024-03-11T13:00:00Z"},
+		},
+		{
+			name:    "dst fall back",
+			rule:    "FREQ=WEEKLY",
+			dtstart: "2024-10-27 09:00",
+			from:    "2024-10-27 00:00",
+			to:      "2024-11-04 00:00",
+			want:    []string{"2024-10-27T13:00:00Z", "2024-11-03T14:00:00Z"},
+		},
+		{
+			// 只给日期的 UNTIL 包含本地的当天，晚上的场次在 UTC 已经是第二天
+			name:    "date only until includes local day",
+			rule:    "FREQ=DAILY;UNTIL=20240103",
+			dtstart: "2024-01-01 20:00",
+			from:    "2024-01-01 00:00",
+			to:      "2024-02-01 00:00",
+			want:    []string{"2024-01-02T01:00:00Z", "2024-01-03T01:00:00Z", "2024-01-04T01:00:00Z"},
+		},
+		{
+			name:    "date time until is exact",
+			rule:    "FREQ=DAILY;UNTIL=20240103T140000Z",
+			dtstart: "2024-01-01 09:00",
+			from:    "2024-01-01 00:00",
+			to:      "2024-02-01 00:00",
+			want:    []string{"2024-01-01T14:00:00Z", "2024-01-02T14:00:00Z", "2024-01-03T14:00:00Z"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := parseRRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := r.occurrences(at(tt.dtstart), ny, at(tt.from), at(tt.to))
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d occurrences %v, got %v", len(tt.want), tt.want, got)
+			}
+			for i, occ := range got {
+				if s := occ.UTC().Format(time.RFC3339); s != tt.want[i] {
+					t.Errorf("occurrence %d: expected %s, got %s", i, tt.want[i], s)
+				}
+			}
+		})
+	}
+}
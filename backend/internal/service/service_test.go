@@ -0,0 +1,67 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/pkg/database"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// 依赖外部服务的测试通过环境变量开启，未设置时跳过：
+//
+//	TEST_DATABASE_DSN="host=localhost user=postgres password=password dbname=mydb_test port=5432 sslmode=disable"
+//	TEST_REDIS_ADDR="localhost:6379"
+
+// setupDB 连接测试库并建表
+func setupDB(t *testing.T) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("connect database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate database: %v", err)
+	}
+	database.DB = db
+}
+
+// setupRedis 连接本地 Redis 并替换 database.RDB
+func setupRedis(t *testing.T) {
+	t.Helper()
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR not set")
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("connect redis: %v", err)
+	}
+	t.Cleanup(func() { rdb.Close() })
+	database.RDB = rdb
+}
+
+func createUser(t *testing.T, nickname string) string {
+	t.Helper()
+	user := model.User{
+		Email:        fmt.Sprintf("service-test-%d-%s@example.com", time.Now().UnixNano(), nickname),
+		PasswordHash: "x",
+		Nickname:     nickname,
+	}
+	if err := database.DB.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	t.Cleanup(func() { database.DB.Delete(&model.User{}, "id = ?", user.ID) })
+	return user.ID
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"backend/internal/dto"
+	"backend/internal/model"
+	"backend/pkg/database"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SessionReviewService 管理员审核被反作弊规则标记的会话
+type SessionReviewService struct{}
+
+// GetFlags 审核队列 (待审核的按时间正序，先处理最早的)
+func (s *SessionReviewService) GetFlags(q dto.SessionFlagQuery) (*dto.SessionFlagListResponse, error) {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize < 1 || q.PageSize > 100 {
+		q.PageSize = 20
+	}
+	if q.Status == "" {
+		q.Status = string(model.SessionReviewPending)
+	}
+
+	query := database.DB.Model(&model.SessionFlag{}).Where("status = ?", q.Status)
+	if q.UserID != "" {
+		query = query.Where("user_id = ?", q.UserID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	order := "created_at DESC"
+	if q.Status == string(model.SessionReviewPending) {
+		order = "created_at ASC"
+	}
+
+	var flags []model.SessionFlag
+	if err := query.Preload("Session").Preload("User").
+		Order(order).
+		Offset((q.Page - 1) * q.PageSize).
+		Limit(q.PageSize).
+		Find(&flags).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.SessionFlagResponse, 0, len(flags))
+	for i := range flags {
+		items = append(items, toSessionFlagResponse(&flags[i]))
+	}
+
+	return &dto.SessionFlagListResponse{
+		Items:    items,
+		Total:    total,
+		Page:     q.Page,
+		PageSize: q.PageSize,
+	}, nil
+}
+
+// ReviewFlag 审核一条记录：通过则补计入排行榜；驳回则继续排除在排行榜外，并可撤销该会话的经验
+func (s *SessionReviewService) ReviewFlag(reviewerID, flagID string, req dto.ReviewSessionFlagRequest) (*dto.SessionFlagResponse, error) {
+	var flag model.SessionFlag
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&flag, "id = ?", flagID).Error; err != nil {
+			return errors.New("flag not found")
+		}
+		if flag.Status != model.SessionReviewPending {
+			return errors.New("flag already reviewed")
+		}
+
+		var session model.StudySession
+		if err := tx.Preload("Pauses").First(&session, "id = ?", flag.SessionID).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		status := model.SessionReviewApproved
+		if req.Action == "reject" {
+			status = model.SessionReviewRejected
+		}
+
+		// 1. 驳回时按需撤销经验
+		xpRevoked := false
+		if status == model.SessionReviewRejected && req.RevokeXP {
+			xpService := &XPService{}
+			if _, err := xpService.Reverse(tx, session.UserID, []model.XPSource{model.XPSourceStudy}, session.ID, nil); err != nil {
+				return err
+			}
+			xpRevoked = true
+		}
+
+		// 2. 更新审核结果
+		if err := tx.Model(&flag).Updates(map[string]interface{}{
+			"status":      status,
+			"xp_revoked":  xpRevoked,
+			"reviewer_id": reviewerID,
+			"review_note": req.Note,
+			"reviewed_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&session).Update("review_status", status).Error; err != nil {
+			return err
+		}
+
+		// 3. 通过时补计入排行榜 (按原结束时间所在周期)
+		if status == model.SessionReviewApproved && session.EndTime != nil &&
+			session.Type != model.SessionTypeRest && (!session.IsManual || ManualSessionInRankings) {
+			minutes := 0
+			for _, day := range splitSpansByDay(focusSpans(session.StartTime, *session.EndTime, session.Pauses), userLocation(tx, session.UserID)) {
+				minutes += day.Minutes
+			}
+			studyService := &StudyService{}
+			if err := studyService.updateRankings(context.Background(), session.UserID, session.TagID, minutes, *session.EndTime); err != nil {
+				return fmt.Errorf("failed to update rankings: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	database.DB.Preload("Session").Preload("User").First(&flag, "id = ?", flagID)
+
+	if flag.Status == model.SessionReviewRejected {
+		content := "One of your study sessions was excluded from the leaderboards after review."
+		if flag.XPRevoked {
+			content = "One of your study sessions was excluded from the leaderboards after review, and its XP was revoked."
+		}
+		notificationService := &NotificationService{}
+		if err := notificationService.Notify(flag.UserID, model.NotificationTypeSystem, "Study session rejected", content, &flag.SessionID); err != nil {
+			log.Printf("[SessionReview] Failed to notify user %s: %v\n", flag.UserID, err)
+		}
+	} else {
+		// 待审核期间会话不计入任务和挑战，通过后补算进度
+		(&QuestService{}).RefreshAsync(flag.UserID, QuestTriggerSession)
+		(&ChallengeService{}).PublishProgressAsync(flag.UserID)
+	}
+
+	resp := toSessionFlagResponse(&flag)
+	return &resp, nil
+}
+
+func toSessionFlagResponse(flag *model.SessionFlag) dto.SessionFlagResponse {
+	return dto.SessionFlagResponse{
+		ID:              flag.ID,
+		SessionID:       flag.SessionID,
+		UserID:          flag.UserID,
+		Nickname:        flag.User.Nickname,
+		Rules:           flag.Rules,
+		Details:         flag.Details,
+		Status:          flag.Status,
+		XPRevoked:       flag.XPRevoked,
+		StartTime:       flag.Session.StartTime,
+		EndTime:         flag.Session.EndTime,
+		DurationMinutes: flag.Session.DurationMinutes,
+		ReviewerID:      flag.ReviewerID,
+		ReviewNote:      flag.ReviewNote,
+		ReviewedAt:      flag.ReviewedAt,
+		CreatedAt:       flag.CreatedAt,
+	}
+}
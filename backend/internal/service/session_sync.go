@@ -0,0 +1,383 @@
+package service
+
+import (
+	"backend/internal/dto"
+	"backend/internal/model"
+	"backend/pkg/database"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	syncMaxGap       = 3 * time.Minute // 相邻两次心跳的最大间隔，与心跳 Key 的 TTL 一致
+	syncMaxExtension = 12 * time.Hour  // 一次同步最多把会话延长多久
+	syncClockSkew    = 1 * time.Minute // 容忍的客户端时钟偏差
+	syncReopenWindow = syncMaxGap      // 最后一次心跳距今不超过该值时，视为客户端仍在计时，重新打开会话
+)
+
+// 同步结果
+const (
+	SyncActionNone     = "none"
+	SyncActionExtended = "extended"
+	SyncActionReopened = "reopened"
+	SyncActionEnded    = "ended"
+)
+
+// newSyncKey 生成会话的离线同步签名密钥
+func newSyncKey() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// syncPayload 参与签名的规范化内容，格式见 dto.SessionSyncRequest
+func syncPayload(sessionID string, req dto.SessionSyncRequest) string {
+	beats := make([]string, len(req.Heartbeats))
+	for i, h := range req.Heartbeats {
+		beats[i] = strconv.FormatInt(h, 10)
+	}
+	endedAt := ""
+	if req.EndedAt != nil {
+		endedAt = strconv.FormatInt(*req.EndedAt, 10)
+	}
+	return strings.Join([]string{sessionID, req.BatchID, strings.Join(beats, ","), endedAt}, "|")
+}
+
+// verifySyncSignature 校验客户端签名，防止伪造离线心跳刷时长
+func verifySyncSignature(key, sessionID string, req dto.SessionSyncRequest) bool {
+	if key == "" {
+		return false
+	}
+	sig, err := hex.DecodeString(req.Signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(syncPayload(sessionID, req)))
+	return hmac.Equal(sig, mac.Sum(nil))
+}
+
+// SyncSession 处理客户端离线期间的心跳批次 (幂等)
+//   - 会话仍在进行：刷新心跳；若客户端离线期间已结束，则按结束时间收尾
+//   - 会话已被 Reaper 回收 (包括回收后已被同步延长过的)：沿心跳链 (相邻间隔 <= 3 分钟) 找到真正的最后活跃时间，
+//     客户端仍在计时则重新打开会话，否则把结束时间延长到该时刻，并按差值修正 DailyStat / Tag XP / 排行榜
+//   - 用户自己结束的会话不接受同步
+func (s *StudyService) SyncSession(userID, sessionID string, req dto.SessionSyncRequest) (*dto.SessionSyncResponse, error) {
+	var session model.StudySession
+	if err := database.DB.Preload("Pauses").Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
+		return nil, errors.New("session not found")
+	}
+	if !verifySyncSignature(session.SyncKey, session.ID, req) {
+		return nil, errors.New("invalid sync signature")
+	}
+
+	// 幂等：同一批次只处理一次
+	var existing model.SessionSyncBatch
+	if err := database.DB.Where("session_id = ? AND batch_id = ?", sessionID, req.BatchID).First(&existing).Error; err == nil {
+		return &dto.SessionSyncResponse{
+			Action:       existing.Action,
+			DeltaMinutes: existing.DeltaMinutes,
+			Duplicate:    true,
+			Session:      s.ToSessionResponse(&session),
+		}, nil
+	}
+
+	now := time.Now()
+	beats := normalizeHeartbeats(req.Heartbeats, session.StartTime, now)
+
+	var endedAt *time.Time
+	if req.EndedAt != nil {
+		t := time.Unix(*req.EndedAt, 0)
+		if t.After(now) {
+			t = now
+		}
+		endedAt = &t
+	}
+
+	action := SyncActionNone
+	delta := 0
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// 批次先落库占位，并发重试时由唯一索引拦下
+		batch := model.SessionSyncBatch{
+			SessionID:      sessionID,
+			BatchID:        req.BatchID,
+			UserID:         userID,
+			Action:         SyncActionNone,
+			HeartbeatCount: len(beats),
+		}
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&batch)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errors.New("sync batch is already being processed")
+		}
+
+		var err error
+		switch {
+		case session.EndTime == nil:
+			action, delta, err = s.syncActiveSession(tx, &session, endedAt)
+		case session.ReapedEndTime != nil &&
+			(session.EndReason == model.SessionEndReasonReaped || session.EndReason == model.SessionEndReasonSynced):
+			// 回收后已被同步延长过的会话，后续批次仍可在同一上限内继续延长
+			action, delta, err = s.syncReapedSession(tx, &session, beats, endedAt, now)
+		default:
+			return errors.New("session is already ended")
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&batch).Updates(map[string]interface{}{"action": action, "delta_minutes": delta}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	key := fmt.Sprintf("study:heartbeat:%s", session.ID)
+	if session.EndTime == nil {
+		database.RDB.Set(ctx, key, now.Unix(), 3*time.Minute)
+	} else {
+		database.RDB.Del(ctx, key)
+	}
+
+	// 离线期间结束了番茄钟中的某个阶段，视为整个计划结束
+	if action == SyncActionEnded && session.PomodoroID != nil {
+		s.stopPomodoro(*session.PomodoroID, model.PomodoroStatusCompleted)
+	}
+	if delta != 0 {
+		(&AchievementService{}).EvaluateAsync(userID, AchievementTriggerSession)
+		(&QuestService{}).RefreshAsync(userID, QuestTriggerSession)
+		(&ChallengeService{}).PublishProgressAsync(userID)
+	}
+
+	return &dto.SessionSyncResponse{
+		Action:       action,
+		DeltaMinutes: delta,
+		Session:      s.ToSessionResponse(&session),
+	}, nil
+}
+
+// normalizeHeartbeats 去重排序，丢弃会话开始之前或明显来自未来的心跳
+func normalizeHeartbeats(raw []int64, start, now time.Time) []time.Time {
+	sorted := append([]int64(nil), raw...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	beats := make([]time.Time, 0, len(sorted))
+	for i, ts := range sorted {
+		if i > 0 && ts == sorted[i-1] {
+			continue
+		}
+		t := time.Unix(ts, 0)
+		if t.Before(start) || t.After(now.Add(syncClockSkew)) {
+			continue
+		}
+		if t.After(now) {
+			t = now
+		}
+		beats = append(beats, t)
+	}
+	return beats
+}
+
+// lastAliveAt 从 anchor 开始沿心跳链向后走，相邻间隔超过 syncMaxGap 即视为断开，返回最后一次有效心跳
+func lastAliveAt(anchor time.Time, beats []time.Time) time.Time {
+	last := anchor
+	for _, b := range beats {
+		if !b.After(last) {
+			continue
+		}
+		if b.Sub(last) > syncMaxGap {
+			break
+		}
+		last = b
+	}
+	return last
+}
+
+// syncActiveSession 会话仍在进行：只需处理客户端离线期间点了结束的情况
+func (s *StudyService) syncActiveSession(tx *gorm.DB, session *model.StudySession, endedAt *time.Time) (string, int, error) {
+	if endedAt == nil {
+		return SyncActionNone, 0, nil
+	}
+
+	end := *endedAt
+	if end.Before(session.StartTime) {
+		end = session.StartTime
+	}
+	if err := s.finishSessionTx(tx, session, end, model.SessionEndReasonSynced); err != nil {
+		return "", 0, err
+	}
+	return SyncActionEnded, *session.DurationMinutes, nil
+}
+
+// syncReapedSession 会话已被回收：重新打开或延长结束时间，并修正统计
+func (s *StudyService) syncReapedSession(tx *gorm.DB, session *model.StudySession, beats []time.Time, endedAt *time.Time, now time.Time) (string, int, error) {
+	oldEnd := *session.EndTime
+	oldSpans := focusSpans(session.StartTime, oldEnd, session.Pauses)
+
+	alive := lastAliveAt(oldEnd, beats)
+	newEnd := alive
+	if endedAt != nil && endedAt.After(alive) && endedAt.Sub(alive) <= syncMaxGap {
+		newEnd = *endedAt
+	}
+	// 多个批次累计的延长不超过 syncMaxExtension，以回收时的结束时间为基准
+	limit := session.ReapedEndTime.Add(syncMaxExtension)
+	if newEnd.After(limit) {
+		newEnd = limit
+	}
+
+	// 回收后用户又开始了新的会话：延长不能越过下一个会话的开始时间，也不能重新打开
+	var next model.StudySession
+	hasNext := tx.Select("start_time").
+		Where("user_id = ? AND id <> ? AND start_time > ?", session.UserID, session.ID, session.StartTime).
+		Order("start_time ASC").Limit(1).Find(&next).RowsAffected > 0
+	if hasNext && newEnd.After(next.StartTime) {
+		newEnd = next.StartTime
+	}
+
+	// 客户端仍在计时，且用户当前没有其他进行中的会话：重新打开
+	reopen := !hasNext && endedAt == nil && now.Sub(alive) <= syncReopenWindow && newEnd.Equal(alive)
+	if reopen {
+		var activeCount int64
+		tx.Model(&model.StudySession{}).Where("user_id = ? AND end_time IS NULL", session.UserID).Count(&activeCount)
+		reopen = activeCount == 0
+	}
+
+	if !reopen && !newEnd.After(oldEnd) {
+		return SyncActionNone, 0, nil
+	}
+
+	// 回收时处于暂停中的会话 (暂停被 Reaper 在 oldEnd 关闭)，离线期间仍视为暂停
+	for i := range session.Pauses {
+		p := &session.Pauses[i]
+		if p.ResumedAt == nil || !p.ResumedAt.Equal(oldEnd) {
+			continue
+		}
+		if reopen {
+			p.ResumedAt = nil
+		} else {
+			p.ResumedAt = &newEnd
+		}
+		if err := tx.Model(p).Update("resumed_at", p.ResumedAt).Error; err != nil {
+			return "", 0, err
+		}
+	}
+
+	oldMinutes := spansMinutes(oldSpans)
+
+	if reopen {
+		session.EndTime = nil
+		session.DurationMinutes = nil
+		session.EndReason = ""
+		if err := tx.Model(session).Select("end_time", "duration_minutes", "end_reason").Updates(session).Error; err != nil {
+			return "", 0, err
+		}
+		// 撤销回收时记入的时长，会话再次结束时会完整重新计入
+		if err := s.adjustCredit(tx, session, oldSpans, oldEnd, nil, time.Time{}); err != nil {
+			return "", 0, err
+		}
+		current := netFocusMinutes(session, now)
+		return SyncActionReopened, current - oldMinutes, nil
+	}
+
+	newSpans := focusSpans(session.StartTime, newEnd, session.Pauses)
+	duration := spansMinutes(newSpans)
+	session.EndTime = &newEnd
+	session.DurationMinutes = &duration
+	session.EndReason = model.SessionEndReasonSynced
+	if err := tx.Model(session).Select("end_time", "duration_minutes", "end_reason").Updates(session).Error; err != nil {
+		return "", 0, err
+	}
+	if err := s.adjustCredit(tx, session, oldSpans, oldEnd, newSpans, newEnd); err != nil {
+		return "", 0, err
+	}
+	return SyncActionExtended, duration - oldMinutes, nil
+}
+
+// adjustCredit 会话时长变化后按差值修正 DailyStat / Tag 统计 / 经验 / 排行榜
+// newSpans 为空表示撤销全部已记入的时长 (会话被重新打开)
+func (s *StudyService) adjustCredit(tx *gorm.DB, session *model.StudySession, oldSpans []timeSpan, oldEnd time.Time, newSpans []timeSpan, newEnd time.Time) error {
+	if session.Type == model.SessionTypeRest {
+		return nil
+	}
+
+	loc := userLocation(tx, session.UserID)
+	newDays := splitSpansByDay(newSpans, loc)
+	diff := make(map[time.Time]int)
+	for _, day := range splitSpansByDay(oldSpans, loc) {
+		diff[day.Date] -= day.Minutes
+	}
+	for _, day := range newDays {
+		diff[day.Date] += day.Minutes
+	}
+
+	days := make([]dayMinutes, 0, len(diff))
+	for date, minutes := range diff {
+		days = append(days, dayMinutes{Date: date, Minutes: minutes})
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Date.Before(days[j].Date) })
+
+	if err := s.updateDailyStats(tx, session.UserID, days); err != nil {
+		return fmt.Errorf("failed to update daily stats: %v", err)
+	}
+
+	oldMinutes := spansMinutes(oldSpans)
+	newMinutes := spansMinutes(newSpans)
+	if session.TagID != nil {
+		if err := s.updateUserTagStats(tx, session.UserID, *session.TagID, newMinutes-oldMinutes); err != nil {
+			return fmt.Errorf("failed to update tag stats: %v", err)
+		}
+	}
+
+	// 经验：撤销该会话之前的流水，再按新的时长重新发放 (审核时已撤销经验的会话不再发放)
+	xpService := &XPService{}
+	if _, err := xpService.Reverse(tx, session.UserID, []model.XPSource{model.XPSourceStudy}, session.ID, nil); err != nil {
+		return fmt.Errorf("failed to reverse xp: %v", err)
+	}
+	if !xpRevokedFor(tx, session) {
+		if _, err := s.grantStudyXP(tx, session, newDays); err != nil {
+			return fmt.Errorf("failed to grant xp: %v", err)
+		}
+	}
+
+	// 延长后的会话重新执行反作弊检查
+	wasHeld := isHeldFromRankings(session)
+	held, err := s.screenSession(tx, session, newDays, newMinutes)
+	if err != nil {
+		return fmt.Errorf("failed to screen session: %v", err)
+	}
+	if session.IsManual && !ManualSessionInRankings {
+		return nil
+	}
+
+	// 排行榜按结束时间划分周期，先从旧的周期榜单扣除，再加到新的周期榜单
+	ctx := context.Background()
+	if !wasHeld {
+		if err := s.updateRankings(ctx, session.UserID, session.TagID, -oldMinutes, oldEnd); err != nil {
+			return fmt.Errorf("failed to update rankings: %v", err)
+		}
+	}
+	if newMinutes > 0 && !held {
+		if err := s.updateRankings(ctx, session.UserID, session.TagID, newMinutes, newEnd); err != nil {
+			return fmt.Errorf("failed to update rankings: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"backend/internal/dto"
+	"backend/internal/model"
+	"backend/pkg/database"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// createSession 直接插入一条会话，end 为空表示仍在进行
+func createSession(t *testing.T, userID string, start time.Time, end *time.Time, reason model.SessionEndReason) *model.StudySession {
+	t.Helper()
+	session := model.StudySession{
+		UserID:    userID,
+		StartTime: start,
+		EndTime:   end,
+		Type:      model.SessionTypeLearning,
+		EndReason: reason,
+		SyncKey:   newSyncKey(),
+	}
+	if end != nil {
+		duration := int(end.Sub(start).Minutes())
+		session.DurationMinutes = &duration
+		if reason == model.SessionEndReasonReaped {
+			session.ReapedEndTime = end
+		}
+	}
+	if err := database.DB.Create(&session).Error; err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	t.Cleanup(func() {
+		database.DB.Where("session_id = ?", session.ID).Delete(&model.SessionSyncBatch{})
+		database.DB.Delete(&model.StudySession{}, "id = ?", session.ID)
+	})
+	return &session
+}
+
+// signedSync 构造带签名的同步请求，心跳从 from 开始每 2 分钟一次直到 to
+func signedSync(session *model.StudySession, batchID string, from, to time.Time) dto.SessionSyncRequest {
+	req := dto.SessionSyncRequest{BatchID: batchID}
+	for t := from; !t.After(to); t = t.Add(2 * time.Minute) {
+		req.Heartbeats = append(req.Heartbeats, t.Unix())
+	}
+	mac := hmac.New(sha256.New, []byte(session.SyncKey))
+	mac.Write([]byte(syncPayload(session.ID, req)))
+	req.Signature = hex.EncodeToString(mac.Sum(nil))
+	return req
+}
+
+// TestSyncReapedSessionStopsAtNextSession 回收后用户开始了新会话，离线心跳不能把旧会话延长到新会话里
+func TestSyncReapedSessionStopsAtNextSession(t *testing.T) {
+	setupDB(t)
+	setupRedis(t)
+
+	now := time.Now().Truncate(time.Second)
+	reapedAt := now.Add(-90 * time.Minute)
+
+	tests := []struct {
+		name       string
+		nextStart  time.Time
+		wantAction string
+		wantEnd    time.Time
+	}{
+		{name: "clamped to next session", nextStart: now.Add(-60 * time.Minute), wantAction: SyncActionExtended, wantEnd: now.Add(-60 * time.Minute)},
+		{name: "next session started before reap", nextStart: reapedAt.Add(-time.Minute), wantAction: SyncActionNone, wantEnd: reapedAt},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := createUser(t, fmt.Sprintf("sync%d", i))
+			reaped := createSession(t, userID, now.Add(-2*time.Hour), &reapedAt, model.SessionEndReasonReaped)
+			nextEnd := now.Add(-10 * time.Minute)
+			createSession(t, userID, tt.nextStart, &nextEnd, model.SessionEndReasonUser)
+
+			// 离线期间的心跳一直持续到 20 分钟前，与下一个会话重叠
+			req := signedSync(reaped, "batch-1", reapedAt, now.Add(-20*time.Minute))
+			resp, err := (&StudyService{}).SyncSession(userID, reaped.ID, req)
+			if err != nil {
+				t.Fatalf("sync: %v", err)
+			}
+			if resp.Action != tt.wantAction {
+				t.Fatalf("expected action %q, got %q", tt.wantAction, resp.Action)
+			}
+
+			var got model.StudySession
+			if err := database.DB.First(&got, "id = ?", reaped.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if got.EndTime == nil || !got.EndTime.Equal(tt.wantEnd) {
+				t.Fatalf("expected end %v, got %v", tt.wantEnd, got.EndTime)
+			}
+			wantDelta := int(tt.wantEnd.Sub(reapedAt).Minutes())
+			if resp.DeltaMinutes != wantDelta {
+				t.Fatalf("expected delta %d, got %d", wantDelta, resp.DeltaMinutes)
+			}
+		})
+	}
+}
+
+// TestSyncExtendsAlreadySyncedSession 已被同步延长过的会话，后续批次可以继续延长，累计不超过上限
+func TestSyncExtendsAlreadySyncedSession(t *testing.T) {
+	setupDB(t)
+	setupRedis(t)
+
+	now := time.Now().Truncate(time.Second)
+	reapedAt := now.Add(-90 * time.Minute)
+	userID := createUser(t, "synced")
+	session := createSession(t, userID, now.Add(-2*time.Hour), &reapedAt, model.SessionEndReasonReaped)
+
+	s := &StudyService{}
+	first := now.Add(-60 * time.Minute)
+	if _, err := s.SyncSession(userID, session.ID, signedSync(session, "batch-1", reapedAt, first)); err != nil {
+		t.Fatalf("first batch: %v", err)
+	}
+
+	second := now.Add(-30 * time.Minute)
+	resp, err := s.SyncSession(userID, session.ID, signedSync(session, "batch-2", first, second))
+	if err != nil {
+		t.Fatalf("second batch: %v", err)
+	}
+	if resp.Action != SyncActionExtended || resp.DeltaMinutes != 30 {
+		t.Fatalf("expected extended by 30 minutes, got %q %d", resp.Action, resp.DeltaMinutes)
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"backend/internal/dto"
+	"backend/internal/model"
+	"backend/pkg/database"
+	"errors"
+	"sort"
+)
+
+// GetSkillTree 用户的技能树
+// 标签通过 ParentID 组成层级，子标签的经验和分钟数累加到所有祖先标签上，
+// 用户没有直接学习过的祖先标签也会出现在树中 (自身 XP 为 0)
+func (s *TagService) GetSkillTree(userID string) (*dto.SkillTreeResponse, error) {
+	var user model.User
+	if err := database.DB.Select("id").First(&user, "id = ?", userID).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	var stats []model.UserTagStat
+	if err := database.DB.Preload("Tag").Where("user_id = ?", userID).Find(&stats).Error; err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string]model.Tag, len(stats))
+	ownXP := make(map[string]int, len(stats))
+	ownMinutes := make(map[string]int, len(stats))
+	for _, stat := range stats {
+		tags[stat.TagID] = stat.Tag
+		ownXP[stat.TagID] += stat.XP
+		ownMinutes[stat.TagID] += stat.TotalMinutes
+	}
+
+	// 1. 逐层补齐祖先标签
+	for {
+		var missing []string
+		for _, tag := range tags {
+			if tag.ParentID == nil {
+				continue
+			}
+			if _, ok := tags[*tag.ParentID]; !ok {
+				missing = append(missing, *tag.ParentID)
+			}
+		}
+		if len(missing) == 0 {
+			break
+		}
+
+		var parents []model.Tag
+		if err := database.DB.Where("id IN ?", missing).Find(&parents).Error; err != nil {
+			return nil, err
+		}
+		if len(parents) == 0 {
+			break // 父标签已被删除
+		}
+		for _, p := range parents {
+			tags[p.ID] = p
+		}
+	}
+
+	// 2. 建立父子关系，父标签不存在的作为顶层
+	children := make(map[string][]string)
+	var roots []string
+	for id, tag := range tags {
+		if tag.ParentID != nil {
+			if _, ok := tags[*tag.ParentID]; ok {
+				children[*tag.ParentID] = append(children[*tag.ParentID], id)
+				continue
+			}
+		}
+		roots = append(roots, id)
+	}
+
+	levelService := &LevelService{}
+	visited := make(map[string]bool, len(tags))
+
+	var build func(id string) dto.SkillNode
+	build = func(id string) dto.SkillNode {
+		visited[id] = true
+		node := dto.SkillNode{
+			TagID:        id,
+			TagName:      tags[id].Name,
+			OwnXP:        ownXP[id],
+			XP:           ownXP[id],
+			TotalMinutes: ownMinutes[id],
+			Children:     []dto.SkillNode{},
+		}
+		for _, childID := range children[id] {
+			if visited[childID] {
+				continue
+			}
+			child := build(childID)
+			node.XP += child.XP
+			node.TotalMinutes += child.TotalMinutes
+			node.Children = append(node.Children, child)
+		}
+		sortSkillNodes(node.Children)
+		node.Level = levelService.CalculateTagLevel(node.XP)
+		return node
+	}
+
+	skills := make([]dto.SkillNode, 0, len(roots))
+	for _, id := range roots {
+		skills = append(skills, build(id))
+	}
+	// 数据异常形成环时，环上的标签没有顶层祖先，直接作为顶层展示
+	for id := range tags {
+		if !visited[id] {
+			skills = append(skills, build(id))
+		}
+	}
+	sortSkillNodes(skills)
+
+	return &dto.SkillTreeResponse{UserID: userID, Skills: skills}, nil
+}
+
+// sortSkillNodes 按 XP 降序，相同时按名称排序保证输出稳定
+func sortSkillNodes(nodes []dto.SkillNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].XP != nodes[j].XP {
+			return nodes[i].XP > nodes[j].XP
+		}
+		return nodes[i].TagName < nodes[j].TagName
+	})
+}
@@ -0,0 +1,439 @@
+package service
+
+import (
+	"backend/internal/dto"
+	"backend/internal/model"
+	"backend/pkg/database"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	MaxFreezeTokens    = 3 // 冻结卡持有上限
+	freezeEarnInterval = 7 // 每连续达标 7 天奖励一张冻结卡
+	freezeLookbackDays = 7 // 冻结卡最多补签到 7 天前
+)
+
+type StreakService struct{}
+
+// frozenDates 查询用户在 [from, to] 内使用过冻结卡的日期
+func frozenDates(db *gorm.DB, userID string, from, to time.Time) (map[time.Time]bool, error) {
+	var freezes []model.StreakFreeze
+	if err := db.Where("user_id = ? AND date BETWEEN ? AND ?", userID, from, to).Find(&freezes).Error; err != nil {
+		return nil, err
+	}
+	frozen := make(map[time.Time]bool, len(freezes))
+	for _, f := range freezes {
+		frozen[dateOnly(f.Date)] = true
+	}
+	return frozen, nil
+}
+
+// dateOnly 把数据库读出的 date 归一化为 UTC 0 点，便于作为 map key 比较
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// bridged 判断 last 与 next 之间 (不含两端) 的每一天是否都用了冻结卡，返回是否连得上以及冻结天数
+func bridged(last, next time.Time, frozen map[time.Time]bool) (bool, int) {
+	days := 0
+	for d := last.AddDate(0, 0, 1); d.Before(next); d = d.AddDate(0, 0, 1) {
+		if !frozen[d] {
+			return false, 0
+		}
+		days++
+	}
+	return true, days
+}
+
+// lockStreak 在事务中获取并锁定用户的连续记录
+// 记录不存在时 (新用户或功能上线前的老用户) 先根据已有 DailyStat 全量计算一次，created 为 true
+func (s *StreakService) lockStreak(tx *gorm.DB, userID string) (streak *model.UserStreak, created bool, err error) {
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.UserStreak{UserID: userID})
+	if res.Error != nil {
+		return nil, false, res.Error
+	}
+
+	var st model.UserStreak
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&st, "user_id = ?", userID).Error; err != nil {
+		return nil, false, err
+	}
+
+	if res.RowsAffected > 0 {
+		if err := s.recompute(tx, &st); err != nil {
+			return nil, false, err
+		}
+		return &st, true, nil
+	}
+	return &st, false, nil
+}
+
+// recompute 根据全部 DailyStat 与冻结记录重建连续记录和历史
+// 用于补录过去日期、修改每日门槛、使用冻结卡等无法增量处理的场景 (不改变冻结卡数量)
+func (s *StreakService) recompute(tx *gorm.DB, streak *model.UserStreak) error {
+	var dates []time.Time
+	if err := tx.Model(&model.DailyStat{}).
+		Where("user_id = ? AND total_minutes >= ?", streak.UserID, streak.DailyMinimum).
+		Order("date ASC").
+		Pluck("date", &dates).Error; err != nil {
+		return err
+	}
+
+	var freezes []model.StreakFreeze
+	if err := tx.Where("user_id = ?", streak.UserID).Find(&freezes).Error; err != nil {
+		return err
+	}
+	frozen := make(map[time.Time]bool, len(freezes))
+	for _, f := range freezes {
+		frozen[dateOnly(f.Date)] = true
+	}
+
+	// 按日期顺序切分出每一段连续记录，最后一段作为当前记录
+	var periods []model.StreakPeriod
+	var cur *model.StreakPeriod
+	longest := 0
+	for _, d := range dates {
+		d = dateOnly(d)
+		if cur != nil {
+			if ok, frozenDays := bridged(cur.EndDate, d, frozen); ok {
+				cur.EndDate = d
+				cur.Length++
+				cur.FrozenDays += frozenDays
+				continue
+			}
+			periods = append(periods, *cur)
+		}
+		cur = &model.StreakPeriod{UserID: streak.UserID, StartDate: d, EndDate: d, Length: 1}
+	}
+
+	for _, p := range periods {
+		if p.Length > longest {
+			longest = p.Length
+		}
+	}
+
+	streak.CurrentStreak = 0
+	streak.CurrentStart = nil
+	streak.LastActiveDate = nil
+	if cur != nil {
+		streak.CurrentStreak = cur.Length
+		streak.CurrentStart = &cur.StartDate
+		streak.LastActiveDate = &cur.EndDate
+		if cur.Length > longest {
+			longest = cur.Length
+		}
+	}
+	streak.LongestStreak = longest
+
+	if err := tx.Where("user_id = ?", streak.UserID).Delete(&model.StreakPeriod{}).Error; err != nil {
+		return err
+	}
+	if len(periods) > 0 {
+		if err := tx.Create(&periods).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(streak).
+		Select("current_streak", "longest_streak", "current_start", "last_active_date").
+		Updates(streak).Error
+}
+
+// onDailyStatChanged 某天的学习分钟数增加后增量更新连续记录 (在 updateDailyStats 的事务中调用)
+func (s *StreakService) onDailyStatChanged(tx *gorm.DB, userID string, date time.Time) error {
+	streak, created, err := s.lockStreak(tx, userID)
+	if err != nil || created {
+		return err // 新建时已经全量计算过
+	}
+
+	var minutes int
+	tx.Model(&model.DailyStat{}).Select("total_minutes").
+		Where("user_id = ? AND date = ?", userID, date).
+		Scan(&minutes)
+	if minutes < streak.DailyMinimum {
+		return nil
+	}
+
+	if streak.LastActiveDate != nil {
+		last := dateOnly(*streak.LastActiveDate)
+		if date.Equal(last) {
+			return nil
+		}
+		// 补录了更早的日期，可能把两段记录连起来，直接全量重算
+		if date.Before(last) {
+			return s.recompute(tx, streak)
+		}
+
+		frozen, err := frozenDates(tx, userID, last, date)
+		if err != nil {
+			return err
+		}
+		if ok, _ := bridged(last, date, frozen); !ok {
+			// 断签：把上一段归档到历史，从今天重新开始
+			if err := s.archiveCurrent(tx, streak); err != nil {
+				return err
+			}
+			streak.CurrentStreak = 0
+			streak.CurrentStart = nil
+		}
+	}
+
+	streak.CurrentStreak++
+	if streak.CurrentStart == nil {
+		streak.CurrentStart = &date
+	}
+	streak.LastActiveDate = &date
+	if streak.CurrentStreak > streak.LongestStreak {
+		streak.LongestStreak = streak.CurrentStreak
+	}
+	if streak.CurrentStreak%freezeEarnInterval == 0 && streak.FreezeTokens < MaxFreezeTokens {
+		streak.FreezeTokens++
+	}
+
+	return tx.Model(streak).
+		Select("current_streak", "longest_streak", "current_start", "last_active_date", "freeze_tokens").
+		Updates(streak).Error
+}
+
+// archiveCurrent 把当前连续记录写入历史
+func (s *StreakService) archiveCurrent(tx *gorm.DB, streak *model.UserStreak) error {
+	if streak.CurrentStreak == 0 || streak.CurrentStart == nil || streak.LastActiveDate == nil {
+		return nil
+	}
+
+	var frozenDays int64
+	tx.Model(&model.StreakFreeze{}).
+		Where("user_id = ? AND date BETWEEN ? AND ?", streak.UserID, *streak.CurrentStart, *streak.LastActiveDate).
+		Count(&frozenDays)
+
+	return tx.Create(&model.StreakPeriod{
+		UserID:     streak.UserID,
+		StartDate:  *streak.CurrentStart,
+		EndDate:    *streak.LastActiveDate,
+		Length:     streak.CurrentStreak,
+		FrozenDays: int(frozenDays),
+	}).Error
+}
+
+// isAlive 当前连续记录截至今天是否仍然有效：最近达标日到今天之间的每一天都用了冻结卡
+// (今天还没学不算断签)
+func (s *StreakService) isAlive(db *gorm.DB, streak *model.UserStreak, today time.Time) (bool, error) {
+	if streak.LastActiveDate == nil || streak.CurrentStreak == 0 {
+		return false, nil
+	}
+	last := dateOnly(*streak.LastActiveDate)
+	if !last.Before(today.AddDate(0, 0, -1)) {
+		return true, nil
+	}
+
+	frozen, err := frozenDates(db, streak.UserID, last, today)
+	if err != nil {
+		return false, err
+	}
+	ok, _ := bridged(last, today, frozen)
+	return ok, nil
+}
+
+// loadStreak 读取用户的连续记录，只读不加锁
+// 记录不存在时才进入事务加锁并全量计算一次
+func (s *StreakService) loadStreak(userID string) (*model.UserStreak, error) {
+	var streak model.UserStreak
+	err := database.DB.First(&streak, "user_id = ?", userID).Error
+	if err == nil {
+		return &streak, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var created *model.UserStreak
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		created, _, err = s.lockStreak(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetStreak 获取用户的连续打卡信息
+func (s *StreakService) GetStreak(userID string) (*dto.StreakResponse, error) {
+	streak, err := s.loadStreak(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.toStreakResponse(database.DB, streak)
+}
+
+// toStreakResponse 模型转 DTO，已经断签的记录当前天数显示为 0
+func (s *StreakService) toStreakResponse(db *gorm.DB, streak *model.UserStreak) (*dto.StreakResponse, error) {
+	today := localDate(time.Now(), userLocation(db, streak.UserID))
+
+	alive, err := s.isAlive(db, streak, today)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.StreakResponse{
+		LongestStreak:   streak.LongestStreak,
+		FreezeTokens:    streak.FreezeTokens,
+		MaxFreezeTokens: MaxFreezeTokens,
+		DailyMinimum:    streak.DailyMinimum,
+	}
+	if alive {
+		resp.CurrentStreak = streak.CurrentStreak
+		start := streak.CurrentStart.Format("2006-01-02")
+		resp.CurrentStart = &start
+	}
+	if streak.LastActiveDate != nil {
+		last := streak.LastActiveDate.Format("2006-01-02")
+		resp.LastActiveDate = &last
+		resp.TodayCompleted = dateOnly(*streak.LastActiveDate).Equal(today)
+	}
+	return resp, nil
+}
+
+// GetStreakHistory 获取历史连续记录 (按开始日期倒序，包含当前这一段)
+func (s *StreakService) GetStreakHistory(userID string) ([]dto.StreakPeriodResponse, error) {
+	items := make([]dto.StreakPeriodResponse, 0)
+
+	streak, err := s.loadStreak(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if streak.CurrentStreak > 0 && streak.CurrentStart != nil && streak.LastActiveDate != nil {
+		today := localDate(time.Now(), userLocation(database.DB, userID))
+		alive, err := s.isAlive(database.DB, streak, today)
+		if err != nil {
+			return nil, err
+		}
+
+		var frozenDays int64
+		database.DB.Model(&model.StreakFreeze{}).
+			Where("user_id = ? AND date BETWEEN ? AND ?", userID, *streak.CurrentStart, *streak.LastActiveDate).
+			Count(&frozenDays)
+
+		items = append(items, dto.StreakPeriodResponse{
+			StartDate:  streak.CurrentStart.Format("2006-01-02"),
+			EndDate:    streak.LastActiveDate.Format("2006-01-02"),
+			Length:     streak.CurrentStreak,
+			FrozenDays: int(frozenDays),
+			Ongoing:    alive,
+		})
+	}
+
+	var periods []model.StreakPeriod
+	if err := database.DB.Where("user_id = ?", userID).Order("start_date DESC").Find(&periods).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range periods {
+		items = append(items, dto.StreakPeriodResponse{
+			StartDate:  p.StartDate.Format("2006-01-02"),
+			EndDate:    p.EndDate.Format("2006-01-02"),
+			Length:     p.Length,
+			FrozenDays: p.FrozenDays,
+		})
+	}
+	return items, nil
+}
+
+// UseFreeze 使用一张冻结卡补上某个未达标的日期 (默认昨天)
+func (s *StreakService) UseFreeze(userID string, req dto.UseStreakFreezeRequest) (*dto.StreakResponse, error) {
+	today := localDate(time.Now(), userLocation(database.DB, userID))
+
+	date := today.AddDate(0, 0, -1)
+	if req.Date != "" {
+		d, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			return nil, errors.New("invalid date format, expected YYYY-MM-DD")
+		}
+		date = d
+	}
+	if !date.Before(today) || date.Before(today.AddDate(0, 0, -freezeLookbackDays)) {
+		return nil, errors.New("freeze date must be within the last 7 days")
+	}
+
+	var resp *dto.StreakResponse
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		streak, _, err := s.lockStreak(tx, userID)
+		if err != nil {
+			return err
+		}
+		if streak.FreezeTokens <= 0 {
+			return errors.New("no streak freeze available")
+		}
+
+		var minutes int
+		tx.Model(&model.DailyStat{}).Select("total_minutes").
+			Where("user_id = ? AND date = ?", userID, date).
+			Scan(&minutes)
+		if minutes >= streak.DailyMinimum {
+			return errors.New("this day already counts towards your streak")
+		}
+
+		var count int64
+		tx.Model(&model.StreakFreeze{}).Where("user_id = ? AND date = ?", userID, date).Count(&count)
+		if count > 0 {
+			return errors.New("this day is already frozen")
+		}
+
+		if err := tx.Create(&model.StreakFreeze{UserID: userID, Date: date}).Error; err != nil {
+			return err
+		}
+		streak.FreezeTokens--
+		if err := tx.Model(streak).Update("freeze_tokens", streak.FreezeTokens).Error; err != nil {
+			return err
+		}
+
+		// 冻结日可能把前后两段连起来
+		if err := s.recompute(tx, streak); err != nil {
+			return err
+		}
+		resp, err = s.toStreakResponse(tx, streak)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// UpdateSettings 修改每日达标门槛，并按新门槛重算
+func (s *StreakService) UpdateSettings(userID string, req dto.UpdateStreakSettingsRequest) (*dto.StreakResponse, error) {
+	var resp *dto.StreakResponse
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		streak, _, err := s.lockStreak(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		streak.DailyMinimum = req.DailyMinimum
+		if err := tx.Model(streak).Update("daily_minimum", streak.DailyMinimum).Error; err != nil {
+			return err
+		}
+		if err := s.recompute(tx, streak); err != nil {
+			return err
+		}
+		resp, err = s.toStreakResponse(tx, streak)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// RecomputeStreak 全量重算用户的连续记录 (DailyStat 被批量改写后调用)
+func (s *StreakService) RecomputeStreak(tx *gorm.DB, userID string) error {
+	streak, created, err := s.lockStreak(tx, userID)
+	if err != nil || created {
+		return err
+	}
+	return s.recompute(tx, streak)
+}
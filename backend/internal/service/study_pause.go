@@ -0,0 +1,251 @@
+package service
+
+import (
+	"backend/internal/dto"
+	"backend/internal/model"
+	"backend/pkg/database"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxPauseDuration 暂停期间不要求心跳，超过该时长仍未恢复的会话交给 Reaper 结束
+const maxPauseDuration = 2 * time.Hour
+
+// timeSpan 一段连续的专注区间 [Start, End)
+type timeSpan struct {
+	Start time.Time
+	End   time.Time
+}
+
+// focusSpans 从 [start, end) 中扣除所有暂停区间，返回按时间排序的专注区间
+// 未恢复的暂停视为一直持续到 end
+func focusSpans(start, end time.Time, pauses []model.StudySessionPause) []timeSpan {
+	if !end.After(start) {
+		return nil
+	}
+
+	spans := []timeSpan{{Start: start, End: end}}
+	for _, p := range pauses {
+		pStart := p.PausedAt
+		pEnd := end
+		if p.ResumedAt != nil && p.ResumedAt.Before(end) {
+			pEnd = *p.ResumedAt
+		}
+		if !pEnd.After(pStart) {
+			continue
+		}
+
+		// 从现有区间中挖掉 [pStart, pEnd)
+		next := make([]timeSpan, 0, len(spans)+1)
+		for _, sp := range spans {
+			if !pEnd.After(sp.Start) || !sp.End.After(pStart) {
+				next = append(next, sp) // 无交集
+				continue
+			}
+			if pStart.After(sp.Start) {
+				next = append(next, timeSpan{Start: sp.Start, End: pStart})
+			}
+			if sp.End.After(pEnd) {
+				next = append(next, timeSpan{Start: pEnd, End: sp.End})
+			}
+		}
+		spans = next
+	}
+	return spans
+}
+
+// spansMinutes 计算专注区间的总分钟数 (向下取整)
+func spansMinutes(spans []timeSpan) int {
+	var total time.Duration
+	for _, sp := range spans {
+		total += sp.End.Sub(sp.Start)
+	}
+	return int(total.Minutes())
+}
+
+// mergeSpans 合并重叠的区间，丢弃空区间，结果按时间排序
+func mergeSpans(spans []timeSpan) []timeSpan {
+	sorted := make([]timeSpan, 0, len(spans))
+	for _, sp := range spans {
+		if sp.End.After(sp.Start) {
+			sorted = append(sorted, sp)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start.Before(sorted[j].Start) })
+
+	merged := make([]timeSpan, 0, len(sorted))
+	for _, sp := range sorted {
+		if n := len(merged); n > 0 && !sp.Start.After(merged[n-1].End) {
+			if sp.End.After(merged[n-1].End) {
+				merged[n-1].End = sp.End
+			}
+			continue
+		}
+		merged = append(merged, sp)
+	}
+	return merged
+}
+
+// intersectSpans 两组已排序且互不重叠的区间的交集
+func intersectSpans(a, b []timeSpan) []timeSpan {
+	var result []timeSpan
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		start, end := a[i].Start, a[i].End
+		if b[j].Start.After(start) {
+			start = b[j].Start
+		}
+		if b[j].End.Before(end) {
+			end = b[j].End
+		}
+		if end.After(start) {
+			result = append(result, timeSpan{Start: start, End: end})
+		}
+		if a[i].End.Before(b[j].End) {
+			i++
+		} else {
+			j++
+		}
+	}
+	return result
+}
+
+// netFocusMinutes 计算会话在 end 时刻的净专注分钟数
+func netFocusMinutes(session *model.StudySession, end time.Time) int {
+	return spansMinutes(focusSpans(session.StartTime, end, session.Pauses))
+}
+
+// openPause 返回会话当前未结束的暂停记录 (没有则返回 nil)
+func openPause(session *model.StudySession) *model.StudySessionPause {
+	for i := range session.Pauses {
+		if session.Pauses[i].ResumedAt == nil {
+			return &session.Pauses[i]
+		}
+	}
+	return nil
+}
+
+// closeOpenPause 在结束会话前把仍在进行的暂停收尾到 endTime
+func closeOpenPause(tx *gorm.DB, session *model.StudySession, endTime time.Time) error {
+	p := openPause(session)
+	if p == nil {
+		return nil
+	}
+	resumedAt := endTime
+	if resumedAt.Before(p.PausedAt) {
+		resumedAt = p.PausedAt
+	}
+	p.ResumedAt = &resumedAt
+	return tx.Model(p).Update("resumed_at", resumedAt).Error
+}
+
+// findActiveSession 查找属于该用户且进行中的会话 (预加载暂停记录)
+func (s *StudyService) findActiveSession(userID, sessionID string) (*model.StudySession, error) {
+	var session model.StudySession
+	if err := database.DB.Preload("Pauses").
+		Where("id = ? AND user_id = ?", sessionID, userID).
+		First(&session).Error; err != nil {
+		return nil, errors.New("session not found")
+	}
+	if session.EndTime != nil {
+		return nil, errors.New("session is already ended")
+	}
+	return &session, nil
+}
+
+// PauseSession 暂停会话，暂停期间不计入专注时长
+func (s *StudyService) PauseSession(userID, sessionID string) (*model.StudySession, error) {
+	session, err := s.findActiveSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if openPause(session) != nil {
+		return nil, errors.New("session is already paused")
+	}
+
+	pause := model.StudySessionPause{
+		SessionID: session.ID,
+		PausedAt:  time.Now(),
+	}
+	if err := database.DB.Create(&pause).Error; err != nil {
+		return nil, err
+	}
+	session.Pauses = append(session.Pauses, pause)
+
+	return session, nil
+}
+
+// ResumeSession 恢复被暂停的会话
+func (s *StudyService) ResumeSession(userID, sessionID string) (*model.StudySession, error) {
+	session, err := s.findActiveSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	p := openPause(session)
+	if p == nil {
+		return nil, errors.New("session is not paused")
+	}
+
+	now := time.Now()
+	if err := database.DB.Model(p).Update("resumed_at", now).Error; err != nil {
+		return nil, err
+	}
+	p.ResumedAt = &now
+
+	// 番茄钟阶段被暂停时，阶段结束时间顺延暂停的时长
+	if session.PomodoroID != nil {
+		database.DB.Model(&model.PomodoroPlan{}).
+			Where("id = ? AND current_session_id = ? AND status = ?", *session.PomodoroID, session.ID, model.PomodoroStatusRunning).
+			Update("phase_ends_at", gorm.Expr("phase_ends_at + make_interval(secs => ?)", now.Sub(p.PausedAt).Seconds()))
+	}
+
+	// 恢复时立即续一次心跳，暂停期间心跳 Key 可能已过期，避免恢复后被 Reaper 误判
+	ctx := context.Background()
+	database.RDB.Set(ctx, fmt.Sprintf("study:heartbeat:%s", session.ID), now.Unix(), 3*time.Minute)
+
+	return session, nil
+}
+
+// ToSessionResponse 模型转 DTO (需要预加载 Pauses 才能得到准确的暂停信息)
+func (s *StudyService) ToSessionResponse(session *model.StudySession) dto.StudySessionResponse {
+	end := time.Now()
+	if session.EndTime != nil {
+		end = *session.EndTime
+	}
+
+	elapsed := 0
+	if end.After(session.StartTime) {
+		elapsed = int(end.Sub(session.StartTime).Minutes())
+	}
+	pausedMinutes := elapsed - netFocusMinutes(session, end)
+	if pausedMinutes < 0 {
+		pausedMinutes = 0
+	}
+
+	var pausedAt *time.Time
+	if p := openPause(session); p != nil && session.EndTime == nil {
+		pausedAt = &p.PausedAt
+	}
+
+	return dto.StudySessionResponse{
+		ID:              session.ID,
+		UserID:          session.UserID,
+		Type:            session.Type,
+		StartTime:       session.StartTime,
+		EndTime:         session.EndTime,
+		DurationMinutes: session.DurationMinutes,
+		IsPaused:        pausedAt != nil,
+		PausedAt:        pausedAt,
+		PausedMinutes:   pausedMinutes,
+		PomodoroID:      session.PomodoroID,
+		IsManual:        session.IsManual,
+		EndReason:       session.EndReason,
+		SyncKey:         session.SyncKey,
+		ReviewStatus:    session.ReviewStatus,
+		CreatedAt:       session.CreatedAt,
+	}
+}
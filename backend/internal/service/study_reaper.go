@@ -7,18 +7,19 @@ import (
 	"fmt"
 	"log"
 	"time"
-
-	"gorm.io/gorm"
 )
 
 // StartSessionReaper 启动后台清理任务
 // 在 main.go 中 go service.StartSessionReaper() 调用
 func StartSessionReaper() {
-	ticker := time.NewTicker(5 * time.Minute)
+	interval := 5 * time.Minute
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for range ticker.C {
-		reapSessions()
+		if acquireJobLock("session_reaper", interval) {
+			reapSessions()
+		}
 	}
 }
 
@@ -28,7 +29,7 @@ func reapSessions() {
 	var activeSessions []model.StudySession
 
 	// 1. 查询所有未结束的会话
-	if err := database.DB.Where("end_time IS NULL").Find(&activeSessions).Error; err != nil {
+	if err := database.DB.Preload("Pauses").Where("end_time IS NULL").Find(&activeSessions).Error; err != nil {
 		log.Printf("[Reaper] Error fetching sessions: %v\n", err)
 		return
 	}
@@ -43,6 +44,11 @@ func reapSessions() {
 			continue
 		}
 
+		// 暂停期间客户端不发心跳，只要暂停未超过上限就保留会话
+		if p := openPause(&session); p != nil && time.Since(p.PausedAt) < maxPauseDuration {
+			continue
+		}
+
 		log.Printf("[Reaper] Session %s seems dead. Cleaning up...", session.ID)
 
 		endTime := time.Now().Add(-3 * time.Minute)
@@ -50,43 +56,16 @@ func reapSessions() {
 			endTime = session.StartTime
 		}
 
-		duration := int(endTime.Sub(session.StartTime).Minutes())
-
-		// 更新 DB
-		session.EndTime = &endTime
-		session.DurationMinutes = &duration
-
-		// 使用事务处理清理逻辑，确保 DailyStats 同步更新
-		err = database.DB.Transaction(func(tx *gorm.DB) error {
-			if err := tx.Save(&session).Error; err != nil {
-				return err
-			}
-
-			// 调用 DailyStats 更新
-			s := &StudyService{}
-			if err := s.updateDailyStats(tx, session.UserID, session.StartTime, duration); err != nil {
-				return err
-			}
-
-			// 调用 TagStats 更新
-			if session.TagID != nil {
-				if err := s.updateUserTagStats(tx, session.UserID, *session.TagID, duration); err != nil {
-					return err
-				}
-			}
-
-			return nil
-		})
-		if err != nil {
+		// 统一结束逻辑：扣除暂停区间，并在事务中同步 DailyStats / TagStats / 排行榜
+		s := &StudyService{}
+		if err := s.finishSession(&session, endTime, model.SessionEndReasonReaped); err != nil {
 			log.Printf("[Reaper] Failed to save session %s: %v\n", session.ID, err)
 			continue
 		}
 
-		// 4. 更新排行榜 (调用统一的辅助方法)
-		s := &StudyService{}
-		// 注意: Redis 更新可以放在事务外，或者只要不报错就行
-		if err := s.updateRankings(ctx, session.UserID, duration, endTime); err != nil {
-			log.Printf("[Reaper] Failed to update rankings for session %s: %v\n", session.ID, err)
+		// 番茄钟阶段被回收，说明客户端已离线，整个计划随之取消
+		if session.PomodoroID != nil {
+			s.stopPomodoro(*session.PomodoroID, model.PomodoroStatusCancelled)
 		}
 
 		// 5. 确保删除心跳 Key
@@ -16,32 +16,53 @@ import (
 
 type StudyService struct{}
 
-// updateDailyStats 内部辅助函数，更新每日统计表 (Upsert)
-func (s *StudyService) updateDailyStats(tx *gorm.DB, userID string, startTime time.Time, durationMinutes int) error {
-	if durationMinutes <= 0 {
-		return nil
-	}
+// updateDailyStats 内部辅助函数，按日期累加每日统计表 (Upsert)
+// days 由 splitSpansByDay 按用户时区拆分，例如 23:30 - 01:30 的会话，前一天记 30 分钟，后一天记 90 分钟
+// 分钟数可以为负 (离线同步撤销已记入的时长)
+func (s *StudyService) updateDailyStats(tx *gorm.DB, userID string, days []dayMinutes) error {
+	streakService := &StreakService{}
+	decreased := false
+
+	for _, day := range days {
+		if day.Minutes == 0 {
+			continue
+		}
 
-	// 简单策略：归属到 StartTime 所在的 UTC 日期
-	date := time.Date(startTime.Year(), startTime.Month(), startTime.Day(), 0, 0, 0, 0, time.UTC)
+		// Upsert: 如果存在则累加，不存在则插入
+		// PostgreSQL: INSERT ... ON CONFLICT (user_id, date) DO UPDATE SET total_minutes = daily_stats.total_minutes + ?
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "date"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"total_minutes": gorm.Expr("daily_stats.total_minutes + ?", day.Minutes)}),
+		}).Create(&model.DailyStat{
+			UserID:       userID,
+			Date:         day.Date,
+			TotalMinutes: day.Minutes,
+		}).Error
+		if err != nil {
+			return err
+		}
 
-	// Upsert: 如果存在则累加，不存在则插入
-	// PostgreSQL: INSERT ... ON CONFLICT (user_id, date) DO UPDATE SET total_minutes = daily_stats.total_minutes + ?
-	err := tx.Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "user_id"}, {Name: "date"}},
-		DoUpdates: clause.Assignments(map[string]interface{}{"total_minutes": gorm.Expr("daily_stats.total_minutes + ?", durationMinutes)}),
-	}).Create(&model.DailyStat{
-		UserID:       userID,
-		Date:         date,
-		TotalMinutes: durationMinutes,
-	}).Error
+		if day.Minutes < 0 {
+			decreased = true
+			continue
+		}
 
-	return err
+		// 同步更新连续打卡记录
+		if err := streakService.onDailyStatChanged(tx, userID, day.Date); err != nil {
+			return err
+		}
+	}
+
+	// 某天分钟数减少可能跌破达标门槛，增量无法处理，全量重算
+	if decreased {
+		return streakService.RecomputeStreak(tx, userID)
+	}
+	return nil
 }
 
-// updateUserTagStats 内部辅助函数，更新标签累计经验 (Upsert)
+// updateUserTagStats 内部辅助函数，更新标签累计学习时长 (Upsert)
 func (s *StudyService) updateUserTagStats(tx *gorm.DB, userID string, tagID string, durationMinutes int) error {
-	if tagID == "" || durationMinutes <= 0 {
+	if tagID == "" || durationMinutes == 0 {
 		return nil
 	}
 
@@ -93,9 +114,40 @@ func (s *StudyService) StartSession(userID string, req dto.StartSessionRequest)
 		StartTime: startTime,
 		EndTime:   nil, // 明确为空
 		TagID:     tagID,
+		SyncKey:   newSyncKey(),
 	}
 
-	if err := database.DB.Create(&session).Error; err != nil {
+	if req.Pomodoro != nil {
+		// 番茄钟：第一阶段固定为专注，后续阶段由 PomodoroScheduler 自动切换
+		session.Type = model.SessionTypeLearning
+		plan := model.PomodoroPlan{
+			UserID:                userID,
+			TagID:                 tagID,
+			FocusMinutes:          req.Pomodoro.FocusMinutes,
+			ShortBreakMinutes:     req.Pomodoro.ShortBreakMinutes,
+			LongBreakMinutes:      req.Pomodoro.LongBreakMinutes,
+			CyclesBeforeLongBreak: req.Pomodoro.CyclesBeforeLongBreak,
+			TotalCycles:           req.Pomodoro.TotalCycles,
+			Status:                model.PomodoroStatusRunning,
+			CurrentPhase:          model.PomodoroPhaseFocus,
+			CurrentCycle:          1,
+			PhaseEndsAt:           startTime.Add(time.Duration(req.Pomodoro.FocusMinutes) * time.Minute),
+		}
+
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&plan).Error; err != nil {
+				return err
+			}
+			session.PomodoroID = &plan.ID
+			if err := tx.Create(&session).Error; err != nil {
+				return err
+			}
+			return tx.Model(&plan).Update("current_session_id", session.ID).Error
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else if err := database.DB.Create(&session).Error; err != nil {
 		return nil, err
 	}
 
@@ -105,11 +157,14 @@ func (s *StudyService) StartSession(userID string, req dto.StartSessionRequest)
 		database.RDB.Set(ctx, fmt.Sprintf("study:heartbeat:%s", session.ID), startTime.Unix(), 3*time.Minute)
 	}()
 
+	// 好友看到的状态变为 studying
+	(&PresenceService{}).PublishAsync(userID)
+
 	return &session, nil
 }
 
-// Heartbeat 接收心跳
-func (s *StudyService) Heartbeat(userID, sessionID string) error {
+// Heartbeat 接收心跳，device 为心跳来源设备标识 (用于反作弊)
+func (s *StudyService) Heartbeat(userID, sessionID, device string) error {
 	// 简单校验该 Session 是否属于该用户且正在进行中
 	// 也可以为了性能只依靠 Redis，但查一下 DB 更稳妥
 	var count int64
@@ -127,97 +182,207 @@ func (s *StudyService) Heartbeat(userID, sessionID string) error {
 	// 更新 Redis
 	ctx := context.Background()
 	key := fmt.Sprintf("study:heartbeat:%s", sessionID)
-	return database.RDB.Set(ctx, key, time.Now().Unix(), 3*time.Minute).Err()
+	now := time.Now()
+	if err := database.RDB.Set(ctx, key, now.Unix(), 3*time.Minute).Err(); err != nil {
+		return err
+	}
+	return recordHeartbeat(ctx, sessionID, device, now)
 }
 
 // updateRankings 内部辅助函数，同步更新排行榜
-func (s *StudyService) updateRankings(ctx context.Context, userID string, duration int, endTime time.Time) error {
-	if duration <= 0 {
+// 会话计入结束时间所在的日 / 周 / 月 / 赛季榜和总榜，有标签时同时计入该标签的各个榜单
+func (s *StudyService) updateRankings(ctx context.Context, userID string, tagID *string, duration int, endTime time.Time) error {
+	if duration == 0 {
 		return nil
 	}
 
+	periods, err := rankingPeriodsAt(database.DB, endTime)
+	if err != nil {
+		return err
+	}
+
 	score := float64(duration)
+	pipe := database.RDB.Pipeline()
+	for _, p := range periods {
+		keys := []string{rankingKey(p, "")}
+		if tagID != nil {
+			keys = append(keys, rankingKey(p, *tagID))
+		}
+		for _, key := range keys {
+			pipe.ZIncrBy(ctx, key, score, userID)
+			// 周期榜单设置过期时间
+			if p.TTL > 0 {
+				pipe.Expire(ctx, key, p.TTL)
+			}
+		}
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
 
-	// 1. 更新总榜
-	if err := database.RDB.ZIncrBy(ctx, "ranking:total", score, userID).Err(); err != nil {
-		return err
+// creditSession 把已结束会话计入 DailyStat、Tag 统计、经验与排行榜 (在事务中调用)
+// 休息段只记录历史，不计入学习时长和经验；补录会话的经验按 ManualSessionXPRate 折算，且默认不进入排行榜
+// 命中反作弊规则的会话照常计入个人统计和经验，但暂不进入排行榜
+// 返回实际发放的经验
+func (s *StudyService) creditSession(tx *gorm.DB, session *model.StudySession, spans []timeSpan) (int, error) {
+	if session.Type == model.SessionTypeRest || session.EndTime == nil {
+		return 0, nil
 	}
 
-	// 2. 更新周榜
-	year, week := endTime.ISOWeek()
-	weekKey := fmt.Sprintf("ranking:week:%d-%d", year, week)
+	days := splitSpansByDay(spans, userLocation(tx, session.UserID))
+	minutes := 0
+	for _, day := range days {
+		minutes += day.Minutes
+	}
 
-	if err := database.RDB.ZIncrBy(ctx, weekKey, score, userID).Err(); err != nil {
-		return err
+	// 1. 更新每日统计表 (预计算，跨天会话按日拆分)
+	if err := s.updateDailyStats(tx, session.UserID, days); err != nil {
+		return 0, fmt.Errorf("failed to update daily stats: %v", err)
 	}
 
-	// 设置周榜过期时间
-	database.RDB.Expire(ctx, weekKey, 14*24*time.Hour)
-	return nil
-}
+	// 2. 更新 Tag 累计学习时长
+	if session.TagID != nil {
+		if err := s.updateUserTagStats(tx, session.UserID, *session.TagID, minutes); err != nil {
+			return 0, fmt.Errorf("failed to update tag stats: %v", err)
+		}
+	}
 
-// EndSession 结束会话
-func (s *StudyService) EndSession(userID, sessionID string, req dto.EndSessionRequest) (*model.StudySession, error) {
-	var session model.StudySession
+	// 3. 发放经验 (写入 XP 流水)
+	xp, err := s.grantStudyXP(tx, session, days)
+	if err != nil {
+		return 0, fmt.Errorf("failed to grant xp: %v", err)
+	}
 
-	// 查找属于该用户的特定会话
-	if err := database.DB.Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
-		return nil, errors.New("session not found")
+	// 4. 反作弊检查，命中规则的会话等审核通过后再进排行榜
+	held, err := s.screenSession(tx, session, days, minutes)
+	if err != nil {
+		return 0, fmt.Errorf("failed to screen session: %v", err)
 	}
 
-	// 简单的校验：不能重复结束
-	if session.EndTime != nil {
-		return nil, errors.New("session is already ended")
+	// 5. 同步更新排行榜
+	if !held && (!session.IsManual || ManualSessionInRankings) {
+		if err := s.updateRankings(context.Background(), session.UserID, session.TagID, minutes, *session.EndTime); err != nil {
+			return 0, fmt.Errorf("failed to update rankings: %v", err)
+		}
 	}
 
-	// 更新 (后端自动计算结束时间和时长)
-	now := time.Now()
-	duration := int(now.Sub(session.StartTime).Minutes())
+	return xp, nil
+}
 
-	session.EndTime = &now
-	session.DurationMinutes = &duration
+// grantStudyXP 按天发放学习经验 (1 分钟 = 1 XP，补录会话按比例折算)
+func (s *StudyService) grantStudyXP(tx *gorm.DB, session *model.StudySession, days []dayMinutes) (int, error) {
+	if session.IsManual {
+		days = scaleDayMinutes(days, ManualSessionXPRate)
+	}
 
+	xpService := &XPService{}
+	total := 0
+	for _, day := range days {
+		if day.Minutes <= 0 {
+			continue
+		}
+		err := xpService.Grant(tx, XPGrant{
+			UserID:      session.UserID,
+			Source:      model.XPSourceStudy,
+			ReferenceID: session.ID,
+			TagID:       session.TagID,
+			Amount:      day.Minutes,
+			Date:        day.Date,
+		})
+		if err != nil {
+			return 0, err
+		}
+		total += day.Minutes
+	}
+	return total, nil
+}
+
+// finishSession 结束会话的统一入口 (EndSession / Reaper / 番茄钟切换共用)
+// 计算净专注时长后在事务中落库，学习类会话同时更新 DailyStat、Tag XP 与排行榜
+func (s *StudyService) finishSession(session *model.StudySession, endTime time.Time, reason model.SessionEndReason) error {
 	// 使用事务确保数据一致性
 	err := database.DB.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Save(&session).Error; err != nil {
-			return err
-		}
+		return s.finishSessionTx(tx, session, endTime, reason)
+	})
+	if err != nil {
+		return err
+	}
 
-		// 1. 更新每日统计表 (预计算)
-		if err := s.updateDailyStats(tx, userID, session.StartTime, duration); err != nil {
-			return fmt.Errorf("failed to update daily stats: %v", err)
-		}
+	// 删除心跳相关 Key
+	database.RDB.Del(context.Background(), fmt.Sprintf("study:heartbeat:%s", session.ID),
+		heartbeatLogKey(session.ID), heartbeatDevicesKey(session.ID))
 
-		// 2. 更新 Tag 累计经验 (XP)
-		if session.TagID != nil {
-			if err := s.updateUserTagStats(tx, userID, *session.TagID, duration); err != nil {
-				return fmt.Errorf("failed to update tag stats: %v", err)
-			}
-		}
+	(&AchievementService{}).EvaluateAsync(session.UserID, AchievementTriggerSession)
+	(&QuestService{}).RefreshAsync(session.UserID, QuestTriggerSession)
+	(&ChallengeService{}).PublishProgressAsync(session.UserID)
+	(&PresenceService{}).PublishAsync(session.UserID)
+	return nil
+}
 
-		// 3. 同步更新排行榜
-		ctx := context.Background()
-		if err := s.updateRankings(ctx, userID, duration, now); err != nil {
-			return fmt.Errorf("failed to update rankings: %v", err)
-		}
+// finishSessionTx finishSession 的事务内部分，供已经开启事务的调用方 (离线同步) 复用
+func (s *StudyService) finishSessionTx(tx *gorm.DB, session *model.StudySession, endTime time.Time, reason model.SessionEndReason) error {
+	spans := focusSpans(session.StartTime, endTime, session.Pauses)
+	duration := spansMinutes(spans)
 
-		// 4. 删除心跳 Key
-		database.RDB.Del(ctx, fmt.Sprintf("study:heartbeat:%s", session.ID))
+	// 条件更新：EndSession / Reaper / 番茄钟切换 / 离线同步可能同时结束同一个会话，只有第一个生效，避免重复计入统计
+	updates := map[string]interface{}{
+		"end_time":         endTime,
+		"duration_minutes": duration,
+		"end_reason":       reason,
+	}
+	if reason == model.SessionEndReasonReaped {
+		updates["reaped_end_time"] = endTime
+	}
+	result := tx.Model(&model.StudySession{}).
+		Where("id = ? AND end_time IS NULL", session.ID).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("session is already ended")
+	}
 
-		return nil
-	})
+	session.EndTime = &endTime
+	session.DurationMinutes = &duration
+	session.EndReason = reason
+	if reason == model.SessionEndReasonReaped {
+		session.ReapedEndTime = &endTime
+	}
+
+	if err := closeOpenPause(tx, session, endTime); err != nil {
+		return err
+	}
 
+	_, err := s.creditSession(tx, session, spans)
+	return err
+}
+
+// EndSession 结束会话
+func (s *StudyService) EndSession(userID, sessionID string, req dto.EndSessionRequest) (*model.StudySession, error) {
+	// 查找属于该用户的进行中会话 (不能重复结束)
+	session, err := s.findActiveSession(userID, sessionID)
 	if err != nil {
 		return nil, err
 	}
 
-	return &session, nil
+	// 后端自动计算结束时间和净专注时长
+	if err := s.finishSession(session, time.Now(), model.SessionEndReasonUser); err != nil {
+		return nil, err
+	}
+
+	// 手动结束番茄钟中的任一阶段，视为整个番茄计划结束
+	if session.PomodoroID != nil {
+		s.stopPomodoro(*session.PomodoroID, model.PomodoroStatusCompleted)
+	}
+
+	return session, nil
 }
 
 // GetActiveSession 获取当前进行中的会话
 func (s *StudyService) GetActiveSession(userID string) (*model.StudySession, error) {
 	var session model.StudySession
-	err := database.DB.Where("user_id = ? AND end_time IS NULL", userID).First(&session).Error
+	err := database.DB.Preload("Pauses").Where("user_id = ? AND end_time IS NULL", userID).First(&session).Error
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, nil // 没有进行中会话，返回 nil
@@ -236,6 +401,11 @@ func (s *StudyService) CancelActiveSession(userID string) (int64, error) {
 	if result.Error != nil {
 		return 0, result.Error
 	}
+
+	// 进行中的番茄计划一并取消
+	if plan, err := s.GetActivePomodoro(userID); err == nil && plan != nil {
+		s.stopPomodoro(plan.ID, model.PomodoroStatusCancelled)
+	}
 	return result.RowsAffected, nil
 }
 
@@ -250,6 +420,9 @@ func (s *StudyService) GetSessionsList(userID string, q dto.GetSessionsQuery) (*
 	if q.Type != "" {
 		db = db.Where("type = ?", q.Type)
 	}
+	if q.PomodoroID != "" {
+		db = db.Where("pomodoro_id = ?", q.PomodoroID)
+	}
 	if q.From != "" {
 		db = db.Where("start_time >= ?", q.From)
 	}
@@ -263,23 +436,15 @@ func (s *StudyService) GetSessionsList(userID string, q dto.GetSessionsQuery) (*
 	// 分页查询
 	offset := (q.Page - 1) * q.PageSize
 	// 排序：默认按创建时间倒序
-	err := db.Order("created_at DESC").Offset(offset).Limit(q.PageSize).Find(&sessions).Error
+	err := db.Preload("Pauses").Order("created_at DESC").Offset(offset).Limit(q.PageSize).Find(&sessions).Error
 	if err != nil {
 		return nil, err
 	}
 
 	// 转换为 Response DTO
 	items := make([]dto.StudySessionResponse, len(sessions))
-	for i, v := range sessions {
-		items[i] = dto.StudySessionResponse{
-			ID:              v.ID,
-			UserID:          v.UserID,
-			Type:            v.Type,
-			StartTime:       v.StartTime,
-			EndTime:         v.EndTime,
-			DurationMinutes: v.DurationMinutes,
-			CreatedAt:       v.CreatedAt,
-		}
+	for i := range sessions {
+		items[i] = s.ToSessionResponse(&sessions[i])
 	}
 
 	return &dto.SessionsListResponse{
@@ -292,11 +457,11 @@ func (s *StudyService) GetSessionsList(userID string, q dto.GetSessionsQuery) (*
 
 // GetStatsSummary 统计仪表盘
 func (s *StudyService) GetStatsSummary(userID string, q dto.GetStatsQuery) (*dto.StatsSummaryResponse, error) {
-	// 1. 计算时间范围
+	// 1. 计算时间范围 (按用户时区确定"今天")
 	var startTime, endTime time.Time
-	now := time.Now()
+	loc := userLocation(database.DB, userID)
+	now := time.Now().In(loc)
 
-	// 默认使用 UTC 处理
 	if q.Range == "custom" || q.Range == "" && q.From != "" {
 		t1, _ := time.Parse("2006-01-02", q.From) // 假设传入 YYYY-MM-DD
 		t2, _ := time.Parse("2006-01-02", q.To)
@@ -308,8 +473,8 @@ func (s *StudyService) GetStatsSummary(userID string, q dto.GetStatsQuery) (*dto
 		if q.Range != "" {
 			days, _ = strconv.Atoi(q.Range)
 		}
-		// 归一化到 UTC 0点
-		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		// 归一化到用户本地日期 (DailyStat.Date 约定为 UTC 0 点)
+		today := localDate(now, loc)
 		endTime = today
 		startTime = today.AddDate(0, 0, -days+1) // +1 是为了包含今天
 	}
@@ -368,121 +533,40 @@ func (s *StudyService) GetStatsSummary(userID string, q dto.GetStatsQuery) (*dto
 
 		// 1. Calculate Total XP & Level
 
-		var totalXP int64
-
-		// 这是一个快速聚合查询
-
-		database.DB.Model(&model.DailyStat{}).
+		// 经验来自 XP 流水的聚合 (DailyStat.XP)，与学习分钟数分开
 
-			Where("user_id = ?", userID).
+		totalXP := (&XPService{}).TotalXP(database.DB, userID)
 
-			Select("COALESCE(SUM(total_minutes), 0)").
+	
 
-			Scan(&totalXP)
+		var user model.User
 
-	
+		database.DB.Select("id", "prestige", "prestige_xp").First(&user, "id = ?", userID)
 
 		levelService := &LevelService{}
 
-		levelInfo := levelService.CalculateLevel(int(totalXP))
+		levelInfo := levelService.CalculateUserLevel(&user, totalXP)
 
 	
 
-		// 2. Calculate Streak
-
-		// 我们需要查找最近的连续打卡记录。
-
-		// 定义：Streak = 连续多少天 total_minutes > 0。
-
-		// 从今天(或昨天)开始倒推。
-
-		var streakStats []model.DailyStat
-
-		// 查最近 100 条非零记录即可，大多数人 streak 不会太夸张，或者分批查。
-
-		// 这里为了性能，先查最近 365 天。
-
-		yesterday := now.AddDate(0, 0, -1)
-
-		oneYearAgo := now.AddDate(-1, 0, 0)
-
-		
+		// 2. Streak (由 StreakService 增量维护)
 
-		// 注意：这里需要查所有日期的记录，而不仅是 daily_stats 存在的记录（因为 daily_stats 只有有学习才有记录）。
+		streak, err := (&StreakService{}).GetStreak(userID)
 
-		// 利用 daily_stats 的特性：只有有学习才有记录。
-
-		// 那么如果是连续的，date 应该是连续的 (差距 24h)。
-
-		
-
-		database.DB.Model(&model.DailyStat{}).
-
-			Where("user_id = ? AND date >= ?", userID, oneYearAgo).
-
-			Order("date DESC").
-
-			Find(&streakStats)
-
-			
-
-		currentStreak := 0
-
-		// 今天的日期字符串 (UTC)
-
-		todayStr := now.UTC().Format("2006-01-02")
-
-		yesterdayStr := yesterday.UTC().Format("2006-01-02")
-
-		
-
-		// 检查 streak
-
-		// 逻辑：如果最近的一条是今天，streak++，继续看上一条是否是昨天。
-
-		// 如果最近的一条是昨天，streak++，继续看上一条是否是前天。
-
-		// 如果最近的一条是前天，说明今天和昨天都断了，streak = 0。
-
-		
-
-		if len(streakStats) > 0 {
-
-			lastDate := streakStats[0].Date.Format("2006-01-02")
-
-			
-
-			// 只有当最近一次学习是“今天”或“昨天”时，Streak 才有效
-
-			if lastDate == todayStr || lastDate == yesterdayStr {
-
-				currentStreak = 1
-
-				expectedDate := streakStats[0].Date.AddDate(0, 0, -1) // 期望的下一条日期
-
-				
-
-				for i := 1; i < len(streakStats); i++ {
-
-					thisDate := streakStats[i].Date
-
-					// 比较 thisDate 和 expectedDate (忽略时分秒，只比年月日)
-
-					if thisDate.Format("2006-01-02") == expectedDate.Format("2006-01-02") {
-
-						currentStreak++
+		if err != nil {
 
-						expectedDate = expectedDate.AddDate(0, 0, -1)
+			return nil, err
 
-					} else {
+		}
 
-						break // 断了
+	
+		// 3. Goals (当前周期进度)
 
-					}
+		goals, err := (&GoalService{}).GetGoals(userID, true)
 
-				}
+		if err != nil {
 
-			}
+			return nil, err
 
 		}
 
@@ -492,7 +576,7 @@ func (s *StudyService) GetStatsSummary(userID string, q dto.GetStatsQuery) (*dto
 
 			Type:			 q.Type,
 
-			Tz:				 "UTC",
+			Tz:				 loc.String(),
 
 			From:			 startTime,
 
@@ -504,9 +588,11 @@ func (s *StudyService) GetStatsSummary(userID string, q dto.GetStatsQuery) (*dto
 
 			LevelInfo:		 levelInfo,
 
-			CurrentStreak:	 currentStreak,
+			CurrentStreak:	 streak.CurrentStreak,
+
+			LongestStreak:	 streak.LongestStreak,
 
-			LongestStreak:	 0, // MVP 暂不计算，需要全表扫描
+			Goals:			 goals,
 
 		}, nil
 
@@ -54,6 +54,30 @@ func (s *TagService) FindOrCreateTag(name string) (*model.Tag, error) {
 	return nil, err
 }
 
+// FindTag 按名称查找标签并解析别名，不存在时不创建 (用于查询场景)
+func (s *TagService) FindTag(name string) (*model.Tag, error) {
+	name = strings.TrimSpace(strings.ToLower(name))
+	if name == "" {
+		return nil, errors.New("tag name cannot be empty")
+	}
+
+	var tag model.Tag
+	if err := database.DB.Where("name = ?", name).First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("tag not found")
+		}
+		return nil, err
+	}
+
+	if tag.ParentID != nil {
+		var parentTag model.Tag
+		if err := database.DB.Where("id = ?", tag.ParentID).First(&parentTag).Error; err == nil {
+			return &parentTag, nil
+		}
+	}
+	return &tag, nil
+}
+
 // SearchTags 搜索标签
 func (s *TagService) SearchTags(query string) ([]dto.TagResponse, error) {
 	query = strings.TrimSpace(strings.ToLower(query))
@@ -101,21 +125,22 @@ func (s *TagService) AttachTagToUser(userID string, tagName string) (*dto.UserTa
 	}
 
 	// 3. 查出最新状态 (为了返回 current XP/Level)
-	// 如果是刚 Create 的，TotalMinutes 是 0
+	// 如果是刚 Create 的，XP 是 0
 	// 如果 DoNothing，可能原来就有 XP
 	var freshStat model.UserTagStat
 	if err := database.DB.Where("user_id = ? AND tag_id = ?", userID, tag.ID).First(&freshStat).Error; err != nil {
 		return nil, err
 	}
 
-	// 计算等级
+	// 计算技能等级
 	levelService := &LevelService{}
-	levelInfo := levelService.CalculateLevel(freshStat.TotalMinutes)
+	levelInfo := levelService.CalculateTagLevel(freshStat.XP)
 
 	return &dto.UserTagResponse{
 		TagID:        tag.ID,
 		TagName:      tag.Name,
 		TotalMinutes: freshStat.TotalMinutes,
+		XP:           freshStat.XP,
 		Level:        levelInfo,
 		LastStudied:  freshStat.UpdatedAt, // 粗略用 UpdatedAt 近似
 	}, nil
@@ -134,14 +159,14 @@ func (s *TagService) DetachTagFromUser(userID string, tagID string) error {
 	return nil
 }
 
-// GetUserTags 获取用户的标签列表
+// GetUserTags 获取用户的标签列表 (按经验降序，经验相同时最近学习的在前)
 func (s *TagService) GetUserTags(userID string) ([]dto.UserTagResponse, error) {
 	var stats []model.UserTagStat
 
-	// 预加载 Tag 信息，按最近更新排序
+	// 预加载 Tag 信息
 	if err := database.DB.Preload("Tag").
 		Where("user_id = ?", userID).
-		Order("updated_at DESC").
+		Order("xp DESC, updated_at DESC").
 		Find(&stats).Error; err != nil {
 		return nil, err
 	}
@@ -150,11 +175,12 @@ func (s *TagService) GetUserTags(userID string) ([]dto.UserTagResponse, error) {
 	res := make([]dto.UserTagResponse, len(stats))
 
 	for i, stat := range stats {
-		levelInfo := levelService.CalculateLevel(stat.TotalMinutes)
+		levelInfo := levelService.CalculateTagLevel(stat.XP)
 		res[i] = dto.UserTagResponse{
 			TagID:        stat.TagID,
 			TagName:      stat.Tag.Name,
 			TotalMinutes: stat.TotalMinutes,
+			XP:           stat.XP,
 			Level:        levelInfo,
 			LastStudied:  stat.UpdatedAt,
 		}
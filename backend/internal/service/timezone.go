@@ -0,0 +1,91 @@
+package service
+
+import (
+	"backend/internal/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultTimezone 新用户的默认时区，与部署环境 (TZ=Asia/Shanghai) 保持一致
+const DefaultTimezone = "Asia/Shanghai"
+
+// ValidateTimezone 校验 IANA 时区名称 (例如 Asia/Shanghai、America/New_York)
+func ValidateTimezone(name string) error {
+	if name == "" {
+		return errors.New("timezone cannot be empty")
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return errors.New("invalid timezone: " + name)
+	}
+	return nil
+}
+
+// loadLocation 解析时区，非法或为空时回退到默认时区
+func loadLocation(name string) *time.Location {
+	if name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	if loc, err := time.LoadLocation(DefaultTimezone); err == nil {
+		return loc
+	}
+	return time.Local
+}
+
+// userLocation 查询用户设置的时区 (db 可以传事务)
+func userLocation(db *gorm.DB, userID string) *time.Location {
+	var tz string
+	db.Model(&model.User{}).Select("timezone").Where("id = ?", userID).Scan(&tz)
+	return loadLocation(tz)
+}
+
+// localDate 返回 t 在 loc 时区下的日历日期
+// 与 DailyStat.Date 的存储约定一致：用 UTC 0 点的 time.Time 表示一个 date
+func localDate(t time.Time, loc *time.Location) time.Time {
+	lt := t.In(loc)
+	return time.Date(lt.Year(), lt.Month(), lt.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// dayMinutes 某个本地日期上的分钟数
+type dayMinutes struct {
+	Date    time.Time
+	Minutes int
+}
+
+// splitSpansByDay 把专注区间按 loc 时区的日界线切分，返回按日期排序的每日分钟数
+// 使用累计取整：每天分到 floor(累计到当天结束) - floor(累计到当天开始)，
+// 保证各天之和等于 spansMinutes(spans)，不会因为跨天多算或少算
+func splitSpansByDay(spans []timeSpan, loc *time.Location) []dayMinutes {
+	var result []dayMinutes
+	var elapsed time.Duration
+
+	for _, sp := range spans {
+		cur := sp.Start.In(loc)
+		end := sp.End.In(loc)
+		for cur.Before(end) {
+			// 下一个本地 0 点
+			nextDay := time.Date(cur.Year(), cur.Month(), cur.Day()+1, 0, 0, 0, 0, loc)
+			periodEnd := end
+			if nextDay.Before(end) {
+				periodEnd = nextDay
+			}
+
+			before := int(elapsed.Minutes())
+			elapsed += periodEnd.Sub(cur)
+			minutes := int(elapsed.Minutes()) - before
+
+			date := localDate(cur, loc)
+			if n := len(result); n > 0 && result[n-1].Date.Equal(date) {
+				result[n-1].Minutes += minutes
+			} else {
+				result = append(result, dayMinutes{Date: date, Minutes: minutes})
+			}
+
+			cur = periodEnd
+		}
+	}
+	return result
+}
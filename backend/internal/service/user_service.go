@@ -6,6 +6,8 @@ import (
 	"backend/pkg/database"
 	"backend/pkg/utils"
 	"errors"
+
+	"gorm.io/gorm"
 )
 
 type UserService struct{}
@@ -31,7 +33,28 @@ func (s *UserService) UpdateProfile(userID string, req dto.UpdateProfileRequest)
 	user.AvatarUrl = req.AvatarURL
 	user.Bio = req.Bio
 
-	if err := database.DB.Save(&user).Error; err != nil {
+	timezoneChanged := false
+	if req.Timezone != nil && *req.Timezone != user.Timezone {
+		if err := ValidateTimezone(*req.Timezone); err != nil {
+			return nil, err
+		}
+		user.Timezone = *req.Timezone
+		timezoneChanged = true
+	}
+
+	// 时区变化后，把已有的每日统计按新时区重新归档，与资料保存在同一事务中
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+		if timezoneChanged {
+			if _, err := rebucketDailyStats(tx, user.ID, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -64,29 +87,23 @@ func (s *UserService) ChangePassword(userID string, req dto.ChangePasswordReques
 func (s *UserService) GetPublicProfile(callerID, targetID string) (*dto.PublicProfileResponse, error) {
 	var user model.User
 	// 1. 获取基本信息
-	if err := database.DB.Select("id", "nickname", "avatar_url", "bio").First(&user, "id = ?", targetID).Error; err != nil {
+	if err := database.DB.Select("id", "nickname", "avatar_url", "bio", "prestige", "prestige_xp").First(&user, "id = ?", targetID).Error; err != nil {
 		return nil, err
 	}
 
 	// 2. 计算总等级
-	var totalXP int64
-	database.DB.Model(&model.DailyStat{}).
-		Where("user_id = ?", targetID).
-		Select("COALESCE(SUM(total_minutes), 0)").
-		Scan(&totalXP)
+	xpService := &XPService{}
+	totalXP := xpService.TotalXP(database.DB, targetID)
 
 	levelService := &LevelService{}
-	levelInfo := levelService.CalculateLevel(int(totalXP))
+	levelInfo := levelService.CalculateUserLevel(&user, totalXP)
 
 	// 3. 获取 Top 3 标签
 	tagService := &TagService{}
 	allTags, err := tagService.GetUserTags(targetID)
 	topTags := make([]dto.UserTagResponse, 0)
 	if err == nil {
-		// GetUserTags 已经按 XP 降序或最近更新排过序了？
-		// 之前代码里 GetUserTags 是按 updated_at 排序的。
-		// 这里我们最好在业务上按 XP 重新排一下，或者修改 GetUserTags。
-		// 为了简单，我们取前 3 个。
+		// GetUserTags 已按 XP 降序排序，取前 3 个
 		count := len(allTags)
 		if count > 3 {
 			count = 3
@@ -113,6 +130,12 @@ func (s *UserService) GetPublicProfile(callerID, targetID string) (*dto.PublicPr
 		}
 	}
 
+	// 5. 已解锁的成就 (查询失败时不影响资料展示)
+	achievements, err := (&AchievementService{}).GetUnlocked(targetID)
+	if err != nil {
+		achievements = []dto.AchievementResponse{}
+	}
+
 	return &dto.PublicProfileResponse{
 		ID:           user.ID,
 		Nickname:     user.Nickname,
@@ -122,6 +145,7 @@ func (s *UserService) GetPublicProfile(callerID, targetID string) (*dto.PublicPr
 		TopTags:      topTags,
 		IsFriend:     isFriend,
 		FriendStatus: friendStatus,
+		Achievements: achievements,
 	}, nil
 }
 
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"backend/internal/model"
+	"backend/pkg/database"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// errBackfillDryRun 用于 dry-run 时回滚事务
+var errBackfillDryRun = errors.New("dry run")
+
+// XPBackfillReport 单个用户经验流水回填的结果
+type XPBackfillReport struct {
+	UserID         string
+	ExistingEvents int // 已有的流水只重新计入聚合，不重复发放
+	Sessions       int
+	StudyMinutes   int
+	StudyXP        int
+	BlogXP         int
+	LikeXP         int
+	BookmarkXP     int
+	DeletedEmpties int64
+}
+
+// TotalXP 本次补发的经验总数
+func (r *XPBackfillReport) TotalXP() int {
+	return r.StudyXP + r.BlogXP + r.LikeXP + r.BookmarkXP
+}
+
+// ledgerKey 一笔经验的来源，点赞 / 收藏同一篇博客时按触发者区分
+func ledgerKey(source model.XPSource, referenceID string, actorID *string) string {
+	key := string(source) + ":" + referenceID
+	if actorID != nil {
+		key += ":" + *actorID
+	}
+	return key
+}
+
+// BackfillXPLedger 为引入经验流水之前的历史数据补写 XPEvent
+// 旧的 DailyStat / UserTagStat 把学习分钟和博客经验混在一起，无法拆分，
+// 因此学习分钟按会话重新计算，经验按会话、博客 AI 评分、点赞、收藏逐笔补发。
+// 已经有流水的来源 (上线后产生的经验，或上次回填写入的) 不再补发，可以重复执行
+func BackfillXPLedger(userID string, dryRun bool) (*XPBackfillReport, error) {
+	report := &XPBackfillReport{UserID: userID}
+
+	loc := userLocation(database.DB, userID)
+	studyService := &StudyService{}
+	xpService := &XPService{}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// 1. 清空旧聚合
+		if err := tx.Model(&model.DailyStat{}).Where("user_id = ?", userID).
+			Updates(map[string]interface{}{"total_minutes": 0, "xp": 0}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.UserTagStat{}).Where("user_id = ?", userID).
+			Updates(map[string]interface{}{"total_minutes": 0, "xp": 0}).Error; err != nil {
+			return err
+		}
+
+		// 2. 已有流水 (含冲正) 重新累加到聚合，并记录已经发放过的来源
+		var events []model.XPEvent
+		if err := tx.Where("user_id = ?", userID).Order("created_at ASC").Find(&events).Error; err != nil {
+			return err
+		}
+		granted := make(map[string]bool, len(events))
+		for i := range events {
+			if err := xpService.applyAggregates(tx, &events[i]); err != nil {
+				return err
+			}
+			granted[ledgerKey(events[i].Source, events[i].ReferenceID, events[i].ActorID)] = true
+		}
+		report.ExistingEvents = len(events)
+
+		// 3. 学习会话：重新计入分钟数，补发缺少的学习经验 (排行榜本来就按分钟统计，不需要改)
+		var sessions []model.StudySession
+		if err := tx.Preload("Pauses").
+			Where("user_id = ? AND end_time IS NOT NULL AND type <> ?", userID, model.SessionTypeRest).
+			Order("start_time ASC").
+			Find(&sessions).Error; err != nil {
+			return err
+		}
+
+		for i := range sessions {
+			sess := &sessions[i]
+			days := splitSpansByDay(focusSpans(sess.StartTime, *sess.EndTime, sess.Pauses), loc)
+			minutes := 0
+			for _, day := range days {
+				minutes += day.Minutes
+			}
+
+			if err := studyService.updateDailyStats(tx, userID, days); err != nil {
+				return err
+			}
+			if sess.TagID != nil {
+				if err := studyService.updateUserTagStats(tx, userID, *sess.TagID, minutes); err != nil {
+					return err
+				}
+			}
+			report.Sessions++
+			report.StudyMinutes += minutes
+
+			if granted[ledgerKey(model.XPSourceStudy, sess.ID, nil)] {
+				continue
+			}
+			xp, err := studyService.grantStudyXP(tx, sess, days)
+			if err != nil {
+				return err
+			}
+			report.StudyXP += xp
+		}
+
+		// 4. 博客 AI 评分经验 (按博客发布日期计入)
+		var blogs []model.Blog
+		if err := tx.Where("user_id = ?", userID).Find(&blogs).Error; err != nil {
+			return err
+		}
+
+		qualities := make(map[string]*model.BlogQuality, len(blogs))
+		for _, blog := range blogs {
+			qualities[blog.ID] = blog.AIQuality
+			if blog.AIXpPerTag == nil || *blog.AIXpPerTag <= 0 || granted[ledgerKey(model.XPSourceBlogAI, blog.ID, nil)] {
+				continue
+			}
+			for _, tagID := range blog.AITagIDs {
+				tagID := tagID
+				if err := xpService.Grant(tx, XPGrant{
+					UserID:      userID,
+					Source:      model.XPSourceBlogAI,
+					ReferenceID: blog.ID,
+					TagID:       &tagID,
+					Amount:      *blog.AIXpPerTag,
+					Date:        localDate(blog.CreatedAt, loc),
+				}); err != nil {
+					return err
+				}
+				report.BlogXP += *blog.AIXpPerTag
+			}
+		}
+
+		// 5. 点赞、收藏经验 (按互动发生的日期计入)
+		var likes []model.BlogLike
+		if err := tx.Joins("JOIN blogs ON blogs.id = blog_likes.blog_id").
+			Where("blogs.user_id = ?", userID).
+			Find(&likes).Error; err != nil {
+			return err
+		}
+		for _, like := range likes {
+			actorID := like.UserID
+			if granted[ledgerKey(model.XPSourceBlogLike, like.BlogID, &actorID)] {
+				continue
+			}
+			amount := likeXP(qualities[like.BlogID])
+			if err := xpService.Grant(tx, XPGrant{
+				UserID:      userID,
+				Source:      model.XPSourceBlogLike,
+				ReferenceID: like.BlogID,
+				ActorID:     &actorID,
+				Amount:      amount,
+				Date:        localDate(like.CreatedAt, loc),
+			}); err != nil {
+				return err
+			}
+			report.LikeXP += amount
+		}
+
+		var bookmarks []model.BlogBookmark
+		if err := tx.Joins("JOIN blogs ON blogs.id = blog_bookmarks.blog_id").
+			Where("blogs.user_id = ?", userID).
+			Find(&bookmarks).Error; err != nil {
+			return err
+		}
+		for _, bm := range bookmarks {
+			actorID := bm.UserID
+			if granted[ledgerKey(model.XPSourceBlogBookmark, bm.BlogID, &actorID)] {
+				continue
+			}
+			amount := bookmarkXP(qualities[bm.BlogID])
+			if err := xpService.Grant(tx, XPGrant{
+				UserID:      userID,
+				Source:      model.XPSourceBlogBookmark,
+				ReferenceID: bm.BlogID,
+				ActorID:     &actorID,
+				Amount:      amount,
+				Date:        localDate(bm.CreatedAt, loc),
+			}); err != nil {
+				return err
+			}
+			report.BookmarkXP += amount
+		}
+
+		// 6. 删除既没有学习也没有经验的空行，并重算连续打卡
+		result := tx.Where("user_id = ? AND total_minutes = 0 AND xp = 0", userID).Delete(&model.DailyStat{})
+		if result.Error != nil {
+			return result.Error
+		}
+		report.DeletedEmpties = result.RowsAffected
+
+		if err := (&StreakService{}).RecomputeStreak(tx, userID); err != nil {
+			return err
+		}
+
+		if dryRun {
+			return errBackfillDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBackfillDryRun) {
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"backend/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// XPService 经验的唯一写入口：写 XPEvent 流水，并同步维护 DailyStat.XP 与 UserTagStat.XP
+// 所有方法都要求在事务中调用，保证流水与聚合一致
+type XPService struct{}
+
+// XPGrant 一笔经验发放
+type XPGrant struct {
+	UserID      string
+	Source      model.XPSource
+	ReferenceID string
+	ActorID     *string
+	TagID       *string
+	Amount      int
+	Date        time.Time // 计入的日期 (UTC 0 点表示的本地日期)，为零值时取用户时区的今天
+}
+
+// Grant 发放经验
+func (s *XPService) Grant(tx *gorm.DB, g XPGrant) error {
+	if g.Amount == 0 {
+		return nil
+	}
+	if g.Date.IsZero() {
+		g.Date = localDate(time.Now(), userLocation(tx, g.UserID))
+	}
+
+	event := model.XPEvent{
+		UserID:      g.UserID,
+		Source:      g.Source,
+		ReferenceID: g.ReferenceID,
+		ActorID:     g.ActorID,
+		TagID:       g.TagID,
+		Amount:      g.Amount,
+		Date:        g.Date,
+	}
+	if err := tx.Create(&event).Error; err != nil {
+		return err
+	}
+	return s.applyAggregates(tx, &event)
+}
+
+// Reverse 撤销某个来源对象上尚未撤销的经验 (例如博客被删除、取消点赞、会话时长被修正)
+// actorID 为空时撤销所有触发者的流水，返回撤销的经验总数
+func (s *XPService) Reverse(tx *gorm.DB, userID string, sources []model.XPSource, referenceID string, actorID *string) (int, error) {
+	query := tx.Where("user_id = ? AND source IN ? AND reference_id = ? AND reversal_of IS NULL", userID, sources, referenceID).
+		Where("NOT EXISTS (SELECT 1 FROM xp_events r WHERE r.reversal_of = xp_events.id)")
+	if actorID != nil {
+		query = query.Where("actor_id = ?", *actorID)
+	}
+
+	var events []model.XPEvent
+	if err := query.Find(&events).Error; err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, e := range events {
+		originalID := e.ID
+		reversal := model.XPEvent{
+			UserID:      e.UserID,
+			Source:      e.Source,
+			ReferenceID: e.ReferenceID,
+			ActorID:     e.ActorID,
+			TagID:       e.TagID,
+			Amount:      -e.Amount,
+			Date:        e.Date, // 冲正记在原日期上，历史曲线保持一致
+			ReversalOf:  &originalID,
+		}
+		if err := tx.Create(&reversal).Error; err != nil {
+			return 0, err
+		}
+		if err := s.applyAggregates(tx, &reversal); err != nil {
+			return 0, err
+		}
+		total += e.Amount
+	}
+	return total, nil
+}
+
+// applyAggregates 把一条流水累加到 DailyStat.XP 与 UserTagStat.XP
+func (s *XPService) applyAggregates(tx *gorm.DB, e *model.XPEvent) error {
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "date"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"xp": gorm.Expr("daily_stats.xp + ?", e.Amount)}),
+	}).Create(&model.DailyStat{
+		UserID: e.UserID,
+		Date:   e.Date,
+		XP:     e.Amount,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	if e.TagID == nil {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "tag_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"xp": gorm.Expr("user_tag_stats.xp + ?", e.Amount)}),
+	}).Create(&model.UserTagStat{
+		UserID: e.UserID,
+		TagID:  *e.TagID,
+		XP:     e.Amount,
+	}).Error
+}
+
+// TotalXP 用户的总经验 (用于计算等级)
+func (s *XPService) TotalXP(db *gorm.DB, userID string) int {
+	var totalXP int64
+	db.Model(&model.DailyStat{}).
+		Where("user_id = ?", userID).
+		Select("COALESCE(SUM(xp), 0)").
+		Scan(&totalXP)
+	return int(totalXP)
+}
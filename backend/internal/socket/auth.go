@@ -0,0 +1,81 @@
+package socket
+
+import (
+	"backend/pkg/utils"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	socketio "github.com/googollee/go-socket.io"
+)
+
+// Ack 错误信息，所有事件统一返回 {"error": "..."}
+const (
+	errUnauthorized     = "unauthorized"
+	errInvalidPayload   = "invalid payload"
+	errPermissionDenied = "permission denied"
+)
+
+// authenticate 从握手请求中解析用户，支持 ?token= 和 Authorization: Bearer <token>
+func authenticate(s socketio.Conn) (string, error) {
+	url := s.URL()
+	token := url.Query().Get("token")
+	if token == "" {
+		if parts := strings.SplitN(s.RemoteHeader().Get("Authorization"), " ", 2); len(parts) == 2 && parts[0] == "Bearer" {
+			token = parts[1]
+		}
+	}
+	if token == "" {
+		return "", errors.New(errUnauthorized)
+	}
+
+	claims, err := utils.ParseToken(token)
+	if err != nil || claims.UserID == "" {
+		return "", errors.New(errUnauthorized)
+	}
+	return claims.UserID, nil
+}
+
+// socketContext 取出连接上的用户信息，未通过鉴权的连接返回 false
+func socketContext(s socketio.Conn) (*SocketContext, bool) {
+	ctx, ok := s.Context().(*SocketContext)
+	if !ok || ctx == nil || ctx.UserID == "" {
+		return nil, false
+	}
+	return ctx, true
+}
+
+// eventHandler 已鉴权的事件处理函数
+type eventHandler func(s socketio.Conn, ctx *SocketContext, msg string) string
+
+// roomEventHandler 已鉴权且通过房间成员校验的事件处理函数，role 为调用者在房间中的角色
+type roomEventHandler func(s socketio.Conn, ctx *SocketContext, roomID, role, msg string) string
+
+// withAuth 要求连接已鉴权
+func withAuth(h eventHandler) func(socketio.Conn, string) string {
+	return func(s socketio.Conn, msg string) string {
+		ctx, ok := socketContext(s)
+		if !ok {
+			return errorResponse(errUnauthorized)
+		}
+		return h(s, ctx, msg)
+	}
+}
+
+// withRoomAuth 要求连接已鉴权、调用者当前在 payload.roomId 房间内，且角色不低于 minRole
+func withRoomAuth(minRole string, h roomEventHandler) func(socketio.Conn, string) string {
+	return withAuth(func(s socketio.Conn, ctx *SocketContext, msg string) string {
+		var scope struct {
+			RoomID string `json:"roomId"`
+		}
+		if err := json.Unmarshal([]byte(msg), &scope); err != nil || scope.RoomID == "" {
+			return errorResponse(errInvalidPayload)
+		}
+
+		role, err := roomService.AuthorizeMember(ctx.UserID, scope.RoomID, minRole)
+		if err != nil {
+			return errorResponse(err.Error())
+		}
+		return h(s, ctx, scope.RoomID, role, msg)
+	})
+}
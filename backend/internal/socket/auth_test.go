@@ -0,0 +1,239 @@
+package socket
+
+import (
+	"backend/internal/model"
+	"backend/pkg/database"
+	"backend/pkg/utils"
+	"net/url"
+	"sort"
+	"testing"
+	"time"
+
+	socketio "github.com/googollee/go-socket.io"
+)
+
+// roomScopedEvents 需要房间成员身份的事件及所需的最低角色
+var roomScopedEvents = map[string]string{
+	"leave_room":      "member",
+	"send_message":    "member",
+	"update_status":   "member",
+	"moderate_member": "admin",
+	"invite_to_room":  "member",
+}
+
+func sortedEvents() []string {
+	events := make([]string, 0)
+	for name := range eventHandlers() {
+		events = append(events, name)
+	}
+	sort.Strings(events)
+	return events
+}
+
+func TestAuthenticate(t *testing.T) {
+	token, err := utils.GenerateAccessToken("user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		header string
+		want   string
+	}{
+		{name: "missing token"},
+		{name: "bad query token", query: "token=not-a-jwt"},
+		{name: "bad bearer token", header: "Bearer not-a-jwt"},
+		{name: "non bearer header", header: "Basic " + token},
+		{name: "query token", query: "token=" + token, want: "user-1"},
+		{name: "bearer token", header: "Bearer " + token, want: "user-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newFakeConn(nil)
+			c.url = url.URL{Path: "/socket.io/", RawQuery: tt.query}
+			if tt.header != "" {
+				c.header.Set("Authorization", tt.header)
+			}
+
+			userID, err := authenticate(c)
+			if tt.want == "" {
+				if err == nil || err.Error() != errUnauthorized {
+					t.Fatalf("expected %q error, got user %q err %v", errUnauthorized, userID, err)
+				}
+				return
+			}
+			if err != nil || userID != tt.want {
+				t.Fatalf("expected user %q, got %q err %v", tt.want, userID, err)
+			}
+		})
+	}
+}
+
+func TestConnectRejectsInvalidToken(t *testing.T) {
+	_, ts := startServer(t, nil)
+
+	for name, query := range map[string]url.Values{
+		"missing token": nil,
+		"bad token":     {"token": {"not-a-jwt"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := dialClient(t, ts, query)
+			select {
+			case <-c.closed:
+			case <-time.After(3 * time.Second):
+				t.Fatal("connection was not closed")
+			}
+			if ack, ok := c.emit("set_presence", `{"status":"away"}`); ok {
+				t.Fatalf("rejected connection got ack %q", ack)
+			}
+		})
+	}
+}
+
+func TestEventsRequireAuthentication(t *testing.T) {
+	for _, ctx := range []interface{}{nil, &SocketContext{}} {
+		for _, event := range sortedEvents() {
+			handler := eventHandlers()[event]
+			ack := handler(newFakeConn(ctx), `{"roomId":"room-1"}`)
+			if got := ackError(t, ack); got != errUnauthorized {
+				t.Errorf("%s with context %v: expected %q, got %q", event, ctx, errUnauthorized, got)
+			}
+		}
+	}
+}
+
+func TestEventsRejectInvalidPayload(t *testing.T) {
+	for _, event := range sortedEvents() {
+		handler := eventHandlers()[event]
+		ack := handler(newFakeConn(&SocketContext{UserID: "user-1"}), "not json")
+		if got := ackError(t, ack); got != errInvalidPayload {
+			t.Errorf("%s: expected %q, got %q", event, errInvalidPayload, got)
+		}
+	}
+
+	// 房间内事件必须带 roomId
+	for event := range roomScopedEvents {
+		ack := eventHandlers()[event](newFakeConn(&SocketContext{UserID: "user-1"}), "{}")
+		if got := ackError(t, ack); got != errInvalidPayload {
+			t.Errorf("%s without roomId: expected %q, got %q", event, errInvalidPayload, got)
+		}
+	}
+}
+
+func TestWithRoomAuth(t *testing.T) {
+	setupDB(t)
+
+	owner := createUser(t, "owner")
+	admin := createUser(t, "admin")
+	member := createUser(t, "member")
+	outsider := createUser(t, "outsider")
+	left := createUser(t, "left")
+	roomID := createRoom(t, owner)
+	addMember(t, roomID, owner, "owner")
+	addMember(t, roomID, admin, "admin")
+	addMember(t, roomID, member, "member")
+	leftMember := addMember(t, roomID, left, "member")
+	if err := database.DB.Model(leftMember).Update("left_at", time.Now()).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		userID   string
+		roomID   string
+		minRole  string
+		wantRole string
+		wantErr  string
+	}{
+		{name: "owner", userID: owner, roomID: roomID, minRole: "admin", wantRole: "owner"},
+		{name: "admin", userID: admin, roomID: roomID, minRole: "admin", wantRole: "admin"},
+		{name: "member", userID: member, roomID: roomID, minRole: "member", wantRole: "member"},
+		{name: "member below admin", userID: member, roomID: roomID, minRole: "admin", wantErr: "permission denied"},
+		{name: "non member", userID: outsider, roomID: roomID, minRole: "member", wantErr: "not in room"},
+		{name: "left member", userID: left, roomID: roomID, minRole: "member", wantErr: "not in room"},
+		{name: "unknown room", userID: owner, roomID: "00000000-0000-0000-0000-000000000000", minRole: "member", wantErr: "room not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := withRoomAuth(tt.minRole, func(s socketio.Conn, ctx *SocketContext, roomID, role, msg string) string {
+				called = true
+				if roomID != tt.roomID || role != tt.wantRole {
+					t.Errorf("handler got room %q role %q", roomID, role)
+				}
+				return successResponse(map[string]bool{"ok": true})
+			})
+
+			ack := handler(newFakeConn(&SocketContext{UserID: tt.userID}), `{"roomId":"`+tt.roomID+`"}`)
+			if tt.wantErr == "" {
+				if !called {
+					t.Fatalf("handler not called, ack %q", ack)
+				}
+				return
+			}
+			if called {
+				t.Fatal("handler should not be called")
+			}
+			if got := ackError(t, ack); got != tt.wantErr {
+				t.Fatalf("expected %q, got %q", tt.wantErr, got)
+			}
+		})
+	}
+}
+
+// TestRoomEventsRejectNonMembers 每个房间内事件对非成员、角色不足的调用者返回统一的错误 Ack
+func TestRoomEventsRejectNonMembers(t *testing.T) {
+	setupDB(t)
+
+	owner := createUser(t, "owner")
+	member := createUser(t, "member")
+	outsider := createUser(t, "outsider")
+	roomID := createRoom(t, owner)
+	addMember(t, roomID, owner, "owner")
+	addMember(t, roomID, member, "member")
+
+	payload := `{"roomId":"` + roomID + `","targetUserId":"` + member + `","content":"hi","status":"learning","action":"kick"}`
+	for event, minRole := range roomScopedEvents {
+		handler := eventHandlers()[event]
+
+		ack := handler(newFakeConn(&SocketContext{UserID: outsider}), payload)
+		if got := ackError(t, ack); got != "not in room" {
+			t.Errorf("%s as non member: expected %q, got %q", event, "not in room", got)
+		}
+
+		ack = handler(newFakeConn(&SocketContext{UserID: outsider}), `{"roomId":"00000000-0000-0000-0000-000000000000"}`)
+		if got := ackError(t, ack); got != "room not found" {
+			t.Errorf("%s in unknown room: expected %q, got %q", event, "room not found", got)
+		}
+
+		if minRole != "member" {
+			ack = handler(newFakeConn(&SocketContext{UserID: member}), payload)
+			if got := ackError(t, ack); got != errPermissionDenied {
+				t.Errorf("%s as member: expected %q, got %q", event, errPermissionDenied, got)
+			}
+		}
+	}
+}
+
+// TestInviteToPrivateRoomRequiresAdmin 私密房间只有房主和管理员可以邀请
+func TestInviteToPrivateRoomRequiresAdmin(t *testing.T) {
+	setupDB(t)
+
+	owner := createUser(t, "owner")
+	member := createUser(t, "member")
+	friend := createUser(t, "friend")
+	roomID := createRoom(t, owner)
+	addMember(t, roomID, owner, "owner")
+	addMember(t, roomID, member, "member")
+	if err := database.DB.Model(&model.Room{}).Where("id = ?", roomID).Update("is_private", true).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	ack := eventHandlers()["invite_to_room"](newFakeConn(&SocketContext{UserID: member}),
+		`{"roomId":"`+roomID+`","targetUserId":"`+friend+`"}`)
+	if got := ackError(t, ack); got != errPermissionDenied {
+		t.Fatalf("expected %q, got %q", errPermissionDenied, got)
+	}
+}
@@ -0,0 +1,110 @@
+package socket
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"os"
+
+	socketio "github.com/googollee/go-socket.io"
+	"github.com/redis/go-redis/v9"
+)
+
+// 多实例部署：所有服务端推送都经过 broadcastEvent / evictFromRoom，
+// 本机直接投递的同时发布到 Redis，其他实例收到后投递给自己的连接。
+// 不使用 go-socket.io 自带的 Redis Adapter (依赖 redigo，且要求在注册事件前配置)。
+const (
+	clusterBroadcastChannel = "socket:broadcast"
+	clusterEvictChannel     = "socket:evict"
+)
+
+// ClusterEnabled SOCKET_ADAPTER=redis 时开启多实例模式
+// 多实例模式只允许 websocket 传输：polling 的会话保存在单个实例内存里，需要负载均衡做粘性会话
+func ClusterEnabled() bool {
+	return os.Getenv("SOCKET_ADAPTER") == "redis"
+}
+
+// clusterBroadcast 跨实例广播的消息，room 为房间 ID 或以 UserID 命名的私有房间
+type clusterBroadcast struct {
+	Origin string          `json:"origin"`
+	Room   string          `json:"room"`
+	Event  string          `json:"event"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// clusterEvict 跨实例的移出房间请求 (踢出 / 封禁)
+type clusterEvict struct {
+	Origin string `json:"origin"`
+	UserID string `json:"userId"`
+	RoomID string `json:"roomId"`
+}
+
+// clusterBus 一个 Socket.IO 实例与 Redis 之间的桥，每个实例一个
+type clusterBus struct {
+	server     *socketio.Server
+	rdb        *redis.Client
+	instanceID string
+}
+
+// bus 为 nil 时是单实例模式
+var bus *clusterBus
+
+func newClusterBus(server *socketio.Server, rdb *redis.Client) *clusterBus {
+	b := make([]byte, 8)
+	rand.Read(b)
+	host, _ := os.Hostname()
+	return &clusterBus{server: server, rdb: rdb, instanceID: host + "-" + hex.EncodeToString(b)}
+}
+
+// run 订阅其他实例的广播并投递到本机连接，ctx 取消时退出
+func (c *clusterBus) run(ctx context.Context) {
+	sub := c.rdb.Subscribe(ctx, clusterBroadcastChannel, clusterEvictChannel)
+	go func() {
+		<-ctx.Done()
+		sub.Close() // 关闭后 Channel() 随之关闭，循环退出
+	}()
+
+	for msg := range sub.Channel() {
+		switch msg.Channel {
+		case clusterBroadcastChannel:
+			var m clusterBroadcast
+			if err := json.Unmarshal([]byte(msg.Payload), &m); err != nil || m.Origin == c.instanceID {
+				continue
+			}
+			c.server.BroadcastToRoom("/", m.Room, m.Event, m.Data)
+		case clusterEvictChannel:
+			var m clusterEvict
+			if err := json.Unmarshal([]byte(msg.Payload), &m); err != nil || m.Origin == c.instanceID {
+				continue
+			}
+			evictLocal(c.server, m.UserID, m.RoomID)
+		}
+	}
+}
+
+// broadcast 本机投递并发布给其他实例
+func (c *clusterBus) broadcast(room, event string, data interface{}) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("[SocketCluster] Failed to encode %s: %v", event, err)
+		return
+	}
+	c.server.BroadcastToRoom("/", room, event, json.RawMessage(raw))
+
+	payload, _ := json.Marshal(clusterBroadcast{Origin: c.instanceID, Room: room, Event: event, Data: raw})
+	if err := c.rdb.Publish(context.Background(), clusterBroadcastChannel, payload).Err(); err != nil {
+		log.Printf("[SocketCluster] Failed to publish %s: %v", event, err)
+	}
+}
+
+// evict 本机移出并通知其他实例
+func (c *clusterBus) evict(userID, roomID string) {
+	evictLocal(c.server, userID, roomID)
+
+	payload, _ := json.Marshal(clusterEvict{Origin: c.instanceID, UserID: userID, RoomID: roomID})
+	if err := c.rdb.Publish(context.Background(), clusterEvictChannel, payload).Err(); err != nil {
+		log.Printf("[SocketCluster] Failed to publish evict: %v", err)
+	}
+}
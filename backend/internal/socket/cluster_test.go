@@ -0,0 +1,150 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	socketio "github.com/googollee/go-socket.io"
+	"github.com/redis/go-redis/v9"
+)
+
+// registerClusterTestHandlers 跳过 Token 与在线状态，按 query 里的 uid / room 直接加入房间
+func registerClusterTestHandlers(server *socketio.Server) {
+	server.OnConnect("/", func(s socketio.Conn) error {
+		query := s.URL()
+		uid, room := query.Query().Get("uid"), query.Query().Get("room")
+		s.SetContext(&SocketContext{UserID: uid, RoomID: room})
+		s.Join(uid)
+		s.Join(room)
+		return nil
+	})
+}
+
+// waitSubscribers 等待两个实例都订阅了广播和移出频道
+func waitSubscribers(t *testing.T, rdb *redis.Client, baseline map[string]int64) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		counts, err := rdb.PubSubNumSub(context.Background(), clusterBroadcastChannel, clusterEvictChannel).Result()
+		if err == nil && counts[clusterBroadcastChannel] >= baseline[clusterBroadcastChannel]+2 &&
+			counts[clusterEvictChannel] >= baseline[clusterEvictChannel]+2 {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("cluster buses did not subscribe")
+}
+
+// eventsUntil 收集事件直到 marker 事件出现
+func (c *testClient) eventsUntil(marker string, timeout time.Duration) ([]testEvent, bool) {
+	var got []testEvent
+	deadline := time.After(timeout)
+	for {
+		select {
+		case e := <-c.events:
+			if e.Name == marker {
+				return got, true
+			}
+			got = append(got, e)
+		case <-deadline:
+			return got, false
+		}
+	}
+}
+
+func countEvents(events []testEvent, name string) int {
+	n := 0
+	for _, e := range events {
+		if e.Name == name {
+			n++
+		}
+	}
+	return n
+}
+
+// TestClusterFanOut 两个进程内实例通过本地 Redis 互相转发广播和移出房间
+func TestClusterFanOut(t *testing.T) {
+	rdb := setupRedis(t)
+	baseline, err := rdb.PubSubNumSub(context.Background(), clusterBroadcastChannel, clusterEvictChannel).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverA, tsA := startServer(t, registerClusterTestHandlers)
+	serverB, tsB := startServer(t, registerClusterTestHandlers)
+	busA := newClusterBus(serverA, rdb)
+	busB := newClusterBus(serverB, rdb)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go busA.run(ctx)
+	go busB.run(ctx)
+	waitSubscribers(t, rdb, baseline)
+
+	const roomID = "cluster-room"
+	onA := dialClient(t, tsA, url.Values{"uid": {"user-a"}, "room": {roomID}})
+	onB := dialClient(t, tsB, url.Values{"uid": {"user-b"}, "room": {roomID}})
+	// 连接建立是异步的，等两个实例都把连接加入房间
+	deadline := time.Now().Add(3 * time.Second)
+	for serverA.RoomLen("/", roomID) == 0 || serverB.RoomLen("/", roomID) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("clients did not join the room")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Run("broadcast", func(t *testing.T) {
+		busA.broadcast(roomID, "new_message", map[string]string{"content": "hello"})
+		busA.broadcast("user-b", "marker", nil)
+
+		events, ok := onB.eventsUntil("marker", 3*time.Second)
+		if !ok {
+			t.Fatal("instance B did not receive the broadcast")
+		}
+		if countEvents(events, "new_message") != 1 {
+			t.Fatalf("instance B got %d new_message, want 1", countEvents(events, "new_message"))
+		}
+		var data map[string]string
+		json.Unmarshal(events[0].Data, &data)
+		if data["content"] != "hello" {
+			t.Fatalf("unexpected payload %s", events[0].Data)
+		}
+
+		// 发出实例只投递一次，不会再从 Redis 收到自己的消息
+		busB.broadcast("user-a", "marker", nil)
+		events, ok = onA.eventsUntil("marker", 3*time.Second)
+		if !ok || countEvents(events, "new_message") != 1 {
+			t.Fatalf("instance A got %d new_message, want 1", countEvents(events, "new_message"))
+		}
+	})
+
+	t.Run("evict", func(t *testing.T) {
+		busA.evict("user-b", roomID)
+
+		deadline := time.Now().Add(3 * time.Second)
+		for serverB.RoomLen("/", roomID) != 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("instance B did not evict the connection")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		serverB.ForEach("/", "user-b", func(c socketio.Conn) {
+			if ctx, ok := socketContext(c); ok && ctx.RoomID != "" {
+				t.Errorf("context still in room %q", ctx.RoomID)
+			}
+		})
+
+		// 被移出后收不到房间广播，私有房间仍然可达
+		busA.broadcast(roomID, "after_evict", nil)
+		busA.broadcast("user-b", "marker", nil)
+		events, ok := onB.eventsUntil("marker", 3*time.Second)
+		if !ok {
+			t.Fatal("instance B did not receive the marker")
+		}
+		if countEvents(events, "after_evict") != 0 {
+			t.Fatal("evicted connection still receives room broadcasts")
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package socket
+
+import (
+	"backend/internal/dto"
+	"backend/internal/service"
+	"log"
+	"sync"
+	"time"
+
+	socketio "github.com/googollee/go-socket.io"
+)
+
+// localConns 本实例上已鉴权的连接 (SocketID -> Conn)，用于定时刷新在线心跳
+var localConns sync.Map
+
+func trackConn(s socketio.Conn) {
+	localConns.Store(s.ID(), s)
+}
+
+func untrackConn(s socketio.Conn) {
+	localConns.Delete(s.ID())
+}
+
+// presenceHeartbeat 定时刷新本实例所有连接的心跳，实例崩溃后这些连接会在 PresenceTTL 后自动失效
+func presenceHeartbeat() {
+	ticker := time.NewTicker(service.PresenceHeartbeatInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		var conns []service.PresenceConn
+		localConns.Range(func(_, value interface{}) bool {
+			s := value.(socketio.Conn)
+			if ctx, ok := socketContext(s); ok {
+				conns = append(conns, service.PresenceConn{UserID: ctx.UserID, SocketID: s.ID(), RoomID: ctx.RoomID})
+			}
+			return true
+		})
+		if err := presenceService.Heartbeat(conns); err != nil {
+			log.Printf("Presence heartbeat failed: %v", err)
+		}
+	}
+}
+
+// leaveRoom 连接离开房间，是用户在房间内的最后一个连接时才写 LeftAt 并广播 user_left
+func leaveRoom(userID, roomID, socketID string) error {
+	last, err := presenceService.LeaveRoom(userID, roomID, socketID)
+	if err != nil {
+		log.Printf("Presence leave failed for user %s: %v", userID, err)
+	}
+	if !last {
+		return nil
+	}
+
+	if err := roomService.LeaveRoom(userID, roomID); err != nil {
+		return err
+	}
+	broadcastEvent(roomID, "user_left", dto.UserLeftEvent{
+		UserID: userID,
+	})
+	return nil
+}
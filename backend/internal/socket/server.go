@@ -4,14 +4,13 @@ import (
 	"backend/internal/dto"
 	"backend/internal/model"
 	"backend/internal/service"
-	"backend/pkg/utils"
+	"backend/pkg/database"
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	socketio "github.com/googollee/go-socket.io"
 	"github.com/googollee/go-socket.io/engineio"
 	"github.com/googollee/go-socket.io/engineio/transport"
@@ -24,6 +23,9 @@ var roomService service.RoomService
 var userService service.UserService // 需要获取用户信息
 var messageService service.MessageService
 var notificationService service.NotificationService
+var moderationService service.RoomModerationService
+var roomMessageService service.RoomMessageService
+var presenceService service.PresenceService
 
 // 辅助结构体，存入 Context
 type SocketContext struct {
@@ -32,75 +34,138 @@ type SocketContext struct {
 }
 
 // InitSocket 初始化 Socket.IO 服务
+// SOCKET_ADAPTER=redis 时为多实例模式 (需要先 InitRedis)，客户端需使用 transports: ['websocket']
 func InitSocket() {
-	var err error
-	Server = socketio.NewServer(&engineio.Options{
-		Transports: []transport.Transport{
-			&polling.Transport{
-				CheckOrigin: func(r *http.Request) bool {
-					return true
-				},
+	Server = newServer(ClusterEnabled())
+
+	if ClusterEnabled() {
+		bus = newClusterBus(Server, database.RDB)
+		go bus.run(context.Background())
+		log.Printf("Socket.IO cluster mode enabled, instance %s", bus.instanceID)
+	}
+
+	registerHandlers(Server)
+
+	// 让后台任务 (番茄钟调度等) 可以通过 Socket 推送事件
+	service.SetEmitter(broadcastEvent)
+	service.SetRoomEvictor(evictFromRoom)
+
+	go presenceHeartbeat()
+	go service.StartPresenceSweeper()
+	go Server.Serve()
+	log.Println("Socket.IO server started")
+}
+
+// newServer 创建 Socket.IO 服务，websocketOnly 时不开启 polling 传输
+func newServer(websocketOnly bool) *socketio.Server {
+	transports := []transport.Transport{
+		&polling.Transport{
+			CheckOrigin: func(r *http.Request) bool {
+				return true
 			},
-			&websocket.Transport{
-				CheckOrigin: func(r *http.Request) bool {
-					return true
-				},
+		},
+		&websocket.Transport{
+			CheckOrigin: func(r *http.Request) bool {
+				return true
 			},
 		},
+	}
+	if websocketOnly {
+		transports = transports[1:]
+	}
+	return socketio.NewServer(&engineio.Options{
+		Transports: transports,
 	})
-	if err != nil {
-		log.Fatal(err)
+}
+
+// registerHandlers 注册连接、断开和所有客户端事件
+func registerHandlers(server *socketio.Server) {
+	server.OnConnect("/", onConnect)
+	for event, handler := range eventHandlers() {
+		server.OnEvent("/", event, handler)
 	}
+	server.OnDisconnect("/", onDisconnect)
+}
 
-	// --- 1. 连接鉴权 (Middleware) ---
-	Server.OnConnect("/", func(s socketio.Conn) error {
-		url := s.URL()
-		token := url.Query().Get("token")
+// eventHandlers 客户端事件及其鉴权方式 (事件名 -> Ack 处理函数)
+// 房间内事件通过 withRoomAuth 校验成员身份和最低角色，其余事件只要求连接已鉴权
+func eventHandlers() map[string]func(socketio.Conn, string) string {
+	return map[string]func(socketio.Conn, string) string{
+		"join_room":            withAuth(onJoinRoom),
+		"leave_room":           withRoomAuth("member", onLeaveRoom),
+		"send_message":         withRoomAuth("member", onSendMessage),
+		"update_status":        withRoomAuth("member", onUpdateStatus),
+		"moderate_member":      withRoomAuth("admin", onModerateMember),
+		"set_presence":         withAuth(onSetPresence),
+		"invite_to_room":       withRoomAuth("member", onInviteToRoom),
+		"send_private_message": withAuth(onSendPrivateMessage),
+	}
+}
 
-		if token == "" {
-			return nil // 拒绝连接
-		}
+// --- 1. 连接鉴权 (Middleware) ---
+// 返回 error 时 go-socket.io 会关闭连接，Token 缺失或无效一律拒绝
+func onConnect(s socketio.Conn) error {
+	userID, err := authenticate(s)
+	if err != nil {
+		log.Printf("Socket %s rejected: %v", s.ID(), err)
+		return err
+	}
 
-		claims, err := utils.ParseToken(token)
-		if err != nil {
-			return nil // Token 无效，拒绝
-		}
+	// 初始化 Context
+	s.SetContext(&SocketContext{
+		UserID: userID,
+		RoomID: "",
+	})
+	log.Printf("User %s connected, SocketID: %s", userID, s.ID())
 
-		// 初始化 Context
-		s.SetContext(&SocketContext{
-			UserID: claims.UserID,
-			RoomID: "",
-		})
-		log.Printf("User %s connected, SocketID: %s", claims.UserID, s.ID())
+	// 自动加入一个以 UserID 命名的房间
+	s.Join(userID)
 
-		// 自动加入一个以 UserID 命名的房间
-		s.Join(claims.UserID)
+	// 多端在线计数，第一个连接时通知好友上线
+	trackConn(s)
+	if _, err := presenceService.Connect(userID, s.ID()); err != nil {
+		log.Printf("Presence connect failed for user %s: %v", userID, err)
+	}
 
-		return nil
-	})
+	return nil
+}
 
-	// --- 2. 事件: join_room ---
-	Server.OnEvent("/", "join_room", func(s socketio.Conn, msg string) string {
-		var payload dto.JoinRoomPayload
-		if err := json.Unmarshal([]byte(msg), &payload); err != nil {
-			return errorResponse("invalid payload")
-		}
+// --- 2. 事件: join_room ---
+// 还不是成员，只要求鉴权；密码、人数、封禁由 JoinRoom 校验
+func onJoinRoom(s socketio.Conn, ctx *SocketContext, msg string) string {
+	var payload dto.JoinRoomPayload
+	if err := json.Unmarshal([]byte(msg), &payload); err != nil || payload.RoomID == "" {
+		return errorResponse(errInvalidPayload)
+	}
+
+	userID := ctx.UserID
 
-		ctx := s.Context().(*SocketContext)
-		userID := ctx.UserID
+	// 业务逻辑：写库 (校验密码、人数)
+	if err := roomService.JoinRoom(userID, payload.RoomID, payload.Password); err != nil {
+		return errorResponse(err.Error())
+	}
 
-		// 业务逻辑：写库 (校验密码、人数)
-		if err := roomService.JoinRoom(userID, payload.RoomID, payload.Password); err != nil {
-			return errorResponse(err.Error())
+	// 一个连接同时只在一个房间内，切换房间时先离开旧房间 (释放计数、写 LeftAt)
+	if old := ctx.RoomID; old != "" && old != payload.RoomID {
+		s.Leave(old)
+		if err := leaveRoom(userID, old, s.ID()); err != nil {
+			log.Printf("Leave room failed for user %s: %v", userID, err)
 		}
+	}
 
-		// 更新 Context，记录当前房间
-		ctx.RoomID = payload.RoomID
+	// 更新 Context，记录当前房间
+	ctx.RoomID = payload.RoomID
 
-		// Socket 逻辑：加入房间
-		s.Join(payload.RoomID)
+	// Socket 逻辑：加入房间
+	s.Join(payload.RoomID)
+
+	// 补发最近的聊天记录，晚加入或重连的用户也能看到上下文
+	if history, err := roomMessageService.RecentMessages(payload.RoomID, service.RoomHistoryReplayLimit); err == nil {
+		s.Emit("room_history", dto.RoomHistoryEvent{RoomID: payload.RoomID, Messages: history})
+	}
 
-		// 广播给房间其他人
+	// 广播给房间其他人 (同一用户的其他设备已经在房间内时不重复广播)
+	if first, _ := presenceService.JoinRoom(userID, payload.RoomID, s.ID()); first {
 		user, _ := userService.GetProfile(userID)
 		broadcastEvent(payload.RoomID, "user_joined", dto.UserJoinedEvent{
 			User: dto.UserSimple{
@@ -109,209 +174,254 @@ func InitSocket() {
 				AvatarURL: user.AvatarUrl,
 			},
 		})
+	}
 
-		return successResponse(gin.H{"message": "joined"})
-	})
+	return successResponse(gin.H{"message": "joined"})
+}
 
-	// --- 3. 事件: leave_room ---
-	Server.OnEvent("/", "leave_room", func(s socketio.Conn, msg string) string {
-		var payload dto.JoinRoomPayload
-		json.Unmarshal([]byte(msg), &payload)
-		
-		ctx := s.Context().(*SocketContext)
-		userID := ctx.UserID
-
-		// 业务逻辑
-		roomService.LeaveRoom(userID, payload.RoomID)
-		
-		// 清理 Context
-		if ctx.RoomID == payload.RoomID {
-			ctx.RoomID = ""
-		}
+// --- 3. 事件: leave_room ---
+// 只有该用户在房间内的最后一个连接离开时才写 LeftAt 并广播
+func onLeaveRoom(s socketio.Conn, ctx *SocketContext, roomID, role, msg string) string {
+	// 清理 Context
+	if ctx.RoomID == roomID {
+		ctx.RoomID = ""
+	}
 
-		// Socket 逻辑
-		s.Leave(payload.RoomID)
+	// Socket 逻辑
+	s.Leave(roomID)
 
-		// 广播
-		broadcastEvent(payload.RoomID, "user_left", dto.UserLeftEvent{
-			UserID: userID,
-		})
+	// 业务逻辑
+	if err := leaveRoom(ctx.UserID, roomID, s.ID()); err != nil {
+		return errorResponse(err.Error())
+	}
 
-		return successResponse(gin.H{"ok": true})
-	})
+	return successResponse(gin.H{"ok": true})
+}
+
+// --- 4. 事件: send_message ---
+func onSendMessage(s socketio.Conn, ctx *SocketContext, roomID, role, msg string) string {
+	var payload dto.SendMessagePayload
+	if err := json.Unmarshal([]byte(msg), &payload); err != nil {
+		return errorResponse(errInvalidPayload)
+	}
 
-	// --- 4. 事件: send_message ---
-	Server.OnEvent("/", "send_message", func(s socketio.Conn, msg string) string {
-		var payload dto.SendMessagePayload
-		json.Unmarshal([]byte(msg), &payload)
-		
-		ctx := s.Context().(*SocketContext)
-		userID := ctx.UserID
+	// 持久化 (校验内容、是否被禁言)
+	eventData, err := roomMessageService.SaveMessage(ctx.UserID, roomID, payload.Content)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
 
-		// 获取发送者信息
-		user, _ := userService.GetProfile(userID)
+	// 广播
+	broadcastEvent(roomID, "new_message", eventData)
 
-		// 构造消息对象
-		eventData := dto.NewMessageEvent{
-			ID:        uuid.New().String(),
-			Content:   payload.Content,
-			CreatedAt: time.Now(),
-			Sender: dto.UserSimple{
-				ID:        user.ID,
-				Nickname:  user.Nickname,
-				AvatarURL: user.AvatarUrl,
-			},
-		}
+	return successResponse(gin.H{"ok": true})
+}
 
-		// 广播
-		broadcastEvent(payload.RoomID, "new_message", eventData)
+// --- 5. 事件: update_status ---
+func onUpdateStatus(s socketio.Conn, ctx *SocketContext, roomID, role, msg string) string {
+	var payload dto.UpdateStatusPayload
+	if err := json.Unmarshal([]byte(msg), &payload); err != nil {
+		return errorResponse(errInvalidPayload)
+	}
+	switch payload.Status {
+	case model.RoomStatusLearning, model.RoomStatusRest, model.RoomStatusIdle:
+	default:
+		return errorResponse("invalid status")
+	}
 
-		return successResponse(gin.H{"ok": true})
-	})
+	userID := ctx.UserID
 
-	// --- 5. 事件: update_status ---
-	Server.OnEvent("/", "update_status", func(s socketio.Conn, msg string) string {
-		var payload dto.UpdateStatusPayload
-		json.Unmarshal([]byte(msg), &payload)
-		
-		ctx := s.Context().(*SocketContext)
-		userID := ctx.UserID
-
-		// 业务逻辑
-		roomService.UpdateStatus(userID, payload.RoomID, payload.Status)
-
-		// 广播
-		broadcastEvent(payload.RoomID, "status_updated", dto.StatusUpdatedEvent{
-			UserID: userID,
-			Status: payload.Status,
-		})
+	// 业务逻辑
+	if err := roomService.UpdateStatus(userID, roomID, payload.Status); err != nil {
+		return errorResponse(err.Error())
+	}
 
-		return successResponse(gin.H{"ok": true})
+	// 广播
+	broadcastEvent(roomID, "status_updated", dto.StatusUpdatedEvent{
+		UserID: userID,
+		Status: payload.Status,
 	})
 
-	// --- 5.1 事件: invite_to_room ---
-	Server.OnEvent("/", "invite_to_room", func(s socketio.Conn, msg string) string {
-		var payload dto.InviteRoomPayload
-		if err := json.Unmarshal([]byte(msg), &payload); err != nil {
-			return errorResponse("invalid payload")
-		}
+	return successResponse(gin.H{"ok": true})
+}
 
-		ctx := s.Context().(*SocketContext)
-		userID := ctx.UserID
+// --- 5.0 事件: moderate_member (踢出 / 封禁 / 禁言，仅房主和管理员) ---
+func onModerateMember(s socketio.Conn, ctx *SocketContext, roomID, role, msg string) string {
+	var payload dto.ModerateMemberPayload
+	if err := json.Unmarshal([]byte(msg), &payload); err != nil || payload.TargetUserID == "" {
+		return errorResponse(errInvalidPayload)
+	}
 
-		// 获取发送者信息
-		sender, err := userService.GetProfile(userID)
-		if err != nil {
-			return errorResponse("user not found")
-		}
+	if err := moderationService.Moderate(ctx.UserID, payload); err != nil {
+		return errorResponse(err.Error())
+	}
 
-		// 获取房间信息
-		roomResp, err := roomService.GetRoom(payload.RoomID)
-		if err != nil {
-			return errorResponse("room not found")
-		}
+	return successResponse(gin.H{"ok": true})
+}
 
-		// 创建数据库通知
-		content := sender.Nickname + " invited you to join room: " + roomResp.Name
-		notif, err := notificationService.CreateNotification(
-			payload.TargetUserID,
-			model.NotificationTypeInvite,
-			"Room Invitation",
-			content,
-			&roomResp.ID,
-		)
-
-		if err == nil {
-			// 发送新通知事件给目标用户
-			broadcastEvent(payload.TargetUserID, "new_notification", dto.NewNotificationEvent{
-				Notification: *notif,
-			})
-		}
+// --- 5.0.1 事件: set_presence (手动设置离开 / 在线) ---
+func onSetPresence(s socketio.Conn, ctx *SocketContext, msg string) string {
+	var payload dto.SetPresencePayload
+	if err := json.Unmarshal([]byte(msg), &payload); err != nil {
+		return errorResponse(errInvalidPayload)
+	}
+	if payload.Status != model.PresenceOnline && payload.Status != model.PresenceAway {
+		return errorResponse("invalid status")
+	}
 
-		// (可选) 兼容旧版前端事件
-		broadcastEvent(payload.TargetUserID, "room_invite", dto.RoomInviteEvent{
-			RoomID:   roomResp.ID,
-			RoomName: roomResp.Name,
-			Sender: dto.UserSimple{
-				ID:        sender.ID,
-				Nickname:  sender.Nickname,
-				AvatarURL: sender.AvatarUrl,
-			},
+	if err := presenceService.SetAway(ctx.UserID, payload.Status == model.PresenceAway); err != nil {
+		return errorResponse(err.Error())
+	}
+
+	return successResponse(gin.H{"ok": true})
+}
+
+// --- 5.1 事件: invite_to_room ---
+// 房间成员都可以邀请，私密房间只有房主和管理员可以邀请
+func onInviteToRoom(s socketio.Conn, ctx *SocketContext, roomID, role, msg string) string {
+	var payload dto.InviteRoomPayload
+	if err := json.Unmarshal([]byte(msg), &payload); err != nil || payload.TargetUserID == "" {
+		return errorResponse(errInvalidPayload)
+	}
+
+	userID := ctx.UserID
+
+	// 获取发送者信息
+	sender, err := userService.GetProfile(userID)
+	if err != nil {
+		return errorResponse("user not found")
+	}
+
+	// 获取房间信息
+	roomResp, err := roomService.GetRoom(roomID)
+	if err != nil {
+		return errorResponse("room not found")
+	}
+	if roomResp.IsPrivate && role == "member" {
+		return errorResponse(errPermissionDenied)
+	}
+
+	// 创建数据库通知
+	content := sender.Nickname + " invited you to join room: " + roomResp.Name
+	notif, err := notificationService.CreateNotification(
+		payload.TargetUserID,
+		model.NotificationTypeInvite,
+		"Room Invitation",
+		content,
+		&roomResp.ID,
+	)
+
+	if err == nil {
+		// 发送新通知事件给目标用户
+		broadcastEvent(payload.TargetUserID, "new_notification", dto.NewNotificationEvent{
+			Notification: *notif,
 		})
+	}
 
-		return successResponse(gin.H{"ok": true})
+	// (可选) 兼容旧版前端事件
+	broadcastEvent(payload.TargetUserID, "room_invite", dto.RoomInviteEvent{
+		RoomID:   roomResp.ID,
+		RoomName: roomResp.Name,
+		Sender: dto.UserSimple{
+			ID:        sender.ID,
+			Nickname:  sender.Nickname,
+			AvatarURL: sender.AvatarUrl,
+		},
 	})
 
-	// --- 5.2 事件: send_private_message ---
-	Server.OnEvent("/", "send_private_message", func(s socketio.Conn, msg string) string {
-		var payload dto.SendPrivateMessagePayload
-		if err := json.Unmarshal([]byte(msg), &payload); err != nil {
-			return errorResponse("invalid payload")
-		}
+	return successResponse(gin.H{"ok": true})
+}
 
-		ctx := s.Context().(*SocketContext)
-		userID := ctx.UserID
+// --- 5.2 事件: send_private_message ---
+func onSendPrivateMessage(s socketio.Conn, ctx *SocketContext, msg string) string {
+	var payload dto.SendPrivateMessagePayload
+	if err := json.Unmarshal([]byte(msg), &payload); err != nil || payload.ReceiverID == "" || payload.Content == "" {
+		return errorResponse(errInvalidPayload)
+	}
 
-		// 保存消息到数据库
-		savedMsg, err := messageService.SaveMessage(userID, payload.ReceiverID, payload.Content)
-		if err != nil {
-			return errorResponse("failed to save message")
-		}
+	userID := ctx.UserID
 
-		// 发送给接收者 (private room is their UserID)
-		broadcastEvent(payload.ReceiverID, "receive_private_message", dto.PrivateMessageEvent{
-			Message: *savedMsg,
-		})
+	// 保存消息到数据库
+	savedMsg, err := messageService.SaveMessage(userID, payload.ReceiverID, payload.Content)
+	if err != nil {
+		return errorResponse("failed to save message")
+	}
 
-		// 同时也发回给发送者，确保多端同步，如果发送者在其他设备登录的话
-		broadcastEvent(userID, "receive_private_message", dto.PrivateMessageEvent{
-			Message: *savedMsg,
-		})
+	// 发送给接收者 (private room is their UserID)
+	broadcastEvent(payload.ReceiverID, "receive_private_message", dto.PrivateMessageEvent{
+		Message: *savedMsg,
+	})
 
-		return successResponse(gin.H{"ok": true})
+	// 同时也发回给发送者，确保多端同步，如果发送者在其他设备登录的话
+	broadcastEvent(userID, "receive_private_message", dto.PrivateMessageEvent{
+		Message: *savedMsg,
 	})
 
-	// --- 6. 断开连接 (修复逻辑) ---
-	Server.OnDisconnect("/", func(s socketio.Conn, reason string) {
-		// 检查 Context
-		if s.Context() == nil {
-			return
-		}
-		
-		ctx, ok := s.Context().(*SocketContext)
-		if !ok {
-			return
-		}
-		
-		userID := ctx.UserID
-		log.Printf("User %s disconnected: %s", userID, reason)
-
-		// 如果用户在房间里，执行离开逻辑
-		if ctx.RoomID != "" {
-			log.Printf("Auto leaving room %s for user %s", ctx.RoomID, userID)
-			
-			// 1. 业务逻辑离开
-			roomService.LeaveRoom(userID, ctx.RoomID)
-			
-			// 2. 广播 (虽然 Socket 断了发不出去给自己，但可以发给房间里其他人)
-			// 注意：go-socket.io 此时连接已断，Server.BroadcastToRoom 依然有效
-			broadcastEvent(ctx.RoomID, "user_left", dto.UserLeftEvent{
-				UserID: userID,
-			})
+	return successResponse(gin.H{"ok": true})
+}
+
+// --- 6. 断开连接 (修复逻辑) ---
+func onDisconnect(s socketio.Conn, reason string) {
+	// 检查 Context
+	if s.Context() == nil {
+		return
+	}
+
+	ctx, ok := s.Context().(*SocketContext)
+	if !ok {
+		return
+	}
+
+	userID := ctx.UserID
+	log.Printf("User %s disconnected: %s", userID, reason)
+
+	// 如果用户在房间里，执行离开逻辑 (其他设备还在房间内时只减少计数)
+	// 广播时连接已断，但 Server.BroadcastToRoom 对房间里其他人依然有效
+	if ctx.RoomID != "" {
+		log.Printf("Auto leaving room %s for user %s", ctx.RoomID, userID)
+		if err := leaveRoom(userID, ctx.RoomID, s.ID()); err != nil {
+			log.Printf("Leave room failed for user %s: %v", userID, err)
 		}
-	})
+	}
 
-	go Server.Serve()
-	log.Println("Socket.IO server started")
+	// 最后一个连接断开时通知好友下线
+	untrackConn(s)
+	if _, err := presenceService.Disconnect(userID, s.ID()); err != nil {
+		log.Printf("Presence disconnect failed for user %s: %v", userID, err)
+	}
 }
 
 // 辅助：广播事件
+// 多实例模式下同时发布到 Redis，其他实例上的连接也能收到
 func broadcastEvent(roomID, event string, data interface{}) {
+	if bus != nil {
+		bus.broadcast(roomID, event, data)
+		return
+	}
 	// go-socket.io 的 BroadcastTo 是把数据转 json 发送
 	// 注意：go-socket.io v1.x 和 v2/v4 行为略有不同，这里使用标准库行为
 	Server.BroadcastToRoom("/", roomID, event, data)
 }
 
+// 辅助：让用户的所有连接离开房间 (被踢出 / 封禁)，每个连接都加入了以 UserID 命名的私有房间
+func evictFromRoom(userID, roomID string) {
+	if bus != nil {
+		bus.evict(userID, roomID)
+		return
+	}
+	evictLocal(Server, userID, roomID)
+}
+
+// evictLocal 只处理本实例上的连接
+func evictLocal(server *socketio.Server, userID, roomID string) {
+	server.ForEach("/", userID, func(c socketio.Conn) {
+		if ctx, ok := c.Context().(*SocketContext); ok && ctx.RoomID == roomID {
+			ctx.RoomID = ""
+		}
+		c.Leave(roomID)
+	})
+}
+
 // 辅助：Ack 响应
 func successResponse(data interface{}) string {
 	b, _ := json.Marshal(data)
@@ -0,0 +1,278 @@
+package socket
+
+import (
+	"backend/internal/model"
+	"backend/pkg/database"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	socketio "github.com/googollee/go-socket.io"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// 依赖外部服务的测试通过环境变量开启，未设置时跳过：
+//
+//	TEST_DATABASE_DSN="host=localhost user=postgres password=password dbname=mydb_test port=5432 sslmode=disable"
+//	TEST_REDIS_ADDR="localhost:6379"
+
+// setupDB 连接测试库并建表
+func setupDB(t *testing.T) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("connect database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate database: %v", err)
+	}
+	database.DB = db
+}
+
+// setupRedis 连接本地 Redis
+func setupRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR not set")
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("connect redis: %v", err)
+	}
+	t.Cleanup(func() { rdb.Close() })
+	return rdb
+}
+
+func createUser(t *testing.T, nickname string) string {
+	t.Helper()
+	user := model.User{
+		Email:        fmt.Sprintf("socket-test-%d-%s@example.com", time.Now().UnixNano(), nickname),
+		PasswordHash: "x",
+		Nickname:     nickname,
+	}
+	if err := database.DB.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	t.Cleanup(func() { database.DB.Delete(&model.User{}, "id = ?", user.ID) })
+	return user.ID
+}
+
+func createRoom(t *testing.T, creatorID string) string {
+	t.Helper()
+	room := model.Room{Name: "socket test", CreatorID: creatorID, MaxMembers: 10}
+	if err := database.DB.Create(&room).Error; err != nil {
+		t.Fatalf("create room: %v", err)
+	}
+	t.Cleanup(func() {
+		database.DB.Where("room_id = ?", room.ID).Delete(&model.RoomMember{})
+		database.DB.Delete(&model.Room{}, "id = ?", room.ID)
+	})
+	return room.ID
+}
+
+func addMember(t *testing.T, roomID, userID, role string) *model.RoomMember {
+	t.Helper()
+	member := model.RoomMember{RoomID: roomID, UserID: userID, Role: role, Status: model.RoomStatusIdle, JoinedAt: time.Now()}
+	if err := database.DB.Create(&member).Error; err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	return &member
+}
+
+// fakeConn 实现 socketio.Conn，用于直接调用事件处理函数
+type fakeConn struct {
+	id     string
+	url    url.URL
+	header http.Header
+	ctx    interface{}
+	rooms  map[string]bool
+}
+
+func newFakeConn(ctx interface{}) *fakeConn {
+	return &fakeConn{id: "fake", header: http.Header{}, ctx: ctx, rooms: map[string]bool{}}
+}
+
+func (c *fakeConn) Close() error                            { return nil }
+func (c *fakeConn) Context() interface{}                    { return c.ctx }
+func (c *fakeConn) SetContext(ctx interface{})              { c.ctx = ctx }
+func (c *fakeConn) Namespace() string                       { return "/" }
+func (c *fakeConn) Emit(eventName string, v ...interface{}) {}
+func (c *fakeConn) Join(room string)                        { c.rooms[room] = true }
+func (c *fakeConn) Leave(room string)                       { delete(c.rooms, room) }
+func (c *fakeConn) LeaveAll()                               { c.rooms = map[string]bool{} }
+func (c *fakeConn) ID() string                              { return c.id }
+func (c *fakeConn) URL() url.URL                            { return c.url }
+func (c *fakeConn) LocalAddr() net.Addr                     { return nil }
+func (c *fakeConn) RemoteAddr() net.Addr                    { return nil }
+func (c *fakeConn) RemoteHeader() http.Header               { return c.header }
+
+func (c *fakeConn) Rooms() []string {
+	rooms := make([]string, 0, len(c.rooms))
+	for r := range c.rooms {
+		rooms = append(rooms, r)
+	}
+	return rooms
+}
+
+var _ socketio.Conn = (*fakeConn)(nil)
+
+// ackError 解析 Ack，要求是 {"error": "..."} 的格式
+func ackError(t *testing.T, ack string) string {
+	t.Helper()
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(ack), &body); err != nil {
+		t.Fatalf("ack %q is not json: %v", ack, err)
+	}
+	msg, ok := body["error"].(string)
+	if !ok || len(body) != 1 {
+		t.Fatalf("ack %q is not an error response", ack)
+	}
+	return msg
+}
+
+// startServer 启动一个 Socket.IO 实例，register 为空时注册正式的事件处理函数
+func startServer(t *testing.T, register func(*socketio.Server)) (*socketio.Server, *httptest.Server) {
+	t.Helper()
+	server := newServer(true)
+	if register == nil {
+		register = registerHandlers
+	}
+	register(server)
+	go server.Serve()
+
+	ts := httptest.NewServer(server)
+	t.Cleanup(func() {
+		ts.Close()
+		server.Close()
+	})
+	return server, ts
+}
+
+type testEvent struct {
+	Name string
+	Data json.RawMessage
+}
+
+// testClient 最简的 Socket.IO (Engine.IO v3) websocket 客户端
+type testClient struct {
+	ws     *websocket.Conn
+	mu     sync.Mutex
+	nextID int
+	acks   map[int]chan string
+	events chan testEvent
+	closed chan struct{}
+}
+
+func dialClient(t *testing.T, ts *httptest.Server, query url.Values) *testClient {
+	t.Helper()
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("EIO", "3")
+	query.Set("transport", "websocket")
+	u := "ws" + strings.TrimPrefix(ts.URL, "http") + "/socket.io/?" + query.Encode()
+
+	ws, _, err := websocket.DefaultDialer.Dial(u, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	c := &testClient{
+		ws:     ws,
+		acks:   map[int]chan string{},
+		events: make(chan testEvent, 64),
+		closed: make(chan struct{}),
+	}
+	t.Cleanup(func() { ws.Close() })
+	go c.readLoop()
+	return c
+}
+
+func (c *testClient) write(msg string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ws.WriteMessage(websocket.TextMessage, []byte(msg))
+}
+
+func (c *testClient) readLoop() {
+	defer close(c.closed)
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		msg := string(data)
+		switch {
+		case msg == "2":
+			c.write("3")
+		case strings.HasPrefix(msg, "42"):
+			var args []json.RawMessage
+			if json.Unmarshal([]byte(msg[2:]), &args) != nil || len(args) == 0 {
+				continue
+			}
+			var name string
+			json.Unmarshal(args[0], &name)
+			event := testEvent{Name: name}
+			if len(args) > 1 {
+				event.Data = args[1]
+			}
+			c.events <- event
+		case strings.HasPrefix(msg, "43"):
+			rest := msg[2:]
+			i := strings.IndexByte(rest, '[')
+			id, err := strconv.Atoi(rest[:max(i, 0)])
+			if err != nil {
+				continue
+			}
+			var args []string
+			json.Unmarshal([]byte(rest[i:]), &args)
+			c.mu.Lock()
+			ch := c.acks[id]
+			c.mu.Unlock()
+			if ch != nil && len(args) > 0 {
+				ch <- args[0]
+			}
+		}
+	}
+}
+
+// emit 发送事件并等待 Ack，连接被关闭或超时返回 false
+func (c *testClient) emit(event, msg string) (string, bool) {
+	c.mu.Lock()
+	c.nextID++
+	id := c.nextID
+	ch := make(chan string, 1)
+	c.acks[id] = ch
+	c.mu.Unlock()
+
+	args, _ := json.Marshal([]string{event, msg})
+	if err := c.write(fmt.Sprintf("42%d%s", id, args)); err != nil {
+		return "", false
+	}
+	select {
+	case ack := <-ch:
+		return ack, true
+	case <-c.closed:
+		return "", false
+	case <-time.After(3 * time.Second):
+		return "", false
+	}
+}
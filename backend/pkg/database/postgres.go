@@ -51,12 +51,26 @@ func InitDB() {
 
 	log.Println("Database connected successfully")
 
+	if err = Migrate(DB); err != nil {
+		log.Fatal("Failed to migrate database: ", err)
+	}
+
+	log.Println("Database migration completed")
+}
+
+// Migrate 建表并补充手动维护的索引 (测试也用它初始化测试库)
+func Migrate(db *gorm.DB) error {
 	// 自动迁移模式 (类似 Prisma db push)
 	// 注意：生产环境建议使用专门的 migration 工具 (如 golang-migrate)
-	err = DB.AutoMigrate(
+	err := db.AutoMigrate(
 		&model.User{},
 		&model.Friend{},
 		&model.StudySession{},
+		&model.StudySessionPause{},
+		&model.StudySessionAudit{},
+		&model.SessionSyncBatch{},
+		&model.SessionFlag{},
+		&model.PomodoroPlan{},
 		&model.Blog{},
 		&model.BlogLike{},
 		&model.BlogBookmark{},
@@ -66,6 +80,25 @@ func InitDB() {
 		&model.AIReport{},
 		&model.RefreshToken{},
 		&model.DailyStat{},
+		&model.XPEvent{},
+		&model.RankingSeason{},
+		&model.LeaderboardSnapshot{},
+		&model.LeaderboardEntry{},
+		&model.UserStreak{},
+		&model.StreakPeriod{},
+		&model.StreakFreeze{},
+		&model.UserAchievement{},
+		&model.UserQuest{},
+		&model.Challenge{},
+		&model.ChallengeParticipant{},
+		&model.RoomSchedule{},
+		&model.RoomScheduleRSVP{},
+		&model.RoomBan{},
+		&model.RoomMute{},
+		&model.RoomModerationLog{},
+		&model.RoomMessage{},
+		&model.StudyGoal{},
+		&model.GoalRecord{},
 		&model.Tag{},
 		&model.UserTagStat{},
 		&model.Message{},
@@ -73,14 +106,13 @@ func InitDB() {
 	)
 
 	if err != nil {
-		log.Fatal("Failed to migrate database: ", err)
+		return err
 	}
 
 	// 手动添加部分唯一索引 (Partial Unique Index)
 	// 确保每个用户只能有一个 end_time 为 NULL 的活跃会话
-	DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_one_active_session_per_user 
+	db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_one_active_session_per_user 
              ON study_sessions (user_id) 
              WHERE end_time IS NULL`)
-
-	log.Println("Database migration completed")
+	return nil
 }